@@ -0,0 +1,223 @@
+// Command apitoken creates, lists and revokes API tokens. It reads the
+// storage settings the same way the server does: the file named by
+// --config or CONFIG_FILE, then environment variables.
+//
+//	apitoken create --name ci --role developer --user u1
+//	apitoken create --name backend-lead --role team_lead --team backend
+//	apitoken list
+//	apitoken revoke --id 3
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/terps489/avito_tech_internship/internal/app"
+	"github.com/terps489/avito_tech_internship/internal/config"
+	"github.com/terps489/avito_tech_internship/internal/domain"
+	"github.com/terps489/avito_tech_internship/internal/repository/postgres"
+	"github.com/terps489/avito_tech_internship/internal/repository/sqlite"
+)
+
+const usage = `usage: apitoken [--config FILE] <command> [flags]
+
+commands:
+  create --name NAME --role admin|team_lead|developer [--team TEAM] [--user USER_ID]
+  list
+  revoke --id ID`
+
+func main() {
+	if err := run(context.Background(), os.Args[1:]); err != nil {
+		fmt.Fprintln(os.Stderr, "apitoken:", err)
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("apitoken", flag.ContinueOnError)
+	fs.Usage = func() { fmt.Fprintln(os.Stderr, usage) }
+	configPath := fs.String("config", "", "YAML configuration file")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return errors.New("no command given")
+	}
+
+	var configArgs []string
+	if *configPath != "" {
+		configArgs = []string{"--config", *configPath}
+	}
+	cfg, _, err := config.Load(configArgs, os.LookupEnv)
+	if err != nil {
+		return err
+	}
+
+	svc, closeDB, err := openService(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = closeDB() }()
+
+	ctx = app.WithActor(ctx, "cli")
+	cmd, cmdArgs := fs.Arg(0), fs.Args()[1:]
+	switch cmd {
+	case "create":
+		return create(ctx, svc, cmdArgs)
+	case "list":
+		return list(ctx, svc)
+	case "revoke":
+		return revoke(ctx, svc, cmdArgs)
+	default:
+		fs.Usage()
+		return fmt.Errorf("unknown command %q", cmd)
+	}
+}
+
+// openService builds a service with only the repositories token
+// management touches.
+func openService(ctx context.Context, cfg config.Config) (*app.Service, func() error, error) {
+	var (
+		db    *sql.DB
+		err   error
+		repos app.Repositories
+	)
+
+	switch cfg.Storage {
+	case config.StoragePostgres:
+		if db, err = postgres.Connect(ctx, postgresConfig(cfg)); err != nil {
+			return nil, nil, err
+		}
+		repos = app.Repositories{
+			Users:     postgres.NewUserRepository(db),
+			Teams:     postgres.NewTeamRepository(db),
+			Audit:     postgres.NewAuditRepository(db),
+			APITokens: postgres.NewAPITokenRepository(db),
+			Tx:        postgres.NewTxManager(db),
+		}
+	case config.StorageSQLite:
+		if db, err = sqlite.Open(cfg.SQLite.Path); err != nil {
+			return nil, nil, err
+		}
+		repos = app.Repositories{
+			Users:     sqlite.NewUserRepository(db),
+			Teams:     sqlite.NewTeamRepository(db),
+			Audit:     sqlite.NewAuditRepository(db),
+			APITokens: sqlite.NewAPITokenRepository(db),
+			Tx:        sqlite.NewTxManager(db),
+		}
+	default:
+		return nil, nil, fmt.Errorf("tokens of %s storage cannot be managed from another process; use auth.bootstrap_token", cfg.Storage)
+	}
+
+	return app.NewService(repos), db.Close, nil
+}
+
+func create(ctx context.Context, svc *app.Service, args []string) error {
+	fs := flag.NewFlagSet("create", flag.ContinueOnError)
+	name := fs.String("name", "", "what the token is for")
+	role := fs.String("role", "", "admin, team_lead or developer")
+	team := fs.String("team", "", "team a team_lead manages")
+	user := fs.String("user", "", "user the token acts as; required for developers")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	raw, t, err := svc.CreateAPIToken(ctx, domain.APIToken{
+		Name:     *name,
+		Role:     domain.Role(*role),
+		TeamName: domain.TeamName(*team),
+		UserID:   domain.UserID(*user),
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "created token %d (%s, %s); it is shown only once:\n", t.ID, t.Name, t.Role)
+	fmt.Println(raw)
+	return nil
+}
+
+func list(ctx context.Context, svc *app.Service) error {
+	tokens, err := svc.ListAPITokens(ctx)
+	if err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tNAME\tROLE\tTEAM\tUSER\tCREATED\tREVOKED")
+	for _, t := range tokens {
+		revoked := "-"
+		if t.RevokedAt != nil {
+			revoked = t.RevokedAt.Format(time.RFC3339)
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			t.ID, t.Name, t.Role, orDash(string(t.TeamName)), orDash(string(t.UserID)),
+			t.CreatedAt.Format(time.RFC3339), revoked)
+	}
+	return tw.Flush()
+}
+
+func revoke(ctx context.Context, svc *app.Service, args []string) error {
+	fs := flag.NewFlagSet("revoke", flag.ContinueOnError)
+	id := fs.Int64("id", 0, "token id, as shown by list")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	t, err := svc.RevokeAPIToken(ctx, *id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("token %d not found", *id)
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "revoked token %d (%s)\n", t.ID, t.Name)
+	return nil
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// postgresConfig mirrors the conversion in cmd/app.
+func postgresConfig(cfg config.Config) postgres.Config {
+	p := cfg.Postgres
+	return postgres.Config{
+		DSN:      p.DSN,
+		Host:     p.Host,
+		Port:     p.Port,
+		User:     p.User,
+		Password: p.Password,
+		DBName:   p.Name,
+		TLS: postgres.TLS{
+			Mode:     p.TLS.Mode,
+			RootCert: p.TLS.RootCert,
+			Cert:     p.TLS.Cert,
+			Key:      p.TLS.Key,
+		},
+		Pool: postgres.Pool{
+			MaxOpenConns:    p.Pool.MaxOpenConns,
+			MaxIdleConns:    p.Pool.MaxIdleConns,
+			ConnMaxLifetime: p.Pool.ConnMaxLifetime,
+			ConnMaxIdleTime: p.Pool.ConnMaxIdleTime,
+		},
+		Retry: postgres.Retry{
+			Attempts:     p.Connect.Attempts,
+			InitialDelay: p.Connect.Backoff,
+			MaxDelay:     p.Connect.MaxBackoff,
+			PingTimeout:  p.Connect.PingTimeout,
+		},
+	}
+}
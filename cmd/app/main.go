@@ -1,34 +1,393 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
 	"log"
+	"log/slog"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
 
 	"github.com/terps489/avito_tech_internship/internal/app"
+	"github.com/terps489/avito_tech_internship/internal/config"
+	"github.com/terps489/avito_tech_internship/internal/domain"
 	httpTransport "github.com/terps489/avito_tech_internship/internal/http"
+	"github.com/terps489/avito_tech_internship/internal/jwtauth"
+	"github.com/terps489/avito_tech_internship/internal/metrics"
+	"github.com/terps489/avito_tech_internship/internal/outbox"
+	"github.com/terps489/avito_tech_internship/internal/pubsub"
+	"github.com/terps489/avito_tech_internship/internal/ratelimit"
+	"github.com/terps489/avito_tech_internship/internal/repository/memory"
 	"github.com/terps489/avito_tech_internship/internal/repository/postgres"
+	"github.com/terps489/avito_tech_internship/internal/repository/sqlite"
+	"github.com/terps489/avito_tech_internship/internal/tracing"
+	"github.com/terps489/avito_tech_internship/internal/webhook"
 )
 
 func main() {
-	db, err := postgres.NewFromEnv()
+	os.Exit(run())
+}
+
+// run returns the exit code instead of exiting, so that deferred cleanup,
+// such as flushing traces, runs on failures too.
+func run() int {
+	var (
+		service      *app.Service
+		outboxStore  outbox.Store
+		webhookStore webhook.Store
+		readiness    []httpTransport.ReadinessCheck
+		closeDB      func(context.Context) error
+	)
+
+	// log.Printf calls elsewhere go through the same JSON handler.
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+	slog.SetDefault(logger)
+
+	cfg, printConfig, err := config.Load(os.Args[1:], os.LookupEnv)
+	if errors.Is(err, flag.ErrHelp) {
+		return 0
+	}
 	if err != nil {
-		log.Fatalf("failed to init postgres: %v", err)
+		log.Printf("failed to load config: %v", err)
+		return 1
+	}
+	if printConfig {
+		if err := config.Print(os.Stdout, cfg); err != nil {
+			log.Printf("failed to print config: %v", err)
+			return 1
+		}
+		return 0
 	}
 
+	// Spans go to stderr so that they stay apart from the logs.
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.Tracing.Exporter, os.Stderr)
+	if err != nil {
+		log.Printf("failed to init tracing: %v", err)
+		return 1
+	}
 	defer func() {
-		if err := db.Close(); err != nil {
-			log.Printf("failed to close db: %v", err)
+		if err := shutdownTracing(context.Background()); err != nil {
+			log.Printf("failed to flush traces: %v", err)
 		}
 	}()
 
-	userRepo := postgres.NewUserRepository(db)
-	teamRepo := postgres.NewTeamRepository(db)
-	prRepo := postgres.NewPullRequestRepository(db)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	var (
+		hub      *pubsub.Hub
+		registry *metrics.Registry
+	)
+	serviceOpts := []app.Option{app.WithAssignment(appAssignment(cfg))}
+	if cfg.Features.Events {
+		hub = pubsub.NewHub(1024)
+		serviceOpts = append(serviceOpts, app.WithPublisher(hub))
+	}
+	if cfg.Features.Metrics {
+		registry = metrics.NewRegistry()
+		serviceOpts = append(serviceOpts, app.WithMetrics(metrics.NewBusiness(registry)))
+	}
+
+	switch cfg.Storage {
+	case config.StorageMemory:
+		store := memory.NewStore()
+		outboxRepo := memory.NewOutboxRepository(store)
+		webhookRepo := memory.NewWebhookRepository(store)
+
+		service = app.NewService(app.Repositories{
+			Users:            memory.NewUserRepository(store),
+			Teams:            memory.NewTeamRepository(store),
+			PullRequests:     memory.NewPullRequestRepository(store),
+			Audit:            memory.NewAuditRepository(store),
+			ReviewerEvents:   memory.NewReviewerEventRepository(store),
+			Outbox:           outboxRepo,
+			Webhooks:         webhookRepo,
+			ExternalAccounts: memory.NewExternalAccountRepository(store),
+			APITokens:        memory.NewAPITokenRepository(store),
+			IdempotencyKeys:  memory.NewIdempotencyRepository(store),
+			Tx:               store,
+		}, serviceOpts...)
+		outboxStore = outboxRepo
+		webhookStore = webhookRepo
+		log.Printf("using in-memory storage, data will be lost on restart")
+
+	case config.StorageSQLite:
+		db, err := sqlite.Open(cfg.SQLite.Path)
+		if err != nil {
+			log.Printf("failed to init sqlite: %v", err)
+			return 1
+		}
+		closeDB = func(context.Context) error { return db.Close() }
+
+		if registry != nil {
+			metrics.RegisterDBStats(registry, db)
+		}
+		readiness = []httpTransport.ReadinessCheck{
+			{Name: "database", Check: db.PingContext},
+			{Name: "migrations", Check: func(ctx context.Context) error { return sqlite.CheckSchema(ctx, db) }},
+		}
+
+		outboxRepo := sqlite.NewOutboxRepository(db)
+		webhookRepo := sqlite.NewWebhookRepository(db)
+		service = app.NewService(app.Repositories{
+			Users:            sqlite.NewUserRepository(db),
+			Teams:            sqlite.NewTeamRepository(db),
+			PullRequests:     sqlite.NewPullRequestRepository(db),
+			Audit:            sqlite.NewAuditRepository(db),
+			ReviewerEvents:   sqlite.NewReviewerEventRepository(db),
+			Outbox:           outboxRepo,
+			Webhooks:         webhookRepo,
+			ExternalAccounts: sqlite.NewExternalAccountRepository(db),
+			APITokens:        sqlite.NewAPITokenRepository(db),
+			IdempotencyKeys:  sqlite.NewIdempotencyRepository(db),
+			Tx:               sqlite.NewTxManager(db),
+		}, serviceOpts...)
+		outboxStore = outboxRepo
+		webhookStore = webhookRepo
+
+	case config.StoragePostgres:
+		db, err := postgres.Connect(ctx, postgresConfig(cfg))
+		if err != nil {
+			log.Printf("failed to init postgres: %v", err)
+			return 1
+		}
+		closeDB = func(context.Context) error { return db.Close() }
+
+		if registry != nil {
+			metrics.RegisterDBStats(registry, db)
+		}
+		readiness = []httpTransport.ReadinessCheck{
+			{Name: "database", Check: db.PingContext},
+			{Name: "migrations", Check: func(ctx context.Context) error { return postgres.CheckSchema(ctx, db) }},
+		}
+
+		outboxRepo := postgres.NewOutboxRepository(db)
+		webhookRepo := postgres.NewWebhookRepository(db)
+		service = app.NewService(app.Repositories{
+			Users:            postgres.NewUserRepository(db),
+			Teams:            postgres.NewTeamRepository(db),
+			PullRequests:     postgres.NewPullRequestRepository(db),
+			Audit:            postgres.NewAuditRepository(db),
+			ReviewerEvents:   postgres.NewReviewerEventRepository(db),
+			Outbox:           outboxRepo,
+			Webhooks:         webhookRepo,
+			ExternalAccounts: postgres.NewExternalAccountRepository(db),
+			APITokens:        postgres.NewAPITokenRepository(db),
+			IdempotencyKeys:  postgres.NewIdempotencyRepository(db),
+			Tx:               postgres.NewTxManager(db),
+		}, serviceOpts...)
+		outboxStore = outboxRepo
+		webhookStore = webhookRepo
 
-	service := app.NewService(userRepo, teamRepo, prRepo)
+	}
 
-	server := httpTransport.NewServer(":8080", service)
+	if token := cfg.Auth.BootstrapToken; token != "" {
+		if _, err := service.EnsureAPIToken(ctx, token, domain.APIToken{Name: "bootstrap", Role: domain.RoleAdmin}); err != nil {
+			log.Printf("failed to register bootstrap token: %v", err)
+			return 1
+		}
+	}
+
+	// Everything that can fail goes before the workers start, so that an
+	// early return does not leave them running.
+	var jwtVerifier *jwtauth.Verifier
+	if cfg.Auth.Enabled && cfg.Auth.JWT.JWKS != "" {
+		if jwtVerifier, err = jwtauth.New(ctx, jwtConfig(cfg)); err != nil {
+			log.Printf("failed to load jwks: %v", err)
+			return 1
+		}
+	}
+
+	var sinks []outbox.Sink
+	if cfg.Features.Webhooks {
+		sinks = append(sinks, webhook.NewFanoutSink(webhookStore))
+	}
+	if url := cfg.Integrations.OutboxWebhookURL; url != "" {
+		sinks = append(sinks, outbox.NewWebhookSink("webhook", url, 5*time.Second))
+	}
+
+	dispatcher, err := outbox.NewDispatcher(outboxStore, sinks, outbox.DefaultConfig())
+	if err != nil {
+		log.Printf("failed to init outbox dispatcher: %v", err)
+		return 1
+	}
+	var workers sync.WaitGroup
+	workers.Add(1)
+	go func() {
+		defer workers.Done()
+		dispatcher.Run(ctx)
+	}()
+	if cfg.Features.Webhooks {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			webhook.NewWorker(webhookStore, webhook.DefaultConfig()).Run(ctx)
+		}()
+	}
+	if cfg.HTTP.IdempotencyTTL > 0 {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			purgeIdempotencyKeys(ctx, service, time.Hour)
+		}()
+	}
+
+	opts := []httpTransport.Option{
+		httpTransport.WithLogger(logger),
+		httpTransport.WithReadinessChecks(readiness...),
+		httpTransport.WithTimeouts(httpTimeouts(cfg)),
+		// Workers stop together with the server; wait for them before
+		// closing the database they use.
+		httpTransport.WithShutdownHook(func(ctx context.Context) error {
+			done := make(chan struct{})
+			go func() {
+				workers.Wait()
+				close(done)
+			}()
+			select {
+			case <-done:
+				return nil
+			case <-ctx.Done():
+				return fmt.Errorf("waiting for background workers: %w", ctx.Err())
+			}
+		}),
+	}
+	if closeDB != nil {
+		opts = append(opts, httpTransport.WithShutdownHook(closeDB))
+	}
+	if cfg.Auth.Enabled {
+		opts = append(opts, httpTransport.WithTokenAuth())
+		if jwtVerifier != nil {
+			opts = append(opts, httpTransport.WithAuthenticator(jwtauth.NewAuthenticator(jwtVerifier, service)))
+		}
+	}
+	if ttl := cfg.HTTP.IdempotencyTTL; ttl > 0 {
+		opts = append(opts, httpTransport.WithIdempotency(ttl))
+	}
+	if cfg.HTTP.RateLimit.Enabled {
+		opts = append(opts, httpTransport.WithRateLimits(httpRateLimits(cfg)))
+	}
+	if hub != nil {
+		opts = append(opts, httpTransport.WithEventHub(hub))
+	}
+	if registry != nil {
+		metrics.RegisterOpenReviews(registry, service)
+		opts = append(opts, httpTransport.WithMetrics(registry))
+	}
+	if secret := cfg.Integrations.GitHubSecret; secret != "" {
+		opts = append(opts, httpTransport.WithGitHubSecret(secret))
+	}
+	if token := cfg.Integrations.GitLabToken; token != "" {
+		opts = append(opts, httpTransport.WithGitLabToken(token))
+	}
+
+	server := httpTransport.NewServer(cfg.HTTP.Addr, service, opts...)
+
+	if err := server.Run(ctx); err != nil {
+		log.Printf("server stopped with error: %v", err)
+		return 1
+	}
+	log.Printf("server stopped")
+	return 0
+}
+
+// purgeIdempotencyKeys deletes expired idempotency keys every interval
+// until ctx is done.
+func purgeIdempotencyKeys(ctx context.Context, service *app.Service, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if n, err := service.PurgeIdempotencyKeys(ctx); err != nil {
+				log.Printf("failed to purge idempotency keys: %v", err)
+			} else if n > 0 {
+				log.Printf("purged %d expired idempotency keys", n)
+			}
+		}
+	}
+}
+
+// The functions below turn the plain configuration into the options of
+// the packages it configures.
+
+func appAssignment(cfg config.Config) app.Assignment {
+	return app.Assignment{
+		Strategy:  app.AssignmentStrategy(cfg.Assignment.Strategy),
+		Reviewers: cfg.Assignment.Reviewers,
+		Seed:      cfg.Assignment.Seed,
+	}
+}
+
+func httpTimeouts(cfg config.Config) httpTransport.Timeouts {
+	return httpTransport.Timeouts{
+		ReadHeader: cfg.HTTP.ReadHeaderTimeout,
+		Read:       cfg.HTTP.ReadTimeout,
+		Write:      cfg.HTTP.WriteTimeout,
+		Idle:       cfg.HTTP.IdleTimeout,
+		DrainDelay: cfg.HTTP.DrainDelay,
+		Shutdown:   cfg.HTTP.ShutdownTimeout,
+	}
+}
+
+func httpRateLimits(cfg config.Config) httpTransport.RateLimits {
+	rl := cfg.HTTP.RateLimit
+	limits := httpTransport.RateLimits{
+		Default:      ratelimit.Limit{Rate: rl.RPS, Burst: rl.Burst},
+		Routes:       make(map[string]ratelimit.Limit, len(rl.Routes)),
+		AuthFailures: ratelimit.Limit{Rate: rl.AuthFailures.RPS, Burst: rl.AuthFailures.Burst},
+	}
+	for route, l := range rl.Routes {
+		limits.Routes[route] = ratelimit.Limit{Rate: l.RPS, Burst: l.Burst}
+	}
+	return limits
+}
+
+func jwtConfig(cfg config.Config) jwtauth.Config {
+	j := cfg.Auth.JWT
+	return jwtauth.Config{
+		JWKS:      j.JWKS,
+		Issuer:    j.Issuer,
+		Audience:  j.Audience,
+		UserClaim: j.UserClaim,
+		Leeway:    j.Leeway,
+	}
+}
 
-	if err := server.Run(); err != nil {
-		log.Fatalf("server stopped with error: %v", err)
+func postgresConfig(cfg config.Config) postgres.Config {
+	p := cfg.Postgres
+	return postgres.Config{
+		DSN:      p.DSN,
+		Host:     p.Host,
+		Port:     p.Port,
+		User:     p.User,
+		Password: p.Password,
+		DBName:   p.Name,
+		TLS: postgres.TLS{
+			Mode:     p.TLS.Mode,
+			RootCert: p.TLS.RootCert,
+			Cert:     p.TLS.Cert,
+			Key:      p.TLS.Key,
+		},
+		Pool: postgres.Pool{
+			MaxOpenConns:    p.Pool.MaxOpenConns,
+			MaxIdleConns:    p.Pool.MaxIdleConns,
+			ConnMaxLifetime: p.Pool.ConnMaxLifetime,
+			ConnMaxIdleTime: p.Pool.ConnMaxIdleTime,
+		},
+		Retry: postgres.Retry{
+			Attempts:     p.Connect.Attempts,
+			InitialDelay: p.Connect.Backoff,
+			MaxDelay:     p.Connect.MaxBackoff,
+			PingTimeout:  p.Connect.PingTimeout,
+		},
 	}
 }
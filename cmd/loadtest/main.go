@@ -4,11 +4,13 @@ package main
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"log"
 	"math/rand"
 	"net/http"
+	"os"
 	"sync/atomic"
 	"time"
 )
@@ -27,6 +29,9 @@ type MergePRRequest struct {
 
 var prCounter uint64
 
+// errRateLimited means the service answered 429.
+var errRateLimited = errors.New("rate limited")
+
 func main() {
 	var durationStr string
 	var rps int
@@ -42,7 +47,8 @@ func main() {
 	log.Printf("Starting load test: duration=%s, rps=%d", dur, rps)
 
 	client := &http.Client{
-		Timeout: 3 * time.Second,
+		Timeout:   3 * time.Second,
+		Transport: bearerTransport{token: os.Getenv("API_TOKEN")},
 	}
 
 	authors := make([]string, 0, 80)
@@ -58,6 +64,7 @@ func main() {
 
 	var success uint64
 	var failed uint64
+	var limited uint64
 
 	for now := range ticker.C {
 		if now.After(stopAt) {
@@ -70,7 +77,12 @@ func main() {
 			id := atomic.AddUint64(&prCounter, 1)
 			prID := fmt.Sprintf("lt-pr-%d", id)
 
-			if err := scenarioCreateAndMerge(client, prID, author); err != nil {
+			err := scenarioCreateAndMerge(client, prID, author)
+			if errors.Is(err, errRateLimited) {
+				atomic.AddUint64(&limited, 1)
+				return
+			}
+			if err != nil {
 				atomic.AddUint64(&failed, 1)
 				return
 			}
@@ -80,7 +92,7 @@ func main() {
 
 	time.Sleep(3 * time.Second)
 
-	log.Printf("Load test finished. Success=%d, Failed=%d", success, failed)
+	log.Printf("Load test finished. Success=%d, Failed=%d, Limited=%d", success, failed, limited)
 }
 
 func scenarioCreateAndMerge(client *http.Client, prID string, author string) error {
@@ -106,6 +118,9 @@ func scenarioCreateAndMerge(client *http.Client, prID string, author string) err
 	if resp.StatusCode == http.StatusConflict {
 		return nil
 	}
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return errRateLimited
+	}
 
 	if resp.StatusCode != http.StatusCreated {
 		return fmt.Errorf("create %s: unexpected status %s", prID, resp.Status)
@@ -125,9 +140,25 @@ func scenarioCreateAndMerge(client *http.Client, prID string, author string) err
 		_ = resp.Body.Close()
 	}()
 
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return errRateLimited
+	}
 	if resp.StatusCode != http.StatusOK {
 		return fmt.Errorf("merge %s: unexpected status %s", prID, resp.Status)
 	}
 
 	return nil
 }
+
+// bearerTransport sends token, an admin API token, with every request.
+type bearerTransport struct {
+	token string
+}
+
+func (t bearerTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	if t.token != "" {
+		r = r.Clone(r.Context())
+		r.Header.Set("Authorization", "Bearer "+t.token)
+	}
+	return http.DefaultTransport.RoundTrip(r)
+}
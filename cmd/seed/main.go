@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"os"
 	"time"
 )
 
@@ -27,7 +28,8 @@ func main() {
 	log.Println("Seeding teams and users...")
 
 	client := &http.Client{
-		Timeout: 5 * time.Second,
+		Timeout:   5 * time.Second,
+		Transport: bearerTransport{token: os.Getenv("API_TOKEN")},
 	}
 
 	userCounter := 1
@@ -88,3 +90,16 @@ func main() {
 
 	log.Printf("Done. Total users created: %d", userCounter-1)
 }
+
+// bearerTransport sends token, an admin API token, with every request.
+type bearerTransport struct {
+	token string
+}
+
+func (t bearerTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	if t.token != "" {
+		r = r.Clone(r.Context())
+		r.Header.Set("Authorization", "Bearer "+t.token)
+	}
+	return http.DefaultTransport.RoundTrip(r)
+}
@@ -0,0 +1,52 @@
+package app
+
+import (
+	"fmt"
+	"math/rand"
+	"time"
+)
+
+// AssignmentStrategy names the way reviewers are picked from the author's
+// team. Only AssignmentRandom exists so far.
+type AssignmentStrategy string
+
+const AssignmentRandom AssignmentStrategy = "random"
+
+// Assignment configures automatic reviewer assignment.
+type Assignment struct {
+	Strategy AssignmentStrategy
+	// Reviewers is how many reviewers a new pull request gets, if the team
+	// has enough active members.
+	Reviewers int
+	// Seed makes the random picks reproducible; 0 seeds from the clock.
+	Seed int64
+}
+
+func DefaultAssignment() Assignment {
+	return Assignment{
+		Strategy:  AssignmentRandom,
+		Reviewers: 2,
+	}
+}
+
+func (a Assignment) Validate() error {
+	if a.Strategy != AssignmentRandom {
+		return fmt.Errorf("unknown assignment strategy %q, expected %q", a.Strategy, AssignmentRandom)
+	}
+	if a.Reviewers < 1 {
+		return fmt.Errorf("reviewers per pull request must be positive, got %d", a.Reviewers)
+	}
+	return nil
+}
+
+// WithAssignment replaces DefaultAssignment. a is expected to be valid.
+func WithAssignment(a Assignment) Option {
+	return func(s *Service) {
+		s.reviewersPerPR = a.Reviewers
+		seed := a.Seed
+		if seed == 0 {
+			seed = time.Now().UnixNano()
+		}
+		s.rnd = rand.New(rand.NewSource(seed))
+	}
+}
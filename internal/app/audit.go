@@ -0,0 +1,166 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/terps489/avito_tech_internship/internal/domain"
+)
+
+func (s *Service) ListAuditEvents(ctx context.Context, filter domain.AuditFilter) ([]domain.AuditEvent, error) {
+	ctx, span := tracer.Start(ctx, "Service.ListAuditEvents")
+	defer span.End()
+
+	if err := requireAnyTeamLead(ctx); err != nil {
+		return nil, err
+	}
+	return s.audit.List(ctx, filter)
+}
+
+// recordAudit appends an audit event. It must run inside the transaction
+// of the change it describes.
+func (s *Service) recordAudit(
+	ctx context.Context,
+	action domain.AuditAction,
+	entity domain.AuditEntity,
+	entityID string,
+	before, after any,
+) error {
+	e := &domain.AuditEvent{
+		Actor:      ActorFromContext(ctx),
+		Action:     action,
+		EntityType: entity,
+		EntityID:   entityID,
+		RequestID:  RequestIDFromContext(ctx),
+	}
+
+	var err error
+	if e.Before, err = marshalSnapshot(before); err != nil {
+		return err
+	}
+	if e.After, err = marshalSnapshot(after); err != nil {
+		return err
+	}
+
+	return s.audit.Append(ctx, e)
+}
+
+func marshalSnapshot(v any) ([]byte, error) {
+	if v == nil {
+		return nil, nil
+	}
+	return json.Marshal(v)
+}
+
+// ---------- Snapshots ----------
+
+type userSnapshot struct {
+	UserID   domain.UserID   `json:"user_id"`
+	Username string          `json:"username"`
+	TeamName domain.TeamName `json:"team_name"`
+	IsActive bool            `json:"is_active"`
+}
+
+type teamSnapshot struct {
+	TeamName domain.TeamName `json:"team_name"`
+	Members  []userSnapshot  `json:"members"`
+}
+
+type pullRequestSnapshot struct {
+	PullRequestID domain.PullRequestID `json:"pull_request_id"`
+	Title         string               `json:"pull_request_name"`
+	AuthorID      domain.UserID        `json:"author_id"`
+	Status        domain.PRStatus      `json:"status"`
+	ReviewerIDs   []domain.UserID      `json:"assigned_reviewers"`
+	MergedAt      *time.Time           `json:"merged_at,omitempty"`
+}
+
+// webhookSnapshot leaves out the secret, which must not end up in the audit log.
+type webhookSnapshot struct {
+	WebhookID  int64              `json:"webhook_id"`
+	URL        string             `json:"url"`
+	EventTypes []domain.EventType `json:"event_types"`
+	IsActive   bool               `json:"is_active"`
+}
+
+type redeliverySnapshot struct {
+	DeliveryID   int64 `json:"delivery_id"`
+	RedeliveryOf int64 `json:"redelivery_of"`
+	EventID      int64 `json:"event_id"`
+}
+
+// apiTokenSnapshot leaves out the hash.
+type apiTokenSnapshot struct {
+	TokenID   int64           `json:"token_id"`
+	Name      string          `json:"name"`
+	Role      domain.Role     `json:"role"`
+	TeamName  domain.TeamName `json:"team_name,omitempty"`
+	UserID    domain.UserID   `json:"user_id,omitempty"`
+	RevokedAt *time.Time      `json:"revoked_at,omitempty"`
+}
+
+type externalAccountSnapshot struct {
+	Provider domain.ExternalProvider `json:"provider"`
+	Login    string                  `json:"login"`
+	UserID   domain.UserID           `json:"user_id"`
+}
+
+func snapshotUser(u *domain.User) *userSnapshot {
+	return &userSnapshot{
+		UserID:   u.ID,
+		Username: u.Username,
+		TeamName: u.TeamName,
+		IsActive: u.IsActive,
+	}
+}
+
+func snapshotTeam(t *domain.Team, members []domain.User) *teamSnapshot {
+	snap := &teamSnapshot{
+		TeamName: t.Name,
+		Members:  make([]userSnapshot, 0, len(members)),
+	}
+	for i := range members {
+		snap.Members = append(snap.Members, *snapshotUser(&members[i]))
+	}
+	return snap
+}
+
+func snapshotPullRequest(pr *domain.PullRequest) *pullRequestSnapshot {
+	return &pullRequestSnapshot{
+		PullRequestID: pr.ID,
+		Title:         pr.Title,
+		AuthorID:      pr.AuthorID,
+		Status:        pr.Status,
+		ReviewerIDs:   append([]domain.UserID{}, pr.ReviewerIDs...),
+		MergedAt:      pr.MergedAt,
+	}
+}
+
+func snapshotWebhook(w *domain.Webhook) *webhookSnapshot {
+	return &webhookSnapshot{
+		WebhookID:  w.ID,
+		URL:        w.URL,
+		EventTypes: w.EventTypes,
+		IsActive:   w.IsActive,
+	}
+}
+
+func snapshotExternalAccount(a *domain.ExternalAccount) *externalAccountSnapshot {
+	return &externalAccountSnapshot{
+		Provider: a.Provider,
+		Login:    a.Login,
+		UserID:   a.UserID,
+	}
+}
+
+func snapshotAPIToken(t *domain.APIToken) *apiTokenSnapshot {
+	return &apiTokenSnapshot{
+		TokenID:   t.ID,
+		Name:      t.Name,
+		Role:      t.Role,
+		TeamName:  t.TeamName,
+		UserID:    t.UserID,
+		RevokedAt: t.RevokedAt,
+	}
+}
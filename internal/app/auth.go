@@ -0,0 +1,339 @@
+package app
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/terps489/avito_tech_internship/internal/domain"
+)
+
+var (
+	ErrUnauthenticated = errors.New("missing, unknown or revoked bearer token")
+	ErrForbidden       = errors.New("the api token does not allow this")
+	ErrInvalidAPIToken = errors.New("invalid api token")
+)
+
+// apiTokenPrefix marks our tokens, so that secret scanners can find
+// leaked ones.
+const apiTokenPrefix = "rvw_"
+
+// minAPITokenLength keeps tokens passed to EnsureAPIToken from being
+// guessable.
+const minAPITokenLength = 32
+
+type principalKey struct{}
+
+// WithPrincipal attaches the authenticated caller to ctx. Service methods
+// check it against their authorization rules. Calls without a principal
+// come from trusted code, such as the provider webhooks or the token CLI,
+// and are not restricted.
+func WithPrincipal(ctx context.Context, p domain.Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+func PrincipalFromContext(ctx context.Context) (domain.Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(domain.Principal)
+	return p, ok
+}
+
+// Authenticate returns the principal of the raw bearer token, or
+// ErrUnauthenticated.
+func (s *Service) Authenticate(ctx context.Context, raw string) (domain.Principal, error) {
+	ctx, span := tracer.Start(ctx, "Service.Authenticate")
+	defer span.End()
+
+	t, err := s.apiTokens.GetByHash(ctx, hashAPIToken(raw))
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.Principal{}, ErrUnauthenticated
+	}
+	if err != nil {
+		return domain.Principal{}, err
+	}
+	if t.RevokedAt != nil {
+		return domain.Principal{}, ErrUnauthenticated
+	}
+
+	return domain.Principal{
+		TokenID:  t.ID,
+		Name:     t.Name,
+		Role:     t.Role,
+		TeamName: t.TeamName,
+		UserID:   t.UserID,
+	}, nil
+}
+
+// AuthenticateUser returns the principal for a user vouched for by an
+// external identity provider. It acts as that user, like a developer
+// token, or fails with ErrUnauthenticated if the user is unknown.
+func (s *Service) AuthenticateUser(ctx context.Context, id domain.UserID) (domain.Principal, error) {
+	ctx, span := tracer.Start(ctx, "Service.AuthenticateUser")
+	defer span.End()
+
+	u, err := s.users.GetByID(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.Principal{}, fmt.Errorf("%w: unknown user %s", ErrUnauthenticated, id)
+	}
+	if err != nil {
+		return domain.Principal{}, err
+	}
+
+	return domain.Principal{
+		Name:   "sso",
+		Role:   domain.RoleDeveloper,
+		UserID: u.ID,
+	}, nil
+}
+
+// CreateAPIToken mints a token with the name, role, team and user of
+// spec. The returned secret is not stored and cannot be shown again.
+func (s *Service) CreateAPIToken(ctx context.Context, spec domain.APIToken) (string, *domain.APIToken, error) {
+	ctx, span := tracer.Start(ctx, "Service.CreateAPIToken")
+	defer span.End()
+
+	raw, err := generateAPIToken()
+	if err != nil {
+		return "", nil, err
+	}
+	t, err := s.addAPIToken(ctx, raw, spec)
+	if err != nil {
+		return "", nil, err
+	}
+	return raw, t, nil
+}
+
+// EnsureAPIToken registers raw, chosen by the operator, with the fields of
+// spec unless it is already known. It is meant for a bootstrap admin
+// token on a fresh installation.
+func (s *Service) EnsureAPIToken(ctx context.Context, raw string, spec domain.APIToken) (*domain.APIToken, error) {
+	ctx, span := tracer.Start(ctx, "Service.EnsureAPIToken")
+	defer span.End()
+
+	if len(raw) < minAPITokenLength {
+		return nil, fmt.Errorf("%w: must be at least %d characters", ErrInvalidAPIToken, minAPITokenLength)
+	}
+
+	t, err := s.apiTokens.GetByHash(ctx, hashAPIToken(raw))
+	if err == nil {
+		return t, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	return s.addAPIToken(ctx, raw, spec)
+}
+
+func (s *Service) addAPIToken(ctx context.Context, raw string, spec domain.APIToken) (*domain.APIToken, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+	if err := validateAPIToken(spec); err != nil {
+		return nil, err
+	}
+
+	t := &domain.APIToken{
+		Name:     strings.TrimSpace(spec.Name),
+		Hash:     hashAPIToken(raw),
+		Role:     spec.Role,
+		TeamName: spec.TeamName,
+		UserID:   spec.UserID,
+	}
+
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if t.TeamName != "" {
+			exists, err := s.teams.Exists(ctx, t.TeamName)
+			if err != nil {
+				return err
+			}
+			if !exists {
+				return ErrTeamNotFound
+			}
+		}
+		if t.UserID != "" {
+			u, err := s.users.GetByID(ctx, t.UserID)
+			if err != nil {
+				return err
+			}
+			if t.TeamName != "" && u.TeamName != t.TeamName {
+				return fmt.Errorf("%w: user %s is not in team %s", ErrInvalidAPIToken, u.ID, t.TeamName)
+			}
+		}
+
+		if err := s.apiTokens.Create(ctx, t); err != nil {
+			return err
+		}
+
+		return s.recordAudit(ctx, domain.AuditActionAPITokenCreate, domain.AuditEntityAPIToken,
+			apiTokenEntityID(t.ID), nil, snapshotAPIToken(t))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
+
+func (s *Service) ListAPITokens(ctx context.Context) ([]domain.APIToken, error) {
+	ctx, span := tracer.Start(ctx, "Service.ListAPITokens")
+	defer span.End()
+
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+	return s.apiTokens.List(ctx)
+}
+
+// RevokeAPIToken makes the token unusable. Revoking it again is a no-op.
+func (s *Service) RevokeAPIToken(ctx context.Context, id int64) (*domain.APIToken, error) {
+	ctx, span := tracer.Start(ctx, "Service.RevokeAPIToken")
+	defer span.End()
+
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	var t *domain.APIToken
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		before, err := s.apiTokens.GetByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		if before.RevokedAt != nil {
+			t = before
+			return nil
+		}
+
+		if err := s.apiTokens.Revoke(ctx, id, time.Now()); err != nil {
+			return err
+		}
+		if t, err = s.apiTokens.GetByID(ctx, id); err != nil {
+			return err
+		}
+
+		return s.recordAudit(ctx, domain.AuditActionAPITokenRevoke, domain.AuditEntityAPIToken,
+			apiTokenEntityID(id), snapshotAPIToken(before), snapshotAPIToken(t))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
+
+func validateAPIToken(t domain.APIToken) error {
+	if strings.TrimSpace(t.Name) == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidAPIToken)
+	}
+
+	switch t.Role {
+	case domain.RoleAdmin:
+		if t.TeamName != "" {
+			return fmt.Errorf("%w: admin tokens are not scoped to a team", ErrInvalidAPIToken)
+		}
+	case domain.RoleTeamLead:
+		if t.TeamName == "" {
+			return fmt.Errorf("%w: team_lead tokens need a team", ErrInvalidAPIToken)
+		}
+	case domain.RoleDeveloper:
+		if t.UserID == "" {
+			return fmt.Errorf("%w: developer tokens need a user", ErrInvalidAPIToken)
+		}
+		if t.TeamName != "" {
+			return fmt.Errorf("%w: developer tokens are not scoped to a team", ErrInvalidAPIToken)
+		}
+	default:
+		return fmt.Errorf("%w: unknown role %q, expected admin, team_lead or developer", ErrInvalidAPIToken, t.Role)
+	}
+	return nil
+}
+
+// ---------- Authorization ----------
+
+func requireAdmin(ctx context.Context) error {
+	p, ok := PrincipalFromContext(ctx)
+	if !ok || p.Role == domain.RoleAdmin {
+		return nil
+	}
+	return ErrForbidden
+}
+
+// requireTeamLead allows admins and the lead of team.
+func requireTeamLead(ctx context.Context, team domain.TeamName) error {
+	p, ok := PrincipalFromContext(ctx)
+	if !ok || p.Role == domain.RoleAdmin {
+		return nil
+	}
+	if p.Role == domain.RoleTeamLead && p.TeamName == team {
+		return nil
+	}
+	return ErrForbidden
+}
+
+// requireAnyTeamLead allows admins and the lead of any team.
+func requireAnyTeamLead(ctx context.Context) error {
+	p, ok := PrincipalFromContext(ctx)
+	if !ok || p.Role == domain.RoleAdmin || p.Role == domain.RoleTeamLead {
+		return nil
+	}
+	return ErrForbidden
+}
+
+// requireUser allows admins and whoever acts as id.
+func requireUser(ctx context.Context, id domain.UserID) error {
+	p, ok := PrincipalFromContext(ctx)
+	if !ok || p.Role == domain.RoleAdmin {
+		return nil
+	}
+	if p.UserID != "" && p.UserID == id {
+		return nil
+	}
+	return ErrForbidden
+}
+
+func generateAPIToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return apiTokenPrefix + base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// hashAPIToken needs no salt or stretching: generated tokens carry 256
+// random bits, and EnsureAPIToken rejects short ones.
+func hashAPIToken(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
+
+func apiTokenEntityID(id int64) string {
+	return strconv.FormatInt(id, 10)
+}
+
+// requirePRParticipant allows admins, the author and reviewers of pr and
+// the lead of the author's team.
+func (s *Service) requirePRParticipant(ctx context.Context, pr *domain.PullRequest) error {
+	p, ok := PrincipalFromContext(ctx)
+	if !ok || p.Role == domain.RoleAdmin {
+		return nil
+	}
+	if p.UserID != "" && (p.UserID == pr.AuthorID || slices.Contains(pr.ReviewerIDs, p.UserID)) {
+		return nil
+	}
+	if p.Role != domain.RoleTeamLead {
+		return ErrForbidden
+	}
+	author, err := s.users.GetByID(ctx, pr.AuthorID)
+	if err != nil {
+		return err
+	}
+	return requireTeamLead(ctx, author.TeamName)
+}
@@ -0,0 +1,24 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/terps489/avito_tech_internship/internal/domain"
+)
+
+// emit persists e in the outbox. It must run inside the transaction of
+// the change that produced e, so the event exists if and only if the
+// change was committed.
+func (s *Service) emit(ctx context.Context, e domain.Event) error {
+	payload, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	return s.outbox.Append(ctx, &domain.OutboxEvent{
+		Type:      e.EventType(),
+		Payload:   payload,
+		RequestID: RequestIDFromContext(ctx),
+	})
+}
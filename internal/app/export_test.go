@@ -0,0 +1,8 @@
+package app
+
+import "time"
+
+// SetNow replaces the clock of s.
+func SetNow(s *Service, now func() time.Time) {
+	s.now = now
+}
@@ -0,0 +1,133 @@
+package app
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/terps489/avito_tech_internship/internal/domain"
+)
+
+// SetExternalAccount maps login at provider to userID,
+// replacing any previous mapping of that login.
+func (s *Service) SetExternalAccount(
+	ctx context.Context,
+	provider domain.ExternalProvider,
+	login string,
+	userID domain.UserID,
+) (*domain.ExternalAccount, error) {
+	ctx, span := tracer.Start(ctx, "Service.SetExternalAccount")
+	defer span.End()
+
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+	if err := validateProvider(provider); err != nil {
+		return nil, err
+	}
+
+	a := &domain.ExternalAccount{
+		Provider: provider,
+		Login:    normalizeLogin(login),
+		UserID:   userID,
+	}
+
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if _, err := s.users.GetByID(ctx, userID); err != nil {
+			return err
+		}
+
+		var before any
+		existing, err := s.externalAccounts.Get(ctx, provider, a.Login)
+		switch {
+		case err == nil:
+			before = snapshotExternalAccount(existing)
+		case !errors.Is(err, sql.ErrNoRows):
+			return err
+		}
+
+		if err := s.externalAccounts.Set(ctx, a); err != nil {
+			return err
+		}
+
+		return s.recordAudit(ctx, domain.AuditActionExternalAccountSet, domain.AuditEntityExternalAccount,
+			externalAccountEntityID(provider, a.Login), before, snapshotExternalAccount(a))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return a, nil
+}
+
+func (s *Service) ListExternalAccounts(ctx context.Context, provider domain.ExternalProvider) ([]domain.ExternalAccount, error) {
+	ctx, span := tracer.Start(ctx, "Service.ListExternalAccounts")
+	defer span.End()
+
+	if provider != "" {
+		if err := validateProvider(provider); err != nil {
+			return nil, err
+		}
+	}
+	return s.externalAccounts.List(ctx, provider)
+}
+
+func (s *Service) DeleteExternalAccount(ctx context.Context, provider domain.ExternalProvider, login string) error {
+	ctx, span := tracer.Start(ctx, "Service.DeleteExternalAccount")
+	defer span.End()
+
+	if err := requireAdmin(ctx); err != nil {
+		return err
+	}
+	if err := validateProvider(provider); err != nil {
+		return err
+	}
+	login = normalizeLogin(login)
+
+	return s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		existing, err := s.externalAccounts.Get(ctx, provider, login)
+		if err != nil {
+			return err
+		}
+
+		if err := s.externalAccounts.Delete(ctx, provider, login); err != nil {
+			return err
+		}
+
+		return s.recordAudit(ctx, domain.AuditActionExternalAccountDelete, domain.AuditEntityExternalAccount,
+			externalAccountEntityID(provider, login), snapshotExternalAccount(existing), nil)
+	})
+}
+
+// ResolveExternalLogin returns the user mapped to login at provider,
+// or an error wrapping ErrLoginNotMapped.
+func (s *Service) ResolveExternalLogin(ctx context.Context, provider domain.ExternalProvider, login string) (domain.UserID, error) {
+	ctx, span := tracer.Start(ctx, "Service.ResolveExternalLogin")
+	defer span.End()
+
+	a, err := s.externalAccounts.Get(ctx, provider, normalizeLogin(login))
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", fmt.Errorf("%w: %s login %q", ErrLoginNotMapped, provider, login)
+	}
+	if err != nil {
+		return "", err
+	}
+	return a.UserID, nil
+}
+
+func validateProvider(p domain.ExternalProvider) error {
+	if p != domain.ProviderGitHub && p != domain.ProviderGitLab {
+		return ErrUnknownProvider
+	}
+	return nil
+}
+
+func normalizeLogin(login string) string {
+	return strings.ToLower(strings.TrimSpace(login))
+}
+
+func externalAccountEntityID(provider domain.ExternalProvider, login string) string {
+	return string(provider) + ":" + login
+}
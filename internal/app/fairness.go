@@ -0,0 +1,234 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"slices"
+	"time"
+
+	"github.com/terps489/avito_tech_internship/internal/domain"
+)
+
+// outlierZScore is how far, in standard deviations, a member's count may
+// stray from the expected one before the member is flagged.
+const outlierZScore = 2
+
+// activityChange is a point in time where a member became active or inactive.
+type activityChange struct {
+	At     time.Time
+	Active bool
+}
+
+// GetFairnessReport compares each member's share of the team's reviewer
+// assignments in the window with the share expected from their active
+// time. Active time is rebuilt from the audit log: team.add marks when
+// the members joined and user.set_is_active when they were switched.
+func (s *Service) GetFairnessReport(ctx context.Context, filter domain.FairnessFilter) (*domain.FairnessReport, error) {
+	ctx, span := tracer.Start(ctx, "Service.GetFairnessReport")
+	defer span.End()
+
+	_, members, err := s.GetTeamWithMembers(ctx, filter.TeamName)
+	if err != nil {
+		return nil, err
+	}
+
+	stats, err := s.prs.GetReviewerAssignmentStats(ctx, domain.AssignmentStatsFilter{
+		From:     filter.From,
+		To:       filter.To,
+		TeamName: filter.TeamName,
+		GroupBy:  domain.GroupByUser,
+	})
+	if err != nil {
+		return nil, err
+	}
+	counts := make(map[domain.UserID]int64, len(stats))
+	for _, st := range stats {
+		counts[domain.UserID(st.Key)] = st.Count
+	}
+
+	joined, initial, err := s.teamJoinSnapshot(ctx, filter.TeamName)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &domain.FairnessReport{
+		TeamName: filter.TeamName,
+		From:     filter.From,
+		To:       filter.To,
+		Members:  make([]domain.MemberFairness, 0, len(members)),
+	}
+
+	for _, m := range members {
+		changes, err := s.activityChanges(ctx, m.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		// Before the team existed the member was not part of it; without a
+		// team.add record, fall back to the state before the first switch.
+		active := m.IsActive
+		if len(changes) > 0 {
+			active = !changes[0].Active
+		}
+		if joined != nil {
+			changes = append([]activityChange{{At: *joined, Active: initial[m.ID]}}, changes...)
+			active = false
+		}
+
+		report.Members = append(report.Members, domain.MemberFairness{
+			UserID:      m.ID,
+			Username:    m.Username,
+			ActiveDays:  activeDuration(filter.From, filter.To, active, changes).Hours() / 24,
+			Assignments: counts[m.ID],
+		})
+	}
+
+	scoreFairness(report)
+	return report, nil
+}
+
+// teamJoinSnapshot returns when the team was created and which of its
+// members were active then. The time is nil if the audit log has no
+// team.add record for the team.
+func (s *Service) teamJoinSnapshot(ctx context.Context, teamName domain.TeamName) (*time.Time, map[domain.UserID]bool, error) {
+	events, err := s.audit.List(ctx, domain.AuditFilter{
+		EntityType: domain.AuditEntityTeam,
+		EntityID:   string(teamName),
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, e := range events {
+		if e.Action != domain.AuditActionTeamAdd {
+			continue
+		}
+		var snap teamSnapshot
+		if err := json.Unmarshal(e.After, &snap); err != nil {
+			return nil, nil, err
+		}
+		initial := make(map[domain.UserID]bool, len(snap.Members))
+		for _, m := range snap.Members {
+			initial[m.UserID] = m.IsActive
+		}
+		at := e.CreatedAt
+		return &at, initial, nil
+	}
+
+	return nil, nil, nil
+}
+
+// activityChanges lists the activity switches of a user, oldest first.
+// Calls that left the flag unchanged are skipped.
+func (s *Service) activityChanges(ctx context.Context, id domain.UserID) ([]activityChange, error) {
+	events, err := s.audit.List(ctx, domain.AuditFilter{
+		EntityType: domain.AuditEntityUser,
+		EntityID:   string(id),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []activityChange
+	for i := len(events) - 1; i >= 0; i-- {
+		e := events[i]
+		if e.Action != domain.AuditActionUserSetActive {
+			continue
+		}
+		var before, after userSnapshot
+		if err := json.Unmarshal(e.Before, &before); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(e.After, &after); err != nil {
+			return nil, err
+		}
+		if before.IsActive != after.IsActive {
+			changes = append(changes, activityChange{At: e.CreatedAt, Active: after.IsActive})
+		}
+	}
+	return changes, nil
+}
+
+// activeDuration returns how long within [from, to) the state was active,
+// given the state before the first change and the changes oldest first.
+func activeDuration(from, to time.Time, active bool, changes []activityChange) time.Duration {
+	var total time.Duration
+	since := from
+	for _, c := range changes {
+		if !c.At.After(since) {
+			active = c.Active
+			continue
+		}
+		if !c.At.Before(to) {
+			break
+		}
+		if active {
+			total += c.At.Sub(since)
+		}
+		since, active = c.At, c.Active
+	}
+	if active {
+		total += to.Sub(since)
+	}
+	return total
+}
+
+// scoreFairness fills in shares, z-scores, outliers and the Gini
+// coefficient from the members' active days and assignment counts.
+func scoreFairness(report *domain.FairnessReport) {
+	var activeDays float64
+	for _, m := range report.Members {
+		report.Total += m.Assignments
+		activeDays += m.ActiveDays
+	}
+
+	var rates []float64
+	for i := range report.Members {
+		m := &report.Members[i]
+		if report.Total > 0 {
+			m.Share = float64(m.Assignments) / float64(report.Total)
+		}
+		if activeDays > 0 {
+			m.ExpectedShare = m.ActiveDays / activeDays
+		}
+
+		n, p := float64(report.Total), m.ExpectedShare
+		switch {
+		case p == 0:
+			m.Outlier = m.Assignments > 0
+		case p < 1 && n > 0:
+			m.ZScore = (float64(m.Assignments) - n*p) / math.Sqrt(n*p*(1-p))
+			m.Outlier = math.Abs(m.ZScore) > outlierZScore
+		}
+
+		if m.ActiveDays > 0 {
+			rates = append(rates, float64(m.Assignments)/m.ActiveDays)
+		}
+	}
+
+	report.Gini = gini(rates)
+}
+
+// gini returns the Gini coefficient of non-negative values: 0 when they
+// are all equal, approaching 1 as one value takes everything.
+func gini(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+
+	sorted := slices.Clone(values)
+	slices.Sort(sorted)
+
+	var sum, weighted float64
+	for i, v := range sorted {
+		sum += v
+		weighted += float64(i+1) * v
+	}
+	if sum == 0 {
+		return 0
+	}
+
+	n := float64(len(sorted))
+	return 2*weighted/(n*sum) - (n+1)/n
+}
@@ -0,0 +1,91 @@
+package app
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/terps489/avito_tech_internship/internal/domain"
+)
+
+func TestActiveDuration(t *testing.T) {
+	from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 0, 10)
+	day := 24 * time.Hour
+
+	tests := []struct {
+		name    string
+		active  bool
+		changes []activityChange
+		want    time.Duration
+	}{
+		{"always active", true, nil, 10 * day},
+		{"never active", false, nil, 0},
+		{"switched before window", false, []activityChange{
+			{At: from.AddDate(0, 0, -3), Active: true},
+		}, 10 * day},
+		{"joined mid-window", false, []activityChange{
+			{At: from.AddDate(0, 0, 4), Active: true},
+		}, 6 * day},
+		{"paused in window", true, []activityChange{
+			{At: from.AddDate(0, 0, 2), Active: false},
+			{At: from.AddDate(0, 0, 5), Active: true},
+		}, 7 * day},
+		{"switched after window", true, []activityChange{
+			{At: to.AddDate(0, 0, 1), Active: false},
+		}, 10 * day},
+	}
+	for _, tt := range tests {
+		if got := activeDuration(from, to, tt.active, tt.changes); got != tt.want {
+			t.Errorf("%s: activeDuration = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestGini(t *testing.T) {
+	tests := []struct {
+		values []float64
+		want   float64
+	}{
+		{nil, 0},
+		{[]float64{0, 0}, 0},
+		{[]float64{3, 3, 3}, 0},
+		{[]float64{0, 1}, 0.5},
+		{[]float64{0, 0, 0, 4}, 0.75},
+	}
+	for _, tt := range tests {
+		if got := gini(tt.values); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("gini(%v) = %v, want %v", tt.values, got, tt.want)
+		}
+	}
+}
+
+func TestScoreFairness(t *testing.T) {
+	report := &domain.FairnessReport{
+		Members: []domain.MemberFairness{
+			{UserID: "u1", ActiveDays: 10, Assignments: 40},
+			{UserID: "u2", ActiveDays: 10, Assignments: 10},
+			{UserID: "u3", ActiveDays: 5, Assignments: 10},
+			{UserID: "u4", ActiveDays: 0, Assignments: 1},
+		},
+	}
+	scoreFairness(report)
+
+	if report.Total != 61 {
+		t.Fatalf("Total = %d, want 61", report.Total)
+	}
+	if got := report.Members[0].ExpectedShare; got != 0.4 {
+		t.Errorf("u1 expected share = %v, want 0.4", got)
+	}
+
+	outliers := map[domain.UserID]bool{"u1": true, "u2": true, "u3": false, "u4": true}
+	for _, m := range report.Members {
+		if m.Outlier != outliers[m.UserID] {
+			t.Errorf("%s: outlier = %v (z = %.2f), want %v", m.UserID, m.Outlier, m.ZScore, outliers[m.UserID])
+		}
+	}
+
+	if report.Gini <= 0 {
+		t.Errorf("Gini = %v, want > 0 for an uneven load", report.Gini)
+	}
+}
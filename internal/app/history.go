@@ -0,0 +1,91 @@
+package app
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/terps489/avito_tech_internship/internal/domain"
+)
+
+// GetPullRequestHistory returns reviewer events of a pull request, oldest first.
+func (s *Service) GetPullRequestHistory(ctx context.Context, prID domain.PullRequestID) ([]domain.ReviewerEvent, error) {
+	ctx, span := tracer.Start(ctx, "Service.GetPullRequestHistory")
+	defer span.End()
+
+	exists, err := s.prs.Exists(ctx, prID)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, sql.ErrNoRows
+	}
+
+	return s.reviewerEvents.ListByPullRequest(ctx, prID)
+}
+
+func (s *Service) GetReassignmentStats(ctx context.Context) ([]domain.ReassignmentStat, error) {
+	ctx, span := tracer.Start(ctx, "Service.GetReassignmentStats")
+	defer span.End()
+
+	return s.reviewerEvents.GetReassignmentStats(ctx)
+}
+
+// recordReviewerChanges appends events turning the reviewer set before
+// into after. A dropped reviewer paired with an added one is recorded as
+// a single reassignment. It must run inside the transaction of the change.
+func (s *Service) recordReviewerChanges(
+	ctx context.Context,
+	prID domain.PullRequestID,
+	before, after []domain.UserID,
+	reason string,
+) error {
+	removed := diffUserIDs(before, after)
+	added := diffUserIDs(after, before)
+	actor := ActorFromContext(ctx)
+
+	appendEvent := func(t domain.ReviewerEventType, from, to domain.UserID) error {
+		return s.reviewerEvents.Append(ctx, &domain.ReviewerEvent{
+			PullRequestID: prID,
+			Type:          t,
+			FromUserID:    from,
+			ToUserID:      to,
+			Reason:        reason,
+			Actor:         actor,
+		})
+	}
+
+	for len(removed) > 0 && len(added) > 0 {
+		if err := appendEvent(domain.ReviewerEventReassigned, removed[0], added[0]); err != nil {
+			return err
+		}
+		removed, added = removed[1:], added[1:]
+	}
+	for _, id := range removed {
+		if err := appendEvent(domain.ReviewerEventRemoved, id, ""); err != nil {
+			return err
+		}
+	}
+	for _, id := range added {
+		if err := appendEvent(domain.ReviewerEventAssigned, "", id); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// diffUserIDs returns the ids of a missing from b, keeping the order of a.
+func diffUserIDs(a, b []domain.UserID) []domain.UserID {
+	in := make(map[domain.UserID]struct{}, len(b))
+	for _, id := range b {
+		in[id] = struct{}{}
+	}
+
+	var diff []domain.UserID
+	for _, id := range a {
+		if _, ok := in[id]; !ok {
+			diff = append(diff, id)
+		}
+	}
+	return diff
+}
@@ -0,0 +1,83 @@
+package app
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/terps489/avito_tech_internship/internal/domain"
+)
+
+var (
+	ErrIdempotencyKeyReused     = errors.New("idempotency key was used for a different request")
+	ErrIdempotencyKeyInProgress = errors.New("a request with this idempotency key is still in progress")
+)
+
+// BeginIdempotentRequest reserves key for the request with requestHash
+// for lease, which must be longer than the request may take: once it
+// runs out, for example because the process died, the key is free again.
+// It returns nil if the caller should run the request and then call
+// CompleteIdempotentRequest or ReleaseIdempotencyKey, or the stored
+// response of an earlier identical request to replay.
+func (s *Service) BeginIdempotentRequest(ctx context.Context, scope, key, requestHash string, lease time.Duration) (*domain.IdempotencyKey, error) {
+	ctx, span := tracer.Start(ctx, "Service.BeginIdempotentRequest")
+	defer span.End()
+
+	now := s.now()
+	reserved, err := s.idempotencyKeys.Reserve(ctx, &domain.IdempotencyKey{
+		Scope:       scope,
+		Key:         key,
+		RequestHash: requestHash,
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(lease),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if reserved {
+		return nil, nil
+	}
+
+	k, err := s.idempotencyKeys.Get(ctx, scope, key)
+	if errors.Is(err, sql.ErrNoRows) {
+		// Released by the request that held it a moment ago.
+		return nil, ErrIdempotencyKeyInProgress
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case k.RequestHash != requestHash:
+		return nil, ErrIdempotencyKeyReused
+	case k.Pending():
+		return nil, ErrIdempotencyKeyInProgress
+	}
+	return k, nil
+}
+
+// CompleteIdempotentRequest stores the response for replays during ttl.
+func (s *Service) CompleteIdempotentRequest(ctx context.Context, scope, key string, status int, body []byte, ttl time.Duration) error {
+	ctx, span := tracer.Start(ctx, "Service.CompleteIdempotentRequest")
+	defer span.End()
+
+	return s.idempotencyKeys.Complete(ctx, scope, key, status, body, s.now().Add(ttl))
+}
+
+// ReleaseIdempotencyKey frees a key whose request failed, so that it can
+// be retried.
+func (s *Service) ReleaseIdempotencyKey(ctx context.Context, scope, key string) error {
+	ctx, span := tracer.Start(ctx, "Service.ReleaseIdempotencyKey")
+	defer span.End()
+
+	return s.idempotencyKeys.Delete(ctx, scope, key)
+}
+
+// PurgeIdempotencyKeys deletes expired keys and returns how many.
+func (s *Service) PurgeIdempotencyKeys(ctx context.Context) (int64, error) {
+	ctx, span := tracer.Start(ctx, "Service.PurgeIdempotencyKeys")
+	defer span.End()
+
+	return s.idempotencyKeys.DeleteExpired(ctx, s.now())
+}
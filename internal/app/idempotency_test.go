@@ -0,0 +1,39 @@
+package app_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/terps489/avito_tech_internship/internal/app"
+	"github.com/terps489/avito_tech_internship/internal/repository/memory"
+)
+
+func TestIdempotencyLease(t *testing.T) {
+	store := memory.NewStore()
+	svc := app.NewService(app.Repositories{
+		IdempotencyKeys: memory.NewIdempotencyRepository(store),
+		Tx:              store,
+	})
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	app.SetNow(svc, func() time.Time { return now })
+
+	ctx := t.Context()
+	const lease = 2 * time.Minute
+	if k, err := svc.BeginIdempotentRequest(ctx, "client", "key", "hash", lease); err != nil || k != nil {
+		t.Fatalf("first request: %v, %v; want to run it", k, err)
+	}
+
+	// The first request is still running a minute and a half later, past
+	// the fixed minute keys used to be held for; a retry must wait.
+	now = now.Add(90 * time.Second)
+	if _, err := svc.BeginIdempotentRequest(ctx, "client", "key", "hash", lease); !errors.Is(err, app.ErrIdempotencyKeyInProgress) {
+		t.Fatalf("retry within the lease: err = %v, want ErrIdempotencyKeyInProgress", err)
+	}
+
+	// A request that never completed frees its key once the lease is over.
+	now = now.Add(lease)
+	if k, err := svc.BeginIdempotentRequest(ctx, "client", "key", "hash", lease); err != nil || k != nil {
+		t.Fatalf("retry after the lease: %v, %v; want to run it", k, err)
+	}
+}
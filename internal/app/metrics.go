@@ -0,0 +1,26 @@
+package app
+
+import "github.com/terps489/avito_tech_internship/internal/domain"
+
+// Metrics counts business events. Calls are made after the outcome is
+// known and must not block.
+type Metrics interface {
+	PullRequestCreated(team domain.TeamName)
+	PullRequestMerged(team domain.TeamName)
+	ReviewerReassigned(team domain.TeamName)
+	NoCandidate(team domain.TeamName)
+}
+
+// WithMetrics makes the service report business events to m.
+func WithMetrics(m Metrics) Option {
+	return func(s *Service) {
+		s.metrics = m
+	}
+}
+
+type nopMetrics struct{}
+
+func (nopMetrics) PullRequestCreated(domain.TeamName) {}
+func (nopMetrics) PullRequestMerged(domain.TeamName)  {}
+func (nopMetrics) ReviewerReassigned(domain.TeamName) {}
+func (nopMetrics) NoCandidate(domain.TeamName)        {}
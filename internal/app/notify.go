@@ -0,0 +1,60 @@
+package app
+
+import (
+	"context"
+	"time"
+
+	"github.com/terps489/avito_tech_internship/internal/domain"
+)
+
+// Publisher receives review notifications for live subscribers.
+// Publish is called after the change is committed and must not block.
+type Publisher interface {
+	Publish(n domain.ReviewNotification)
+}
+
+type Option func(*Service)
+
+// WithPublisher makes the service publish review notifications to p.
+func WithPublisher(p Publisher) Option {
+	return func(s *Service) {
+		s.publisher = p
+	}
+}
+
+type nopPublisher struct{}
+
+func (nopPublisher) Publish(domain.ReviewNotification) {}
+
+// notifyReviewers publishes typ to every user in userIDs about pr.
+func (s *Service) notifyReviewers(
+	typ domain.ReviewNotificationType,
+	pr *domain.PullRequest,
+	userIDs []domain.UserID,
+	reason string,
+) {
+	now := time.Now().UTC()
+	for _, id := range userIDs {
+		s.publisher.Publish(domain.ReviewNotification{
+			Type:          typ,
+			UserID:        id,
+			PullRequestID: pr.ID,
+			Title:         pr.Title,
+			AuthorID:      pr.AuthorID,
+			Reason:        reason,
+			At:            now,
+		})
+	}
+}
+
+// GetNotificationRecipient returns user id if the caller may receive
+// the notifications addressed to them: admins and the user themselves.
+func (s *Service) GetNotificationRecipient(ctx context.Context, id domain.UserID) (*domain.User, error) {
+	ctx, span := tracer.Start(ctx, "Service.GetNotificationRecipient")
+	defer span.End()
+
+	if err := requireUser(ctx, id); err != nil {
+		return nil, err
+	}
+	return s.users.GetByID(ctx, id)
+}
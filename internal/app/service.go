@@ -1,6 +1,7 @@
 package app
 
 import (
+	"context"
 	"errors"
 	"math/rand"
 	"time"
@@ -16,88 +17,253 @@ var (
 	ErrTeamExists           = errors.New("team already exists")
 	ErrTeamNotFound         = errors.New("team not found")
 	ErrPRExists             = errors.New("pull request already exists")
+	ErrInvalidWebhookURL    = errors.New("webhook url must be an absolute http or https url")
+	ErrUnsupportedEventType = errors.New("event type cannot be subscribed to")
+	ErrUnknownProvider      = errors.New("unknown provider, expected github or gitlab")
+	ErrLoginNotMapped       = errors.New("login is not mapped to a user")
 )
 
 // ---------- Репозитории ----------
 
 type UserRepository interface {
-	GetByID(id domain.UserID) (*domain.User, error)
-	ListActiveByTeam(teamName domain.TeamName) ([]domain.User, error)
-	UpsertUsersForTeam(teamName domain.TeamName, users []domain.User) error
-	SetIsActive(id domain.UserID, active bool) error
+	GetByID(ctx context.Context, id domain.UserID) (*domain.User, error)
+	ListActiveByTeam(ctx context.Context, teamName domain.TeamName) ([]domain.User, error)
+	UpsertUsersForTeam(ctx context.Context, teamName domain.TeamName, users []domain.User) error
+	SetIsActive(ctx context.Context, id domain.UserID, active bool) error
+	List(ctx context.Context, filter domain.UserFilter) ([]domain.User, error)
+	// CountOpenReviews counts open pull requests the user reviews.
+	CountOpenReviews(ctx context.Context, id domain.UserID) (int, error)
 }
 
 type TeamRepository interface {
-	GetByName(name domain.TeamName) (*domain.Team, error)
-	Create(name domain.TeamName) error
-	Exists(name domain.TeamName) (bool, error)
-	ListMembers(name domain.TeamName) ([]domain.User, error)
+	GetByName(ctx context.Context, name domain.TeamName) (*domain.Team, error)
+	Create(ctx context.Context, name domain.TeamName) error
+	Exists(ctx context.Context, name domain.TeamName) (bool, error)
+	ListMembers(ctx context.Context, name domain.TeamName) ([]domain.User, error)
 }
 
 type PullRequestRepository interface {
-	Create(pr *domain.PullRequest) error
-	GetByID(id domain.PullRequestID) (*domain.PullRequest, error)
-	Update(pr *domain.PullRequest) error
-	Exists(id domain.PullRequestID) (bool, error)
-	ListByReviewer(userID domain.UserID) ([]domain.PullRequest, error)
-	GetReviewerAssignmentStats() ([]domain.ReviewerAssignmentStat, error)
+	Create(ctx context.Context, pr *domain.PullRequest) error
+	GetByID(ctx context.Context, id domain.PullRequestID) (*domain.PullRequest, error)
+	Update(ctx context.Context, pr *domain.PullRequest) error
+	Exists(ctx context.Context, id domain.PullRequestID) (bool, error)
+	List(ctx context.Context, filter domain.PullRequestFilter) ([]domain.PullRequest, error)
+	GetReviewerAssignmentStats(ctx context.Context, filter domain.AssignmentStatsFilter) ([]domain.ReviewerAssignmentStat, error)
+	// ListMergeTimes returns merged pull requests, oldest merge first.
+	ListMergeTimes(ctx context.Context, filter domain.MergeTimesFilter) ([]domain.MergeTime, error)
 }
 
-func (s *Service) ListPullRequestsForReviewer(userID domain.UserID) ([]domain.PullRequest, error) {
-	return s.prs.ListByReviewer(userID)
+// AuditRepository is append-only: recorded events are never changed.
+type AuditRepository interface {
+	Append(ctx context.Context, e *domain.AuditEvent) error
+	List(ctx context.Context, filter domain.AuditFilter) ([]domain.AuditEvent, error)
 }
 
-func (s *Service) GetReviewerAssignmentStats() ([]domain.ReviewerAssignmentStat, error) {
-	return s.prs.GetReviewerAssignmentStats()
+// ReviewerEventRepository is append-only, like AuditRepository.
+type ReviewerEventRepository interface {
+	Append(ctx context.Context, e *domain.ReviewerEvent) error
+	ListByPullRequest(ctx context.Context, prID domain.PullRequestID) ([]domain.ReviewerEvent, error)
+	GetReassignmentStats(ctx context.Context) ([]domain.ReassignmentStat, error)
+}
+
+// OutboxRepository stores events for asynchronous delivery;
+// see package outbox for the dispatcher side.
+type OutboxRepository interface {
+	Append(ctx context.Context, e *domain.OutboxEvent) error
+}
+
+// WebhookRepository stores webhook subscriptions and their delivery log;
+// see package webhook for the delivery side. Deleting a webhook deletes
+// its deliveries.
+type WebhookRepository interface {
+	Create(ctx context.Context, w *domain.Webhook) error
+	GetByID(ctx context.Context, id int64) (*domain.Webhook, error)
+	List(ctx context.Context) ([]domain.Webhook, error)
+	Update(ctx context.Context, w *domain.Webhook) error
+	Delete(ctx context.Context, id int64) error
+	CreateDelivery(ctx context.Context, d *domain.WebhookDelivery) error
+	GetDelivery(ctx context.Context, id int64) (*domain.WebhookDelivery, error)
+	ListDeliveries(ctx context.Context, filter domain.WebhookDeliveryFilter) ([]domain.WebhookDelivery, error)
+}
+
+// ExternalAccountRepository maps provider logins to users.
+type ExternalAccountRepository interface {
+	Set(ctx context.Context, a *domain.ExternalAccount) error
+	Get(ctx context.Context, provider domain.ExternalProvider, login string) (*domain.ExternalAccount, error)
+	List(ctx context.Context, provider domain.ExternalProvider) ([]domain.ExternalAccount, error)
+	Delete(ctx context.Context, provider domain.ExternalProvider, login string) error
+}
+
+// APITokenRepository stores API tokens by the hash of their secret.
+type APITokenRepository interface {
+	Create(ctx context.Context, t *domain.APIToken) error
+	GetByID(ctx context.Context, id int64) (*domain.APIToken, error)
+	GetByHash(ctx context.Context, hash string) (*domain.APIToken, error)
+	List(ctx context.Context) ([]domain.APIToken, error)
+	Revoke(ctx context.Context, id int64, at time.Time) error
+}
+
+// IdempotencyRepository stores responses by client scope and
+// Idempotency-Key. Reserve reports whether k was stored, which it is
+// unless an unexpired record has the same scope and key.
+type IdempotencyRepository interface {
+	Reserve(ctx context.Context, k *domain.IdempotencyKey) (bool, error)
+	Get(ctx context.Context, scope, key string) (*domain.IdempotencyKey, error)
+	Complete(ctx context.Context, scope, key string, status int, body []byte, expiresAt time.Time) error
+	Delete(ctx context.Context, scope, key string) error
+	DeleteExpired(ctx context.Context, now time.Time) (int64, error)
+}
+
+// TxManager runs fn in a single transaction. Repository calls made with
+// the context passed to fn take part in that transaction; the transaction
+// is committed if fn returns nil and rolled back otherwise.
+type TxManager interface {
+	WithinTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// Repositories are the storage dependencies of Service.
+// All of them must be backed by the same storage as Tx.
+type Repositories struct {
+	Users            UserRepository
+	Teams            TeamRepository
+	PullRequests     PullRequestRepository
+	Audit            AuditRepository
+	ReviewerEvents   ReviewerEventRepository
+	Outbox           OutboxRepository
+	Webhooks         WebhookRepository
+	ExternalAccounts ExternalAccountRepository
+	APITokens        APITokenRepository
+	IdempotencyKeys  IdempotencyRepository
+	Tx               TxManager
+}
+
+func (s *Service) GetPullRequest(ctx context.Context, id domain.PullRequestID) (*domain.PullRequest, error) {
+	ctx, span := tracer.Start(ctx, "Service.GetPullRequest")
+	defer span.End()
+
+	return s.prs.GetByID(ctx, id)
+}
+
+func (s *Service) ListPullRequests(ctx context.Context, filter domain.PullRequestFilter) ([]domain.PullRequest, error) {
+	ctx, span := tracer.Start(ctx, "Service.ListPullRequests")
+	defer span.End()
+
+	return s.prs.List(ctx, filter)
+}
+
+func (s *Service) GetReviewerAssignmentStats(
+	ctx context.Context,
+	filter domain.AssignmentStatsFilter,
+) ([]domain.ReviewerAssignmentStat, error) {
+	ctx, span := tracer.Start(ctx, "Service.GetReviewerAssignmentStats")
+	defer span.End()
+
+	return s.prs.GetReviewerAssignmentStats(ctx, filter)
 }
 
 // ---------- Service ----------
 
 type Service struct {
-	users UserRepository
-	teams TeamRepository
-	prs   PullRequestRepository
-	rnd   *rand.Rand
+	users            UserRepository
+	teams            TeamRepository
+	prs              PullRequestRepository
+	audit            AuditRepository
+	reviewerEvents   ReviewerEventRepository
+	outbox           OutboxRepository
+	webhooks         WebhookRepository
+	externalAccounts ExternalAccountRepository
+	apiTokens        APITokenRepository
+	idempotencyKeys  IdempotencyRepository
+	tx               TxManager
+	publisher        Publisher
+	metrics          Metrics
+	rnd              *rand.Rand
+	reviewersPerPR   int
+	now              func() time.Time
 }
 
-func NewService(u UserRepository, t TeamRepository, p PullRequestRepository) *Service {
-	return &Service{
-		users: u,
-		teams: t,
-		prs:   p,
-		rnd:   rand.New(rand.NewSource(time.Now().UnixNano())),
-	}
+func NewService(repos Repositories, opts ...Option) *Service {
+	s := &Service{
+		users:            repos.Users,
+		teams:            repos.Teams,
+		prs:              repos.PullRequests,
+		audit:            repos.Audit,
+		reviewerEvents:   repos.ReviewerEvents,
+		outbox:           repos.Outbox,
+		webhooks:         repos.Webhooks,
+		externalAccounts: repos.ExternalAccounts,
+		apiTokens:        repos.APITokens,
+		idempotencyKeys:  repos.IdempotencyKeys,
+		tx:               repos.Tx,
+		publisher:        nopPublisher{},
+		metrics:          nopMetrics{},
+		rnd:              rand.New(rand.NewSource(time.Now().UnixNano())),
+		reviewersPerPR:   DefaultAssignment().Reviewers,
+		now:              time.Now,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
 }
 
 // ---------- Команды ----------
 
-func (s *Service) CreateTeamWithMembers(teamName domain.TeamName, members []domain.User) (*domain.Team, []domain.User, error) {
-	exists, err := s.teams.Exists(teamName)
-	if err != nil {
-		return nil, nil, err
-	}
-	if exists {
-		return nil, nil, ErrTeamExists
-	}
+func (s *Service) CreateTeamWithMembers(ctx context.Context, teamName domain.TeamName, members []domain.User) (*domain.Team, []domain.User, error) {
+	ctx, span := tracer.Start(ctx, "Service.CreateTeamWithMembers")
+	defer span.End()
 
-	if err := s.teams.Create(teamName); err != nil {
+	if err := requireAdmin(ctx); err != nil {
 		return nil, nil, err
 	}
 
-	for i := range members {
-		members[i].TeamName = teamName
-	}
+	var (
+		team          *domain.Team
+		membersFromDB []domain.User
+	)
 
-	if err := s.users.UpsertUsersForTeam(teamName, members); err != nil {
-		return nil, nil, err
-	}
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		exists, err := s.teams.Exists(ctx, teamName)
+		if err != nil {
+			return err
+		}
+		if exists {
+			return ErrTeamExists
+		}
 
-	team, err := s.teams.GetByName(teamName)
-	if err != nil {
-		return nil, nil, err
-	}
+		if err := s.teams.Create(ctx, teamName); err != nil {
+			return err
+		}
+
+		for i := range members {
+			members[i].TeamName = teamName
+		}
+
+		if err := s.users.UpsertUsersForTeam(ctx, teamName, members); err != nil {
+			return err
+		}
 
-	membersFromDB, err := s.teams.ListMembers(teamName)
+		team, err = s.teams.GetByName(ctx, teamName)
+		if err != nil {
+			return err
+		}
+
+		membersFromDB, err = s.teams.ListMembers(ctx, teamName)
+		if err != nil {
+			return err
+		}
+
+		memberIDs := make([]domain.UserID, 0, len(membersFromDB))
+		for _, m := range membersFromDB {
+			memberIDs = append(memberIDs, m.ID)
+		}
+		if err := s.emit(ctx, domain.TeamCreated{TeamName: teamName, Members: memberIDs}); err != nil {
+			return err
+		}
+
+		return s.recordAudit(ctx, domain.AuditActionTeamAdd, domain.AuditEntityTeam, string(teamName),
+			nil, snapshotTeam(team, membersFromDB))
+	})
 	if err != nil {
 		return nil, nil, err
 	}
@@ -106,65 +272,101 @@ func (s *Service) CreateTeamWithMembers(teamName domain.TeamName, members []doma
 }
 
 func (s *Service) CreatePullRequestWithID(
+	ctx context.Context,
 	id domain.PullRequestID,
 	name string,
 	authorID domain.UserID,
 ) (*domain.PullRequest, error) {
+	ctx, span := tracer.Start(ctx, "Service.CreatePullRequestWithID")
+	defer span.End()
+
+	var (
+		pr   *domain.PullRequest
+		team domain.TeamName
+	)
+
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		exists, err := s.prs.Exists(ctx, id)
+		if err != nil {
+			return err
+		}
+		if exists {
+			return ErrPRExists
+		}
 
-	exists, err := s.prs.Exists(id)
-	if err != nil {
-		return nil, err
-	}
-	if exists {
-		return nil, ErrPRExists
-	}
+		author, err := s.users.GetByID(ctx, authorID)
+		if err != nil {
+			return err
+		}
 
-	author, err := s.users.GetByID(authorID)
-	if err != nil {
-		return nil, err
-	}
+		if !author.IsActive {
+			return ErrAuthorNotActive
+		}
+		team = author.TeamName
 
-	if !author.IsActive {
-		return nil, ErrAuthorNotActive
-	}
+		candidates, err := s.users.ListActiveByTeam(ctx, author.TeamName)
+		if err != nil {
+			return err
+		}
 
-	candidates, err := s.users.ListActiveByTeam(author.TeamName)
-	if err != nil {
-		return nil, err
-	}
+		var reviewerPool []domain.UserID
+		for _, u := range candidates {
+			if u.ID == author.ID {
+				continue
+			}
+			reviewerPool = append(reviewerPool, u.ID)
+		}
 
-	var reviewerPool []domain.UserID
-	for _, u := range candidates {
-		if u.ID == author.ID {
-			continue
+		s.shuffleUserIDs(reviewerPool)
+
+		reviewers := make([]domain.UserID, 0, s.reviewersPerPR)
+		for i := 0; i < len(reviewerPool) && len(reviewers) < s.reviewersPerPR; i++ {
+			reviewers = append(reviewers, reviewerPool[i])
 		}
-		reviewerPool = append(reviewerPool, u.ID)
-	}
 
-	s.shuffleUserIDs(reviewerPool)
+		pr = &domain.PullRequest{
+			ID:          id,
+			Title:       name,
+			AuthorID:    authorID,
+			Status:      domain.PRStatusOpen,
+			ReviewerIDs: reviewers,
+		}
 
-	reviewers := make([]domain.UserID, 0, 2)
-	for i := 0; i < len(reviewerPool) && len(reviewers) < 2; i++ {
-		reviewers = append(reviewers, reviewerPool[i])
-	}
+		if err := s.prs.Create(ctx, pr); err != nil {
+			return err
+		}
 
-	pr := &domain.PullRequest{
-		ID:          id,
-		Title:       name,
-		AuthorID:    authorID,
-		Status:      domain.PRStatusOpen,
-		ReviewerIDs: reviewers,
-	}
+		if err := s.recordReviewerChanges(ctx, pr.ID, nil, pr.ReviewerIDs, domain.ReviewerReasonAutoAssign); err != nil {
+			return err
+		}
 
-	if err := s.prs.Create(pr); err != nil {
+		if err := s.emit(ctx, domain.PRCreated{
+			PullRequestID: pr.ID,
+			Title:         pr.Title,
+			AuthorID:      pr.AuthorID,
+			ReviewerIDs:   pr.ReviewerIDs,
+		}); err != nil {
+			return err
+		}
+
+		return s.recordAudit(ctx, domain.AuditActionPRCreate, domain.AuditEntityPullRequest, string(pr.ID),
+			nil, snapshotPullRequest(pr))
+	})
+	if err != nil {
 		return nil, err
 	}
 
+	s.metrics.PullRequestCreated(team)
+	s.notifyReviewers(domain.ReviewAssigned, pr, pr.ReviewerIDs, domain.ReviewerReasonAutoAssign)
+
 	return pr, nil
 }
 
-func (s *Service) GetTeamWithMembers(teamName domain.TeamName) (*domain.Team, []domain.User, error) {
-	exists, err := s.teams.Exists(teamName)
+func (s *Service) GetTeamWithMembers(ctx context.Context, teamName domain.TeamName) (*domain.Team, []domain.User, error) {
+	ctx, span := tracer.Start(ctx, "Service.GetTeamWithMembers")
+	defer span.End()
+
+	exists, err := s.teams.Exists(ctx, teamName)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -172,12 +374,12 @@ func (s *Service) GetTeamWithMembers(teamName domain.TeamName) (*domain.Team, []
 		return nil, nil, ErrTeamNotFound
 	}
 
-	team, err := s.teams.GetByName(teamName)
+	team, err := s.teams.GetByName(ctx, teamName)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	members, err := s.teams.ListMembers(teamName)
+	members, err := s.teams.ListMembers(ctx, teamName)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -185,142 +387,266 @@ func (s *Service) GetTeamWithMembers(teamName domain.TeamName) (*domain.Team, []
 	return team, members, nil
 }
 
-func (s *Service) SetUserIsActive(id domain.UserID, active bool) (*domain.User, error) {
-	if err := s.users.SetIsActive(id, active); err != nil {
-		return nil, err
+func (s *Service) GetUser(ctx context.Context, id domain.UserID) (*domain.User, error) {
+	ctx, span := tracer.Start(ctx, "Service.GetUser")
+	defer span.End()
+
+	return s.users.GetByID(ctx, id)
+}
+
+// GetUserProfile returns the user and the number of open reviews
+// assigned to them.
+func (s *Service) GetUserProfile(ctx context.Context, id domain.UserID) (*domain.User, int, error) {
+	ctx, span := tracer.Start(ctx, "Service.GetUserProfile")
+	defer span.End()
+
+	u, err := s.users.GetByID(ctx, id)
+	if err != nil {
+		return nil, 0, err
 	}
 
-	u, err := s.users.GetByID(id)
+	openReviews, err := s.users.CountOpenReviews(ctx, id)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 
-	return u, nil
+	return u, openReviews, nil
 }
 
-// ---------- PR: создание / переназначение / merge ----------
+func (s *Service) ListUsers(ctx context.Context, filter domain.UserFilter) ([]domain.User, error) {
+	ctx, span := tracer.Start(ctx, "Service.ListUsers")
+	defer span.End()
 
-func (s *Service) CreatePullRequest(authorID domain.UserID, title string) (*domain.PullRequest, error) {
-	author, err := s.users.GetByID(authorID)
-	if err != nil {
-		return nil, err
-	}
+	return s.users.List(ctx, filter)
+}
 
-	if !author.IsActive {
-		return nil, ErrAuthorNotActive
-	}
+func (s *Service) SetUserIsActive(ctx context.Context, id domain.UserID, active bool) (*domain.User, error) {
+	ctx, span := tracer.Start(ctx, "Service.SetUserIsActive")
+	defer span.End()
 
-	candidates, err := s.users.ListActiveByTeam(author.TeamName)
-	if err != nil {
-		return nil, err
-	}
+	var u *domain.User
 
-	var reviewerPool []domain.UserID
-	for _, u := range candidates {
-		if u.ID == author.ID {
-			continue
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		before, err := s.users.GetByID(ctx, id)
+		if err != nil {
+			return err
 		}
-		reviewerPool = append(reviewerPool, u.ID)
-	}
 
-	s.shuffleUserIDs(reviewerPool)
+		if err := requireTeamLead(ctx, before.TeamName); err != nil {
+			return err
+		}
 
-	var reviewers []domain.UserID
-	for i := 0; i < len(reviewerPool) && len(reviewers) < 2; i++ {
-		reviewers = append(reviewers, reviewerPool[i])
-	}
+		if err := s.users.SetIsActive(ctx, id, active); err != nil {
+			return err
+		}
 
-	pr := &domain.PullRequest{
-		Title:       title,
-		AuthorID:    authorID,
-		Status:      domain.PRStatusOpen,
-		ReviewerIDs: reviewers,
-	}
+		u, err = s.users.GetByID(ctx, id)
+		if err != nil {
+			return err
+		}
 
-	if err := s.prs.Create(pr); err != nil {
+		if before.IsActive != u.IsActive {
+			if err := s.emit(ctx, domain.UserActivityChanged{
+				UserID:   u.ID,
+				TeamName: u.TeamName,
+				IsActive: u.IsActive,
+			}); err != nil {
+				return err
+			}
+		}
+
+		return s.recordAudit(ctx, domain.AuditActionUserSetActive, domain.AuditEntityUser, string(id),
+			snapshotUser(before), snapshotUser(u))
+	})
+	if err != nil {
 		return nil, err
 	}
 
-	return pr, nil
+	return u, nil
 }
 
-func (s *Service) ReassignReviewer(prID domain.PullRequestID, oldReviewerID domain.UserID) (*domain.PullRequest, domain.UserID, error) {
-	pr, err := s.prs.GetByID(prID)
-	if err != nil {
-		return nil, "", err
-	}
+// ---------- PR: создание / переназначение / merge ----------
 
-	if pr.Status == domain.PRStatusMerged {
-		return nil, "", ErrPRAlreadyMerged
-	}
+// ReassignReviewer replaces oldReviewerID with a random active member of
+// their team. An empty reason is recorded as domain.ReviewerReasonManual.
+// Only the author, a reviewer, the lead of the author's team or an admin
+// may reassign.
+func (s *Service) ReassignReviewer(
+	ctx context.Context,
+	prID domain.PullRequestID,
+	oldReviewerID domain.UserID,
+	reason string,
+) (*domain.PullRequest, domain.UserID, error) {
+	ctx, span := tracer.Start(ctx, "Service.ReassignReviewer")
+	defer span.End()
+
+	if reason == "" {
+		reason = domain.ReviewerReasonManual
+	}
+
+	var (
+		pr            *domain.PullRequest
+		newReviewerID domain.UserID
+		team          domain.TeamName
+	)
+
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		pr, err = s.prs.GetByID(ctx, prID)
+		if err != nil {
+			return err
+		}
 
-	idx := -1
-	for i, id := range pr.ReviewerIDs {
-		if id == oldReviewerID {
-			idx = i
-			break
+		if err := s.requirePRParticipant(ctx, pr); err != nil {
+			return err
 		}
-	}
-	if idx == -1 {
-		return nil, "", ErrReviewerNotAssigned
-	}
 
-	reviewer, err := s.users.GetByID(oldReviewerID)
-	if err != nil {
-		return nil, "", err
-	}
+		if pr.Status == domain.PRStatusMerged {
+			return ErrPRAlreadyMerged
+		}
 
-	candidates, err := s.users.ListActiveByTeam(reviewer.TeamName)
-	if err != nil {
-		return nil, "", err
-	}
+		idx := -1
+		for i, id := range pr.ReviewerIDs {
+			if id == oldReviewerID {
+				idx = i
+				break
+			}
+		}
+		if idx == -1 {
+			return ErrReviewerNotAssigned
+		}
 
-	exclude := make(map[domain.UserID]struct{}, len(pr.ReviewerIDs)+1)
-	for _, id := range pr.ReviewerIDs {
-		exclude[id] = struct{}{}
-	}
-	exclude[oldReviewerID] = struct{}{}
-	exclude[pr.AuthorID] = struct{}{}
+		reviewer, err := s.users.GetByID(ctx, oldReviewerID)
+		if err != nil {
+			return err
+		}
+		team = reviewer.TeamName
 
-	var pool []domain.UserID
-	for _, u := range candidates {
-		if _, banned := exclude[u.ID]; banned {
-			continue
+		candidates, err := s.users.ListActiveByTeam(ctx, reviewer.TeamName)
+		if err != nil {
+			return err
 		}
-		pool = append(pool, u.ID)
-	}
 
-	if len(pool) == 0 {
-		return nil, "", ErrNoAvailableReviewers
-	}
+		exclude := make(map[domain.UserID]struct{}, len(pr.ReviewerIDs)+1)
+		for _, id := range pr.ReviewerIDs {
+			exclude[id] = struct{}{}
+		}
+		exclude[oldReviewerID] = struct{}{}
+		exclude[pr.AuthorID] = struct{}{}
+
+		var pool []domain.UserID
+		for _, u := range candidates {
+			if _, banned := exclude[u.ID]; banned {
+				continue
+			}
+			pool = append(pool, u.ID)
+		}
+
+		if len(pool) == 0 {
+			return ErrNoAvailableReviewers
+		}
+
+		before := snapshotPullRequest(pr)
+
+		newReviewerID = pool[s.rnd.Intn(len(pool))]
+
+		pr.ReviewerIDs[idx] = newReviewerID
+
+		if err := s.prs.Update(ctx, pr); err != nil {
+			return err
+		}
 
-	newReviewerID := pool[s.rnd.Intn(len(pool))]
+		if err := s.recordReviewerChanges(ctx, pr.ID, before.ReviewerIDs, pr.ReviewerIDs, reason); err != nil {
+			return err
+		}
 
-	pr.ReviewerIDs[idx] = newReviewerID
+		if err := s.emit(ctx, domain.PRReviewerReassigned{
+			PullRequestID: pr.ID,
+			OldReviewerID: oldReviewerID,
+			NewReviewerID: newReviewerID,
+			Reason:        reason,
+		}); err != nil {
+			return err
+		}
 
-	if err := s.prs.Update(pr); err != nil {
+		return s.recordAudit(ctx, domain.AuditActionPRReassign, domain.AuditEntityPullRequest, string(pr.ID),
+			before, snapshotPullRequest(pr))
+	})
+	if err != nil {
+		if errors.Is(err, ErrNoAvailableReviewers) {
+			s.metrics.NoCandidate(team)
+		}
 		return nil, "", err
 	}
 
+	s.metrics.ReviewerReassigned(team)
+	s.notifyReviewers(domain.ReviewUnassigned, pr, []domain.UserID{oldReviewerID}, reason)
+	s.notifyReviewers(domain.ReviewAssigned, pr, []domain.UserID{newReviewerID}, reason)
+
 	return pr, newReviewerID, nil
 }
 
-func (s *Service) MergePullRequest(prID domain.PullRequestID) (*domain.PullRequest, error) {
-	pr, err := s.prs.GetByID(prID)
-	if err != nil {
-		return nil, err
-	}
+func (s *Service) MergePullRequest(ctx context.Context, prID domain.PullRequestID) (*domain.PullRequest, error) {
+	ctx, span := tracer.Start(ctx, "Service.MergePullRequest")
+	defer span.End()
+
+	var (
+		pr     *domain.PullRequest
+		merged bool
+		team   domain.TeamName
+	)
+
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		pr, err = s.prs.GetByID(ctx, prID)
+		if err != nil {
+			return err
+		}
 
-	if pr.Status == domain.PRStatusMerged {
-		return pr, nil
-	}
+		if err := requireUser(ctx, pr.AuthorID); err != nil {
+			return err
+		}
+
+		if pr.Status == domain.PRStatusMerged {
+			return nil
+		}
 
-	pr.Status = domain.PRStatusMerged
+		before := snapshotPullRequest(pr)
 
-	if err := s.prs.Update(pr); err != nil {
+		pr.Status = domain.PRStatusMerged
+
+		if err := s.prs.Update(ctx, pr); err != nil {
+			return err
+		}
+		merged = true
+
+		author, err := s.users.GetByID(ctx, pr.AuthorID)
+		if err != nil {
+			return err
+		}
+		team = author.TeamName
+
+		if err := s.emit(ctx, domain.PRMerged{
+			PullRequestID: pr.ID,
+			AuthorID:      pr.AuthorID,
+			ReviewerIDs:   pr.ReviewerIDs,
+			MergedAt:      *pr.MergedAt,
+		}); err != nil {
+			return err
+		}
+
+		return s.recordAudit(ctx, domain.AuditActionPRMerge, domain.AuditEntityPullRequest, string(pr.ID),
+			before, snapshotPullRequest(pr))
+	})
+	if err != nil {
 		return nil, err
 	}
 
+	if merged {
+		s.metrics.PullRequestMerged(team)
+		s.notifyReviewers(domain.ReviewPRMerged, pr, pr.ReviewerIDs, "")
+	}
+
 	return pr, nil
 }
 
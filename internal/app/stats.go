@@ -0,0 +1,87 @@
+package app
+
+import (
+	"context"
+	"math"
+	"slices"
+	"time"
+
+	"github.com/terps489/avito_tech_internship/internal/domain"
+)
+
+// GetCycleTimeReport computes time-to-merge percentiles of pull requests
+// merged in the window, overall and per author's team and author.
+//
+// Time to first review is not reported: reviews and approvals are not
+// stored. The first reviewer assignment in pr_reviewer_events is not a
+// stand-in, as reviewers are assigned when the pull request is created.
+func (s *Service) GetCycleTimeReport(ctx context.Context, filter domain.MergeTimesFilter) (*domain.CycleTimeReport, error) {
+	ctx, span := tracer.Start(ctx, "Service.GetCycleTimeReport")
+	defer span.End()
+
+	samples, err := s.prs.ListMergeTimes(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		all      = make([]time.Duration, 0, len(samples))
+		byTeam   = make(map[string][]time.Duration)
+		byAuthor = make(map[string][]time.Duration)
+	)
+	for _, m := range samples {
+		d := m.Duration()
+		all = append(all, d)
+		byTeam[string(m.TeamName)] = append(byTeam[string(m.TeamName)], d)
+		byAuthor[string(m.AuthorID)] = append(byAuthor[string(m.AuthorID)], d)
+	}
+
+	return &domain.CycleTimeReport{
+		Overall:  cycleTimeStat("", all),
+		ByTeam:   cycleTimeStats(byTeam),
+		ByAuthor: cycleTimeStats(byAuthor),
+	}, nil
+}
+
+func cycleTimeStats(groups map[string][]time.Duration) []domain.CycleTimeStat {
+	keys := make([]string, 0, len(groups))
+	for k := range groups {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+
+	stats := make([]domain.CycleTimeStat, 0, len(keys))
+	for _, k := range keys {
+		stats = append(stats, cycleTimeStat(k, groups[k]))
+	}
+	return stats
+}
+
+func cycleTimeStat(key string, durations []time.Duration) domain.CycleTimeStat {
+	slices.Sort(durations)
+	return domain.CycleTimeStat{
+		Key:   key,
+		Count: len(durations),
+		P50:   percentile(durations, 0.50),
+		P90:   percentile(durations, 0.90),
+		P99:   percentile(durations, 0.99),
+	}
+}
+
+// percentile interpolates linearly between the closest ranks of sorted,
+// like percentile_cont in SQL. It returns 0 for no samples.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+
+	rank := p * float64(len(sorted)-1)
+	lo := int(math.Floor(rank))
+	hi := int(math.Ceil(rank))
+	if lo == hi {
+		return sorted[lo]
+	}
+
+	frac := rank - float64(lo)
+	return sorted[lo] + time.Duration(math.Round(frac*float64(sorted[hi]-sorted[lo])))
+}
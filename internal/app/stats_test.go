@@ -0,0 +1,35 @@
+package app
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPercentile(t *testing.T) {
+	var sorted []time.Duration
+	for i := 1; i <= 10; i++ {
+		sorted = append(sorted, time.Duration(i)*time.Hour)
+	}
+
+	tests := []struct {
+		p    float64
+		want time.Duration
+	}{
+		{0, time.Hour},
+		{0.5, 5*time.Hour + 30*time.Minute},
+		{0.9, 9*time.Hour + 6*time.Minute},
+		{1, 10 * time.Hour},
+	}
+	for _, tt := range tests {
+		if got := percentile(sorted, tt.p); got != tt.want {
+			t.Errorf("percentile(%v) = %v, want %v", tt.p, got, tt.want)
+		}
+	}
+
+	if got := percentile(nil, 0.5); got != 0 {
+		t.Errorf("percentile of no samples = %v, want 0", got)
+	}
+	if got := percentile([]time.Duration{time.Minute}, 0.99); got != time.Minute {
+		t.Errorf("percentile of one sample = %v, want 1m", got)
+	}
+}
@@ -0,0 +1,6 @@
+package app
+
+import "go.opentelemetry.io/otel"
+
+// tracer starts a span for every exported Service method.
+var tracer = otel.Tracer("github.com/terps489/avito_tech_internship/internal/app")
@@ -0,0 +1,274 @@
+package app
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/url"
+	"strconv"
+
+	"github.com/terps489/avito_tech_internship/internal/domain"
+)
+
+// WebhookUpdate lists the fields to change; nil fields are kept.
+type WebhookUpdate struct {
+	URL        *string
+	Secret     *string
+	EventTypes []domain.EventType
+	IsActive   *bool
+}
+
+// CreateWebhook subscribes rawURL to eventTypes, or to all supported
+// events if none are given. An empty secret is generated.
+func (s *Service) CreateWebhook(
+	ctx context.Context,
+	rawURL, secret string,
+	eventTypes []domain.EventType,
+) (*domain.Webhook, error) {
+	ctx, span := tracer.Start(ctx, "Service.CreateWebhook")
+	defer span.End()
+
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+	if err := validateWebhookURL(rawURL); err != nil {
+		return nil, err
+	}
+	if len(eventTypes) == 0 {
+		eventTypes = domain.WebhookEventTypes
+	}
+	if err := validateWebhookEventTypes(eventTypes); err != nil {
+		return nil, err
+	}
+	if secret == "" {
+		var err error
+		if secret, err = generateWebhookSecret(); err != nil {
+			return nil, err
+		}
+	}
+
+	w := &domain.Webhook{
+		URL:        rawURL,
+		Secret:     secret,
+		EventTypes: eventTypes,
+		IsActive:   true,
+	}
+
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.webhooks.Create(ctx, w); err != nil {
+			return err
+		}
+
+		created, err := s.webhooks.GetByID(ctx, w.ID)
+		if err != nil {
+			return err
+		}
+		w = created
+
+		return s.recordAudit(ctx, domain.AuditActionWebhookCreate, domain.AuditEntityWebhook, webhookEntityID(w.ID),
+			nil, snapshotWebhook(w))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return w, nil
+}
+
+func (s *Service) GetWebhook(ctx context.Context, id int64) (*domain.Webhook, error) {
+	ctx, span := tracer.Start(ctx, "Service.GetWebhook")
+	defer span.End()
+
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	return s.webhooks.GetByID(ctx, id)
+}
+
+func (s *Service) ListWebhooks(ctx context.Context) ([]domain.Webhook, error) {
+	ctx, span := tracer.Start(ctx, "Service.ListWebhooks")
+	defer span.End()
+
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	return s.webhooks.List(ctx)
+}
+
+func (s *Service) UpdateWebhook(ctx context.Context, id int64, upd WebhookUpdate) (*domain.Webhook, error) {
+	ctx, span := tracer.Start(ctx, "Service.UpdateWebhook")
+	defer span.End()
+
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+	if upd.URL != nil {
+		if err := validateWebhookURL(*upd.URL); err != nil {
+			return nil, err
+		}
+	}
+	if upd.EventTypes != nil {
+		if err := validateWebhookEventTypes(upd.EventTypes); err != nil {
+			return nil, err
+		}
+	}
+
+	var w *domain.Webhook
+
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		before, err := s.webhooks.GetByID(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		changed := *before
+		if upd.URL != nil {
+			changed.URL = *upd.URL
+		}
+		if upd.Secret != nil {
+			changed.Secret = *upd.Secret
+		}
+		if upd.EventTypes != nil {
+			changed.EventTypes = upd.EventTypes
+		}
+		if upd.IsActive != nil {
+			changed.IsActive = *upd.IsActive
+		}
+
+		if err := s.webhooks.Update(ctx, &changed); err != nil {
+			return err
+		}
+
+		w, err = s.webhooks.GetByID(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		return s.recordAudit(ctx, domain.AuditActionWebhookUpdate, domain.AuditEntityWebhook, webhookEntityID(id),
+			snapshotWebhook(before), snapshotWebhook(w))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return w, nil
+}
+
+// DeleteWebhook removes the webhook together with its delivery log.
+func (s *Service) DeleteWebhook(ctx context.Context, id int64) (*domain.Webhook, error) {
+	ctx, span := tracer.Start(ctx, "Service.DeleteWebhook")
+	defer span.End()
+
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	var w *domain.Webhook
+
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		w, err = s.webhooks.GetByID(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		if err := s.webhooks.Delete(ctx, id); err != nil {
+			return err
+		}
+
+		return s.recordAudit(ctx, domain.AuditActionWebhookDelete, domain.AuditEntityWebhook, webhookEntityID(id),
+			snapshotWebhook(w), nil)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return w, nil
+}
+
+func (s *Service) ListWebhookDeliveries(ctx context.Context, filter domain.WebhookDeliveryFilter) ([]domain.WebhookDelivery, error) {
+	ctx, span := tracer.Start(ctx, "Service.ListWebhookDeliveries")
+	defer span.End()
+
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	return s.webhooks.ListDeliveries(ctx, filter)
+}
+
+// RedeliverWebhookDelivery queues the event of delivery id to be sent
+// to its webhook again. The original delivery is left as is.
+func (s *Service) RedeliverWebhookDelivery(ctx context.Context, id int64) (*domain.WebhookDelivery, error) {
+	ctx, span := tracer.Start(ctx, "Service.RedeliverWebhookDelivery")
+	defer span.End()
+
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	var d *domain.WebhookDelivery
+
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		original, err := s.webhooks.GetDelivery(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		d = &domain.WebhookDelivery{
+			WebhookID:    original.WebhookID,
+			EventID:      original.EventID,
+			EventType:    original.EventType,
+			RedeliveryOf: &original.ID,
+		}
+		if err := s.webhooks.CreateDelivery(ctx, d); err != nil {
+			return err
+		}
+
+		return s.recordAudit(ctx, domain.AuditActionWebhookRedeliver, domain.AuditEntityWebhook,
+			webhookEntityID(d.WebhookID), nil, redeliverySnapshot{
+				DeliveryID:   d.ID,
+				RedeliveryOf: original.ID,
+				EventID:      d.EventID,
+			})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return d, nil
+}
+
+func validateWebhookURL(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return ErrInvalidWebhookURL
+	}
+	return nil
+}
+
+func validateWebhookEventTypes(types []domain.EventType) error {
+	if len(types) == 0 {
+		return ErrUnsupportedEventType
+	}
+	for _, t := range types {
+		if !domain.IsWebhookEventType(t) {
+			return ErrUnsupportedEventType
+		}
+	}
+	return nil
+}
+
+func generateWebhookSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func webhookEntityID(id int64) string {
+	return strconv.FormatInt(id, 10)
+}
@@ -0,0 +1,420 @@
+// Package config resolves the service configuration from a YAML file,
+// environment variables and command-line flags.
+//
+// It holds plain data only; cmd/app turns it into the options of the
+// packages it configures, so that this package depends on none of them.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/terps489/avito_tech_internship/internal/tracing"
+)
+
+const (
+	StoragePostgres = "postgres"
+	StorageSQLite   = "sqlite"
+	StorageMemory   = "memory"
+)
+
+// AssignmentRandom is the only strategy app.Service implements.
+const AssignmentRandom = "random"
+
+// redacted replaces secrets in PrintConfig output.
+const redacted = "REDACTED"
+
+// minBootstrapTokenLength matches what app.Service.EnsureAPIToken accepts.
+const minBootstrapTokenLength = 32
+
+type Config struct {
+	HTTP         HTTP         `yaml:"http"`
+	Storage      string       `yaml:"storage"`
+	Postgres     Postgres     `yaml:"postgres"`
+	SQLite       SQLite       `yaml:"sqlite"`
+	Assignment   Assignment   `yaml:"assignment"`
+	Auth         Auth         `yaml:"auth"`
+	Features     Features     `yaml:"features"`
+	Integrations Integrations `yaml:"integrations"`
+	Tracing      Tracing      `yaml:"tracing"`
+}
+
+type HTTP struct {
+	Addr              string        `yaml:"addr"`
+	ReadHeaderTimeout time.Duration `yaml:"read_header_timeout"`
+	ReadTimeout       time.Duration `yaml:"read_timeout"`
+	WriteTimeout      time.Duration `yaml:"write_timeout"`
+	IdleTimeout       time.Duration `yaml:"idle_timeout"`
+	DrainDelay        time.Duration `yaml:"drain_delay"`
+	ShutdownTimeout   time.Duration `yaml:"shutdown_timeout"`
+	RateLimit         RateLimit     `yaml:"rate_limit"`
+	// IdempotencyTTL is how long responses to POST requests with an
+	// Idempotency-Key are kept for replays; 0 turns the header off.
+	IdempotencyTTL time.Duration `yaml:"idempotency_ttl"`
+}
+
+// RateLimit applies token buckets per client, which is an API token, an
+// SSO user or an IP address, and per route.
+type RateLimit struct {
+	Enabled bool    `yaml:"enabled"`
+	RPS     float64 `yaml:"rps"`
+	Burst   int     `yaml:"burst"`
+	// Routes override RPS and Burst for single paths; an rps of 0 lifts
+	// the limit.
+	Routes RouteLimits `yaml:"routes"`
+	// AuthFailures limits requests that fail authentication per IP
+	// address, to slow down token guessing.
+	AuthFailures RouteLimit `yaml:"auth_failures"`
+}
+
+type RouteLimit struct {
+	RPS   float64 `yaml:"rps"`
+	Burst int     `yaml:"burst"`
+}
+
+// RouteLimits from a file replace the defaults rather than being merged
+// into them, so that a default route limit can be removed.
+type RouteLimits map[string]RouteLimit
+
+func (r *RouteLimits) UnmarshalYAML(value *yaml.Node) error {
+	routes := make(map[string]RouteLimit)
+	if err := value.Decode(&routes); err != nil {
+		return err
+	}
+	*r = routes
+	return nil
+}
+
+type Postgres struct {
+	// DSN, if set, replaces the connection fields and TLS.
+	DSN      string      `yaml:"dsn"`
+	Host     string      `yaml:"host"`
+	Port     int         `yaml:"port"`
+	User     string      `yaml:"user"`
+	Password string      `yaml:"password"`
+	Name     string      `yaml:"name"`
+	TLS      PostgresTLS `yaml:"tls"`
+	Pool     Pool        `yaml:"pool"`
+	Connect  Connect     `yaml:"connect"`
+}
+
+type PostgresTLS struct {
+	Mode     string `yaml:"mode"`
+	RootCert string `yaml:"root_cert"`
+	Cert     string `yaml:"cert"`
+	Key      string `yaml:"key"`
+}
+
+type Pool struct {
+	MaxOpenConns    int           `yaml:"max_open_conns"`
+	MaxIdleConns    int           `yaml:"max_idle_conns"`
+	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime"`
+	ConnMaxIdleTime time.Duration `yaml:"conn_max_idle_time"`
+}
+
+type Connect struct {
+	Attempts    int           `yaml:"attempts"`
+	Backoff     time.Duration `yaml:"backoff"`
+	MaxBackoff  time.Duration `yaml:"max_backoff"`
+	PingTimeout time.Duration `yaml:"ping_timeout"`
+}
+
+type SQLite struct {
+	Path string `yaml:"path"`
+}
+
+type Assignment struct {
+	Strategy  string `yaml:"strategy"`
+	Reviewers int    `yaml:"reviewers"`
+	Seed      int64  `yaml:"seed"`
+}
+
+type Auth struct {
+	// Enabled requires an API token on all routes but probes, metrics and
+	// provider webhooks.
+	Enabled bool `yaml:"enabled"`
+	// BootstrapToken, if set, is registered as an admin token at startup,
+	// so that a fresh installation can be used before any token is minted.
+	BootstrapToken string `yaml:"bootstrap_token"`
+	// JWT additionally accepts tokens of the company SSO.
+	JWT JWT `yaml:"jwt"`
+}
+
+// JWT is off while JWKS is empty.
+type JWT struct {
+	// JWKS is a file path or an http(s) URL of the signing keys.
+	JWKS      string        `yaml:"jwks"`
+	Issuer    string        `yaml:"issuer"`
+	Audience  string        `yaml:"audience"`
+	UserClaim string        `yaml:"user_claim"`
+	Leeway    time.Duration `yaml:"leeway"`
+}
+
+// Features switch optional subsystems off.
+type Features struct {
+	// Metrics serves /metrics.
+	Metrics bool `yaml:"metrics"`
+	// Events serves /events/stream.
+	Events bool `yaml:"events"`
+	// Webhooks delivers events to webhook subscriptions.
+	Webhooks bool `yaml:"webhooks"`
+}
+
+type Integrations struct {
+	GitHubSecret     string `yaml:"github_secret"`
+	GitLabToken      string `yaml:"gitlab_token"`
+	OutboxWebhookURL string `yaml:"outbox_webhook_url"`
+}
+
+type Tracing struct {
+	Exporter string `yaml:"exporter"`
+}
+
+// Default is the configuration before the file, environment and flags
+// are applied. It matches docker-compose.yml.
+func Default() Config {
+	return Config{
+		HTTP: HTTP{
+			Addr:              ":8080",
+			ReadHeaderTimeout: 5 * time.Second,
+			ReadTimeout:       15 * time.Second,
+			WriteTimeout:      30 * time.Second,
+			IdleTimeout:       120 * time.Second,
+			ShutdownTimeout:   20 * time.Second,
+			IdempotencyTTL:    24 * time.Hour,
+			RateLimit: RateLimit{
+				Enabled: true,
+				RPS:     50,
+				Burst:   100,
+				// Every create assigns reviewers and writes several rows.
+				Routes: RouteLimits{
+					"/pullRequest/create": {RPS: 10, Burst: 20},
+				},
+				AuthFailures: RouteLimit{RPS: 1, Burst: 20},
+			},
+		},
+		Storage: StoragePostgres,
+		Postgres: Postgres{
+			Host:     "localhost",
+			Port:     5432,
+			User:     "postgres",
+			Password: "postgres",
+			Name:     "avito_review",
+			TLS:      PostgresTLS{Mode: "disable"},
+			Pool: Pool{
+				MaxOpenConns:    20,
+				MaxIdleConns:    10,
+				ConnMaxLifetime: 30 * time.Minute,
+				ConnMaxIdleTime: 5 * time.Minute,
+			},
+			Connect: Connect{
+				Attempts:    10,
+				Backoff:     500 * time.Millisecond,
+				MaxBackoff:  10 * time.Second,
+				PingTimeout: 2 * time.Second,
+			},
+		},
+		SQLite: SQLite{Path: "reviewer.db"},
+		Assignment: Assignment{
+			Strategy:  AssignmentRandom,
+			Reviewers: 2,
+		},
+		// Auth is off so that upgrading does not lock out existing
+		// clients; see the README for turning it on.
+		Auth: Auth{
+			JWT: JWT{UserClaim: "sub", Leeway: time.Minute},
+		},
+		Features: Features{
+			Metrics:  true,
+			Events:   true,
+			Webhooks: true,
+		},
+		Tracing: Tracing{Exporter: tracing.ExporterNone},
+	}
+}
+
+var sslModes = map[string]bool{
+	"disable": true, "allow": true, "prefer": true,
+	"require": true, "verify-ca": true, "verify-full": true,
+}
+
+// Validate reports every problem it finds, not just the first.
+func (c Config) Validate() error {
+	var errs []error
+	add := func(format string, args ...any) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+
+	if _, _, err := net.SplitHostPort(c.HTTP.Addr); err != nil {
+		add("http.addr: %v", err)
+	}
+	for name, d := range map[string]time.Duration{
+		"http.read_header_timeout": c.HTTP.ReadHeaderTimeout,
+		"http.read_timeout":        c.HTTP.ReadTimeout,
+		"http.write_timeout":       c.HTTP.WriteTimeout,
+		"http.idle_timeout":        c.HTTP.IdleTimeout,
+		"http.drain_delay":         c.HTTP.DrainDelay,
+		"http.idempotency_ttl":     c.HTTP.IdempotencyTTL,
+	} {
+		if d < 0 {
+			add("%s must not be negative", name)
+		}
+	}
+	if c.HTTP.ShutdownTimeout <= 0 {
+		add("http.shutdown_timeout must be positive")
+	}
+	if c.HTTP.RateLimit.Enabled {
+		validLimit := func(name string, rps float64, burst int) {
+			if rps < 0 {
+				add("%s.rps must not be negative", name)
+			}
+			if rps > 0 && burst < 1 {
+				add("%s.burst must be at least 1", name)
+			}
+		}
+		validLimit("http.rate_limit", c.HTTP.RateLimit.RPS, c.HTTP.RateLimit.Burst)
+		for route, l := range c.HTTP.RateLimit.Routes {
+			if !strings.HasPrefix(route, "/") {
+				add("http.rate_limit.routes: %q is not a path", route)
+			}
+			validLimit("http.rate_limit.routes."+route, l.RPS, l.Burst)
+		}
+		validLimit("http.rate_limit.auth_failures", c.HTTP.RateLimit.AuthFailures.RPS, c.HTTP.RateLimit.AuthFailures.Burst)
+	}
+
+	switch c.Storage {
+	case StoragePostgres:
+		errs = append(errs, c.Postgres.validate()...)
+	case StorageSQLite:
+		if c.SQLite.Path == "" {
+			add("sqlite.path is required")
+		}
+	case StorageMemory:
+	default:
+		add("storage %q is not one of postgres, sqlite, memory", c.Storage)
+	}
+
+	if t := c.Auth.BootstrapToken; t != "" && len(t) < minBootstrapTokenLength {
+		add("auth.bootstrap_token must be at least %d characters", minBootstrapTokenLength)
+	}
+	// The token CLI cannot reach a process's memory, so nobody could
+	// authenticate.
+	if c.Auth.Enabled && c.Storage == StorageMemory && c.Auth.BootstrapToken == "" {
+		add("auth.bootstrap_token is required with storage memory unless auth.enabled is false")
+	}
+	if c.Auth.JWT.JWKS != "" {
+		if c.Auth.JWT.UserClaim == "" {
+			add("auth.jwt.user_claim is required with auth.jwt.jwks")
+		}
+		if c.Auth.JWT.Leeway < 0 {
+			add("auth.jwt.leeway must not be negative")
+		}
+	}
+
+	if c.Assignment.Strategy != AssignmentRandom {
+		add("assignment.strategy %q is not %s", c.Assignment.Strategy, AssignmentRandom)
+	}
+	if c.Assignment.Reviewers < 1 {
+		add("assignment.reviewers must be positive")
+	}
+
+	switch c.Tracing.Exporter {
+	case "", tracing.ExporterNone, tracing.ExporterStdout, tracing.ExporterOTLP:
+	default:
+		add("tracing.exporter %q is not one of none, stdout, otlp", c.Tracing.Exporter)
+	}
+
+	return errors.Join(errs...)
+}
+
+func (p Postgres) validate() []error {
+	var errs []error
+	add := func(format string, args ...any) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+
+	if p.DSN == "" {
+		if p.Host == "" {
+			add("postgres.host is required unless postgres.dsn is set")
+		}
+		if p.Port < 1 || p.Port > 65535 {
+			add("postgres.port %d is out of range", p.Port)
+		}
+		if p.Name == "" {
+			add("postgres.name is required unless postgres.dsn is set")
+		}
+		if p.TLS.Mode != "" && !sslModes[p.TLS.Mode] {
+			add("postgres.tls.mode %q is not a libpq sslmode", p.TLS.Mode)
+		}
+		if (p.TLS.Cert == "") != (p.TLS.Key == "") {
+			add("postgres.tls.cert and postgres.tls.key must be set together")
+		}
+	}
+
+	if p.Pool.MaxOpenConns < 0 || p.Pool.MaxIdleConns < 0 {
+		add("postgres.pool sizes must not be negative")
+	}
+	if p.Pool.ConnMaxLifetime < 0 || p.Pool.ConnMaxIdleTime < 0 {
+		add("postgres.pool durations must not be negative")
+	}
+
+	if p.Connect.Attempts < 1 {
+		add("postgres.connect.attempts must be positive")
+	}
+	if p.Connect.Backoff <= 0 || p.Connect.MaxBackoff <= 0 || p.Connect.PingTimeout <= 0 {
+		add("postgres.connect durations must be positive")
+	}
+	return errs
+}
+
+// Redacted returns a copy of c with passwords, tokens and URL credentials
+// replaced, for printing.
+func (c Config) Redacted() Config {
+	hide := func(s *string) {
+		if *s != "" {
+			*s = redacted
+		}
+	}
+
+	hide(&c.Postgres.Password)
+	c.Postgres.DSN = redactURL(c.Postgres.DSN)
+	hide(&c.Auth.BootstrapToken)
+	hide(&c.Integrations.GitHubSecret)
+	hide(&c.Integrations.GitLabToken)
+	c.Integrations.OutboxWebhookURL = redactURL(c.Integrations.OutboxWebhookURL)
+	return c
+}
+
+// redactURL hides the password and credential-looking query parameters
+// of a URL. Anything that does not parse as a URL with a scheme, such as
+// a key=value DSN, is hidden entirely.
+func redactURL(s string) string {
+	if s == "" {
+		return ""
+	}
+	u, err := url.Parse(s)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return redacted
+	}
+	if _, ok := u.User.Password(); ok {
+		u.User = url.UserPassword(u.User.Username(), redacted)
+	}
+
+	q := u.Query()
+	for key := range q {
+		lower := strings.ToLower(key)
+		for _, secret := range []string{"pass", "secret", "token"} {
+			if strings.Contains(lower, secret) {
+				q.Set(key, redacted)
+			}
+		}
+	}
+	u.RawQuery = q.Encode()
+	return u.String()
+}
@@ -0,0 +1,210 @@
+package config_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/terps489/avito_tech_internship/internal/config"
+)
+
+func envMap(m map[string]string) func(string) (string, bool) {
+	return func(key string) (string, bool) {
+		v, ok := m[key]
+		return v, ok
+	}
+}
+
+func writeFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	path := writeFile(t, `
+http:
+  addr: ":9000"
+  write_timeout: 10s
+storage: sqlite
+sqlite:
+  path: /var/lib/reviewer.db
+assignment:
+  reviewers: 3
+features:
+  events: false
+`)
+
+	cfg, printConfig, err := config.Load(
+		[]string{"--config", path, "--http.addr", ":9100", "--print-config"},
+		envMap(map[string]string{
+			"HTTP_ADDR":            ":9050",
+			"HTTP_WRITE_TIMEOUT":   "45s",
+			"ASSIGNMENT_REVIEWERS": "1",
+		}),
+	)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	if !printConfig {
+		t.Error("printConfig = false, want true")
+	}
+	if cfg.HTTP.Addr != ":9100" {
+		t.Errorf("addr = %q, want the flag value", cfg.HTTP.Addr)
+	}
+	if cfg.HTTP.WriteTimeout != 45*time.Second {
+		t.Errorf("write timeout = %v, want the env value", cfg.HTTP.WriteTimeout)
+	}
+	if cfg.Assignment.Reviewers != 1 {
+		t.Errorf("reviewers = %d, want the env value", cfg.Assignment.Reviewers)
+	}
+	if cfg.Storage != config.StorageSQLite || cfg.SQLite.Path != "/var/lib/reviewer.db" {
+		t.Errorf("storage = %q %q, want the file values", cfg.Storage, cfg.SQLite.Path)
+	}
+	if cfg.Features.Events || !cfg.Features.Metrics {
+		t.Errorf("features = %+v, want events off from the file and metrics on by default", cfg.Features)
+	}
+	if cfg.HTTP.ReadTimeout != config.Default().HTTP.ReadTimeout {
+		t.Errorf("read timeout = %v, want the default", cfg.HTTP.ReadTimeout)
+	}
+}
+
+func TestLoadConfigFileFromEnv(t *testing.T) {
+	path := writeFile(t, "storage: memory\nauth:\n  enabled: false\n")
+
+	cfg, _, err := config.Load(nil, envMap(map[string]string{"CONFIG_FILE": path}))
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.Storage != config.StorageMemory {
+		t.Errorf("storage = %q, want memory", cfg.Storage)
+	}
+}
+
+func TestLoadRejectsUnknownKeys(t *testing.T) {
+	path := writeFile(t, "htp:\n  addr: \":9000\"\n")
+
+	if _, _, err := config.Load([]string{"--config", path}, envMap(nil)); err == nil {
+		t.Fatal("expected an error for a misspelled key")
+	}
+}
+
+func TestLoadValidates(t *testing.T) {
+	_, _, err := config.Load(
+		[]string{"--storage", "mongo", "--assignment.reviewers", "0", "--http.addr", "8080"},
+		envMap(nil),
+	)
+	if err == nil {
+		t.Fatal("expected a validation error")
+	}
+	for _, want := range []string{"storage", "reviewers", "http.addr"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not mention %s", err, want)
+		}
+	}
+
+	_, _, err = config.Load(nil, envMap(map[string]string{"STORAGE": "memory", "AUTH_ENABLED": "true"}))
+	if err == nil || !strings.Contains(err.Error(), "auth.bootstrap_token") {
+		t.Errorf("memory storage with auth and no bootstrap token: error = %v", err)
+	}
+
+	_, _, err = config.Load(nil, envMap(map[string]string{"DB_PORT": "five"}))
+	if err == nil || !strings.Contains(err.Error(), "DB_PORT") {
+		t.Errorf("error = %v, want it to name DB_PORT", err)
+	}
+}
+
+func TestLoadRateLimitRoutes(t *testing.T) {
+	path := writeFile(t, `
+http:
+  rate_limit:
+    routes:
+      /pullRequest/reassign: {rps: 2, burst: 4}
+`)
+	cfg, _, err := config.Load([]string{"--config", path}, envMap(nil))
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	routes := cfg.HTTP.RateLimit.Routes
+	if len(routes) != 1 || routes["/pullRequest/reassign"] != (config.RouteLimit{RPS: 2, Burst: 4}) {
+		t.Errorf("routes = %v, want only the file route", routes)
+	}
+
+	// An empty map in the file removes the default route limits.
+	path = writeFile(t, `
+http:
+  rate_limit:
+    routes: {}
+`)
+	if cfg, _, err = config.Load([]string{"--config", path}, envMap(nil)); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if routes := cfg.HTTP.RateLimit.Routes; len(routes) != 0 {
+		t.Errorf("routes = %v, want none", routes)
+	}
+
+	// Without the key the defaults stay.
+	path = writeFile(t, `
+http:
+  rate_limit:
+    rps: 10
+`)
+	if cfg, _, err = config.Load([]string{"--config", path}, envMap(nil)); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.HTTP.RateLimit.Routes["/pullRequest/create"].RPS == 0 {
+		t.Errorf("routes = %v, want the default route limit", cfg.HTTP.RateLimit.Routes)
+	}
+
+	cfg, _, err = config.Load(nil, envMap(map[string]string{
+		"HTTP_RATE_LIMIT_ROUTES": "/pullRequest/merge=0.5:1, /team/add=1:2",
+	}))
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	want := map[string]config.RouteLimit{
+		"/pullRequest/merge": {RPS: 0.5, Burst: 1},
+		"/team/add":          {RPS: 1, Burst: 2},
+	}
+	if got := cfg.HTTP.RateLimit.Routes; len(got) != len(want) || got["/pullRequest/merge"] != want["/pullRequest/merge"] || got["/team/add"] != want["/team/add"] {
+		t.Errorf("routes from env = %v, want %v", got, want)
+	}
+
+	_, _, err = config.Load(nil, envMap(map[string]string{"HTTP_RATE_LIMIT_ROUTES": "/team/add=1:0"}))
+	if err == nil || !strings.Contains(err.Error(), "burst") {
+		t.Errorf("error = %v, want it to mention burst", err)
+	}
+}
+
+func TestPrintRedactsSecrets(t *testing.T) {
+	cfg := config.Default()
+	cfg.Postgres.Password = "hunter2"
+	cfg.Postgres.DSN = "postgres://app:hunter2@db:5432/reviews?sslmode=require&password=hunter2"
+	cfg.Integrations.GitHubSecret = "gh-secret"
+	cfg.Auth.BootstrapToken = "bootstrap-bootstrap-bootstrap-bootstrap"
+	cfg.Integrations.OutboxWebhookURL = "https://hooks.example.com/in?token=abc&team=backend"
+
+	var buf bytes.Buffer
+	if err := config.Print(&buf, cfg); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+
+	for _, secret := range []string{"hunter2", "gh-secret", "abc", "bootstrap-"} {
+		if strings.Contains(out, secret) {
+			t.Errorf("output contains %q:\n%s", secret, out)
+		}
+	}
+	for _, kept := range []string{"sslmode=require", "team=backend", "write_timeout: 30s", "gitlab_token: \"\""} {
+		if !strings.Contains(out, kept) {
+			t.Errorf("output lacks %q:\n%s", kept, out)
+		}
+	}
+}
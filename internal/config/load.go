@@ -0,0 +1,266 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// setting is one value that can come from both the environment and a
+// flag. The flag is named after the YAML path.
+type setting struct {
+	flag string
+	env  string
+	set  func(string) error
+}
+
+func settings(c *Config) []setting {
+	return []setting{
+		{"http.addr", "HTTP_ADDR", stringVar(&c.HTTP.Addr)},
+		{"http.read_header_timeout", "HTTP_READ_HEADER_TIMEOUT", durationVar(&c.HTTP.ReadHeaderTimeout)},
+		{"http.read_timeout", "HTTP_READ_TIMEOUT", durationVar(&c.HTTP.ReadTimeout)},
+		{"http.write_timeout", "HTTP_WRITE_TIMEOUT", durationVar(&c.HTTP.WriteTimeout)},
+		{"http.idle_timeout", "HTTP_IDLE_TIMEOUT", durationVar(&c.HTTP.IdleTimeout)},
+		{"http.drain_delay", "HTTP_DRAIN_DELAY", durationVar(&c.HTTP.DrainDelay)},
+		{"http.shutdown_timeout", "HTTP_SHUTDOWN_TIMEOUT", durationVar(&c.HTTP.ShutdownTimeout)},
+		{"http.idempotency_ttl", "HTTP_IDEMPOTENCY_TTL", durationVar(&c.HTTP.IdempotencyTTL)},
+		{"http.rate_limit.enabled", "HTTP_RATE_LIMIT_ENABLED", boolVar(&c.HTTP.RateLimit.Enabled)},
+		{"http.rate_limit.rps", "HTTP_RATE_LIMIT_RPS", float64Var(&c.HTTP.RateLimit.RPS)},
+		{"http.rate_limit.burst", "HTTP_RATE_LIMIT_BURST", intVar(&c.HTTP.RateLimit.Burst)},
+		{"http.rate_limit.routes", "HTTP_RATE_LIMIT_ROUTES", routeLimitsVar(&c.HTTP.RateLimit.Routes)},
+		{"http.rate_limit.auth_failures.rps", "HTTP_RATE_LIMIT_AUTH_FAILURES_RPS", float64Var(&c.HTTP.RateLimit.AuthFailures.RPS)},
+		{"http.rate_limit.auth_failures.burst", "HTTP_RATE_LIMIT_AUTH_FAILURES_BURST", intVar(&c.HTTP.RateLimit.AuthFailures.Burst)},
+
+		{"storage", "STORAGE", stringVar(&c.Storage)},
+
+		{"postgres.dsn", "DB_DSN", stringVar(&c.Postgres.DSN)},
+		{"postgres.host", "DB_HOST", stringVar(&c.Postgres.Host)},
+		{"postgres.port", "DB_PORT", intVar(&c.Postgres.Port)},
+		{"postgres.user", "DB_USER", stringVar(&c.Postgres.User)},
+		{"postgres.password", "DB_PASSWORD", stringVar(&c.Postgres.Password)},
+		{"postgres.name", "DB_NAME", stringVar(&c.Postgres.Name)},
+		{"postgres.tls.mode", "DB_SSLMODE", stringVar(&c.Postgres.TLS.Mode)},
+		{"postgres.tls.root_cert", "DB_SSLROOTCERT", stringVar(&c.Postgres.TLS.RootCert)},
+		{"postgres.tls.cert", "DB_SSLCERT", stringVar(&c.Postgres.TLS.Cert)},
+		{"postgres.tls.key", "DB_SSLKEY", stringVar(&c.Postgres.TLS.Key)},
+		{"postgres.pool.max_open_conns", "DB_MAX_OPEN_CONNS", intVar(&c.Postgres.Pool.MaxOpenConns)},
+		{"postgres.pool.max_idle_conns", "DB_MAX_IDLE_CONNS", intVar(&c.Postgres.Pool.MaxIdleConns)},
+		{"postgres.pool.conn_max_lifetime", "DB_CONN_MAX_LIFETIME", durationVar(&c.Postgres.Pool.ConnMaxLifetime)},
+		{"postgres.pool.conn_max_idle_time", "DB_CONN_MAX_IDLE_TIME", durationVar(&c.Postgres.Pool.ConnMaxIdleTime)},
+		{"postgres.connect.attempts", "DB_CONNECT_ATTEMPTS", intVar(&c.Postgres.Connect.Attempts)},
+		{"postgres.connect.backoff", "DB_CONNECT_BACKOFF", durationVar(&c.Postgres.Connect.Backoff)},
+		{"postgres.connect.max_backoff", "DB_CONNECT_MAX_BACKOFF", durationVar(&c.Postgres.Connect.MaxBackoff)},
+		{"postgres.connect.ping_timeout", "DB_PING_TIMEOUT", durationVar(&c.Postgres.Connect.PingTimeout)},
+
+		{"sqlite.path", "SQLITE_PATH", stringVar(&c.SQLite.Path)},
+
+		{"assignment.strategy", "ASSIGNMENT_STRATEGY", stringVar(&c.Assignment.Strategy)},
+		{"assignment.reviewers", "ASSIGNMENT_REVIEWERS", intVar(&c.Assignment.Reviewers)},
+		{"assignment.seed", "ASSIGNMENT_SEED", int64Var(&c.Assignment.Seed)},
+
+		{"auth.enabled", "AUTH_ENABLED", boolVar(&c.Auth.Enabled)},
+		{"auth.bootstrap_token", "AUTH_BOOTSTRAP_TOKEN", stringVar(&c.Auth.BootstrapToken)},
+		{"auth.jwt.jwks", "AUTH_JWT_JWKS", stringVar(&c.Auth.JWT.JWKS)},
+		{"auth.jwt.issuer", "AUTH_JWT_ISSUER", stringVar(&c.Auth.JWT.Issuer)},
+		{"auth.jwt.audience", "AUTH_JWT_AUDIENCE", stringVar(&c.Auth.JWT.Audience)},
+		{"auth.jwt.user_claim", "AUTH_JWT_USER_CLAIM", stringVar(&c.Auth.JWT.UserClaim)},
+		{"auth.jwt.leeway", "AUTH_JWT_LEEWAY", durationVar(&c.Auth.JWT.Leeway)},
+
+		{"features.metrics", "FEATURE_METRICS", boolVar(&c.Features.Metrics)},
+		{"features.events", "FEATURE_EVENTS", boolVar(&c.Features.Events)},
+		{"features.webhooks", "FEATURE_WEBHOOKS", boolVar(&c.Features.Webhooks)},
+
+		{"integrations.github_secret", "GITHUB_WEBHOOK_SECRET", stringVar(&c.Integrations.GitHubSecret)},
+		{"integrations.gitlab_token", "GITLAB_WEBHOOK_TOKEN", stringVar(&c.Integrations.GitLabToken)},
+		{"integrations.outbox_webhook_url", "OUTBOX_WEBHOOK_URL", stringVar(&c.Integrations.OutboxWebhookURL)},
+
+		{"tracing.exporter", "OTEL_TRACES_EXPORTER", stringVar(&c.Tracing.Exporter)},
+	}
+}
+
+// Load resolves the configuration from Default, then the YAML file named
+// by --config or CONFIG_FILE, then environment variables, then flags, and
+// validates the result. printConfig reports whether --print-config was
+// given.
+func Load(args []string, lookupEnv func(string) (string, bool)) (cfg Config, printConfig bool, err error) {
+	cfg = Default()
+	table := settings(&cfg)
+
+	fs := flag.NewFlagSet("app", flag.ContinueOnError)
+	path := fs.String("config", "", "YAML configuration file (env CONFIG_FILE)")
+	fs.BoolVar(&printConfig, "print-config", false, "print the resolved configuration with secrets redacted and exit")
+
+	// Flag values are applied after the file and the environment.
+	var fromFlags []func() error
+	for _, s := range table {
+		fs.Func(s.flag, "env "+s.env, func(v string) error {
+			fromFlags = append(fromFlags, func() error {
+				if err := s.set(v); err != nil {
+					return fmt.Errorf("flag --%s: %w", s.flag, err)
+				}
+				return nil
+			})
+			return nil
+		})
+	}
+	if err := fs.Parse(args); err != nil {
+		return cfg, false, err
+	}
+	if fs.NArg() > 0 {
+		return cfg, false, fmt.Errorf("unexpected arguments %q", fs.Args())
+	}
+
+	if *path == "" {
+		*path, _ = lookupEnv("CONFIG_FILE")
+	}
+	if *path != "" {
+		if err := loadFile(&cfg, *path); err != nil {
+			return cfg, false, err
+		}
+	}
+
+	for _, s := range table {
+		v, ok := lookupEnv(s.env)
+		if !ok || v == "" {
+			continue
+		}
+		if err := s.set(v); err != nil {
+			return cfg, false, fmt.Errorf("%s: %w", s.env, err)
+		}
+	}
+
+	for _, apply := range fromFlags {
+		if err := apply(); err != nil {
+			return cfg, false, err
+		}
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return cfg, false, fmt.Errorf("invalid configuration:\n%w", err)
+	}
+	return cfg, printConfig, nil
+}
+
+// loadFile overlays the YAML file at path on cfg. Unknown keys are an
+// error, so that typos do not go unnoticed.
+func loadFile(cfg *Config, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read config: %w", err)
+	}
+
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("parse config %s: %w", path, err)
+	}
+	return nil
+}
+
+// Print writes c as YAML with secrets redacted.
+func Print(w io.Writer, c Config) error {
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(c.Redacted()); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+func stringVar(dst *string) func(string) error {
+	return func(v string) error {
+		*dst = v
+		return nil
+	}
+}
+
+func intVar(dst *int) func(string) error {
+	return func(v string) error {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", v)
+		}
+		*dst = n
+		return nil
+	}
+}
+
+func int64Var(dst *int64) func(string) error {
+	return func(v string) error {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", v)
+		}
+		*dst = n
+		return nil
+	}
+}
+
+func float64Var(dst *float64) func(string) error {
+	return func(v string) error {
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return fmt.Errorf("invalid number %q", v)
+		}
+		*dst = f
+		return nil
+	}
+}
+
+// routeLimitsVar parses "/path=rps:burst,/other=rps:burst" and replaces
+// all route limits.
+func routeLimitsVar(dst *RouteLimits) func(string) error {
+	return func(v string) error {
+		routes := make(RouteLimits)
+		for _, item := range strings.Split(v, ",") {
+			route, limit, ok := strings.Cut(strings.TrimSpace(item), "=")
+			rps, burst, ok2 := strings.Cut(limit, ":")
+			if !ok || !ok2 {
+				return fmt.Errorf("invalid route limit %q, expected /path=rps:burst", item)
+			}
+			var (
+				l   RouteLimit
+				err error
+			)
+			if l.RPS, err = strconv.ParseFloat(rps, 64); err != nil {
+				return fmt.Errorf("invalid rps in %q", item)
+			}
+			if l.Burst, err = strconv.Atoi(burst); err != nil {
+				return fmt.Errorf("invalid burst in %q", item)
+			}
+			routes[route] = l
+		}
+		*dst = routes
+		return nil
+	}
+}
+
+func boolVar(dst *bool) func(string) error {
+	return func(v string) error {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("invalid boolean %q", v)
+		}
+		*dst = b
+		return nil
+	}
+}
+
+func durationVar(dst *time.Duration) func(string) error {
+	return func(v string) error {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return fmt.Errorf("invalid duration %q", v)
+		}
+		*dst = d
+		return nil
+	}
+}
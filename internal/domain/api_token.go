@@ -0,0 +1,39 @@
+package domain
+
+import "time"
+
+// Role decides what an API token may do.
+type Role string
+
+const (
+	// RoleAdmin may do anything.
+	RoleAdmin Role = "admin"
+	// RoleTeamLead may additionally manage the members of one team.
+	RoleTeamLead Role = "team_lead"
+	// RoleDeveloper acts as one user.
+	RoleDeveloper Role = "developer"
+)
+
+// APIToken is a credential for the HTTP API. Only the SHA-256 hash of
+// the secret is stored. TeamName is set for team leads; UserID is
+// required for developers and optional for the other roles.
+type APIToken struct {
+	ID        int64
+	Name      string
+	Hash      string
+	Role      Role
+	TeamName  TeamName
+	UserID    UserID
+	CreatedAt time.Time
+	RevokedAt *time.Time
+}
+
+// Principal is an authenticated caller: the holder of an API token, or a
+// user signed in through the SSO, who has no TokenID.
+type Principal struct {
+	TokenID  int64
+	Name     string
+	Role     Role
+	TeamName TeamName
+	UserID   UserID
+}
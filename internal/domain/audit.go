@@ -0,0 +1,59 @@
+package domain
+
+import "time"
+
+type AuditAction string
+
+const (
+	AuditActionTeamAdd       AuditAction = "team.add"
+	AuditActionUserSetActive AuditAction = "user.set_is_active"
+	AuditActionPRCreate      AuditAction = "pr.create"
+	AuditActionPRReassign    AuditAction = "pr.reassign"
+	AuditActionPRMerge       AuditAction = "pr.merge"
+
+	AuditActionWebhookCreate    AuditAction = "webhook.create"
+	AuditActionWebhookUpdate    AuditAction = "webhook.update"
+	AuditActionWebhookDelete    AuditAction = "webhook.delete"
+	AuditActionWebhookRedeliver AuditAction = "webhook.redeliver"
+
+	AuditActionExternalAccountSet    AuditAction = "external_account.set"
+	AuditActionExternalAccountDelete AuditAction = "external_account.delete"
+
+	AuditActionAPITokenCreate AuditAction = "api_token.create"
+	AuditActionAPITokenRevoke AuditAction = "api_token.revoke"
+)
+
+type AuditEntity string
+
+const (
+	AuditEntityTeam            AuditEntity = "team"
+	AuditEntityUser            AuditEntity = "user"
+	AuditEntityPullRequest     AuditEntity = "pull_request"
+	AuditEntityWebhook         AuditEntity = "webhook"
+	AuditEntityExternalAccount AuditEntity = "external_account"
+	AuditEntityAPIToken        AuditEntity = "api_token"
+)
+
+// AuditEvent is an immutable record of a state change.
+// Before and After hold JSON snapshots of the entity; either may be nil.
+type AuditEvent struct {
+	ID         int64
+	Actor      string
+	Action     AuditAction
+	EntityType AuditEntity
+	EntityID   string
+	Before     []byte
+	After      []byte
+	RequestID  string
+	CreatedAt  time.Time
+}
+
+// AuditFilter selects audit events, newest first.
+// Empty fields match everything; BeforeID is an exclusive cursor.
+type AuditFilter struct {
+	EntityType AuditEntity
+	EntityID   string
+	Actor      string
+	BeforeID   int64
+	Limit      int
+}
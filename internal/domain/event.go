@@ -0,0 +1,76 @@
+package domain
+
+import "time"
+
+type EventType string
+
+const (
+	EventTeamCreated          EventType = "team.created"
+	EventUserActivated        EventType = "user.activated"
+	EventUserDeactivated      EventType = "user.deactivated"
+	EventPRCreated            EventType = "pr.created"
+	EventPRReviewerReassigned EventType = "pr.reviewer_reassigned"
+	EventPRMerged             EventType = "pr.merged"
+)
+
+// Event is a typed domain event. Its JSON encoding is the event payload
+// seen by consumers, so field tags are part of the public contract.
+type Event interface {
+	EventType() EventType
+}
+
+type TeamCreated struct {
+	TeamName TeamName `json:"team_name"`
+	Members  []UserID `json:"members"`
+}
+
+type UserActivityChanged struct {
+	UserID   UserID   `json:"user_id"`
+	TeamName TeamName `json:"team_name"`
+	IsActive bool     `json:"is_active"`
+}
+
+type PRCreated struct {
+	PullRequestID PullRequestID `json:"pull_request_id"`
+	Title         string        `json:"pull_request_name"`
+	AuthorID      UserID        `json:"author_id"`
+	ReviewerIDs   []UserID      `json:"assigned_reviewers"`
+}
+
+type PRReviewerReassigned struct {
+	PullRequestID PullRequestID `json:"pull_request_id"`
+	OldReviewerID UserID        `json:"old_user_id"`
+	NewReviewerID UserID        `json:"new_user_id"`
+	Reason        string        `json:"reason"`
+}
+
+type PRMerged struct {
+	PullRequestID PullRequestID `json:"pull_request_id"`
+	AuthorID      UserID        `json:"author_id"`
+	ReviewerIDs   []UserID      `json:"assigned_reviewers"`
+	MergedAt      time.Time     `json:"merged_at"`
+}
+
+func (TeamCreated) EventType() EventType          { return EventTeamCreated }
+func (PRCreated) EventType() EventType            { return EventPRCreated }
+func (PRReviewerReassigned) EventType() EventType { return EventPRReviewerReassigned }
+func (PRMerged) EventType() EventType             { return EventPRMerged }
+
+func (e UserActivityChanged) EventType() EventType {
+	if e.IsActive {
+		return EventUserActivated
+	}
+	return EventUserDeactivated
+}
+
+// OutboxEvent is a domain event persisted in the outbox together with
+// the change that produced it.
+type OutboxEvent struct {
+	ID        int64
+	Type      EventType
+	Payload   []byte
+	RequestID string
+	CreatedAt time.Time
+	// Attempts counts dispatch attempts including the current one.
+	Attempts int
+}
@@ -0,0 +1,21 @@
+package domain
+
+import "time"
+
+// ExternalProvider is a code hosting service whose webhooks we ingest.
+type ExternalProvider string
+
+const (
+	ProviderGitHub ExternalProvider = "github"
+	ProviderGitLab ExternalProvider = "gitlab"
+)
+
+// ExternalAccount maps a provider login to one of our users.
+// Logins are stored lower-case, as both providers compare them
+// case-insensitively.
+type ExternalAccount struct {
+	Provider  ExternalProvider
+	Login     string
+	UserID    UserID
+	CreatedAt time.Time
+}
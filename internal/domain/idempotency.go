@@ -0,0 +1,21 @@
+package domain
+
+import "time"
+
+// IdempotencyKey remembers the response to a request sent with an
+// Idempotency-Key header, so that retries get the same response instead
+// of repeating the change. Keys are scoped to the client that sent them.
+// Status is zero while the first request is still running.
+type IdempotencyKey struct {
+	Scope       string
+	Key         string
+	RequestHash string
+	Status      int
+	Body        []byte
+	CreatedAt   time.Time
+	ExpiresAt   time.Time
+}
+
+func (k *IdempotencyKey) Pending() bool {
+	return k.Status == 0
+}
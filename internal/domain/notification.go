@@ -0,0 +1,25 @@
+package domain
+
+import "time"
+
+type ReviewNotificationType string
+
+const (
+	ReviewAssigned   ReviewNotificationType = "review.assigned"
+	ReviewUnassigned ReviewNotificationType = "review.unassigned"
+	ReviewPRMerged   ReviewNotificationType = "review.pr_merged"
+)
+
+// ReviewNotification tells UserID that their set of reviews changed.
+// Notifications are published after the change is committed and are
+// not persisted.
+type ReviewNotification struct {
+	Type          ReviewNotificationType
+	UserID        UserID
+	PullRequestID PullRequestID
+	Title         string
+	AuthorID      UserID
+	// Reason is the reviewer event reason for assignment changes.
+	Reason string
+	At     time.Time
+}
@@ -20,3 +20,32 @@ type PullRequest struct {
 	CreatedAt   time.Time
 	MergedAt    *time.Time
 }
+
+// PullRequestCursor is the position of a pull request in a listing
+// ordered by (CreatedAt, ID).
+type PullRequestCursor struct {
+	CreatedAt time.Time
+	ID        PullRequestID
+}
+
+// PullRequestFilter selects pull requests ordered by creation time, oldest
+// first unless Desc is set. Empty fields match everything; CreatedFrom is
+// inclusive, CreatedTo is exclusive and After is an exclusive cursor in
+// the chosen order. TeamName matches the author's current team and
+// TitleContains is a case-insensitive substring.
+type PullRequestFilter struct {
+	AuthorID      UserID
+	ReviewerID    UserID
+	TeamName      TeamName
+	Status        PRStatus
+	TitleContains string
+	CreatedFrom   time.Time
+	CreatedTo     time.Time
+	After         *PullRequestCursor
+	Desc          bool
+	Limit         int
+}
+
+func (pr PullRequest) Cursor() PullRequestCursor {
+	return PullRequestCursor{CreatedAt: pr.CreatedAt, ID: pr.ID}
+}
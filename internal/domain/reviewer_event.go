@@ -0,0 +1,37 @@
+package domain
+
+import "time"
+
+type ReviewerEventType string
+
+const (
+	ReviewerEventAssigned   ReviewerEventType = "assigned"
+	ReviewerEventReassigned ReviewerEventType = "reassigned"
+	ReviewerEventRemoved    ReviewerEventType = "removed"
+)
+
+// Reasons recorded by the service; callers may supply their own
+// reason for manual reassignments.
+const (
+	ReviewerReasonAutoAssign = "auto_assign"
+	ReviewerReasonManual     = "manual_reassign"
+)
+
+// ReviewerEvent is a change of a pull request's reviewer set.
+// FromUserID is empty for assigned events, ToUserID for removed ones.
+type ReviewerEvent struct {
+	ID            int64
+	PullRequestID PullRequestID
+	Type          ReviewerEventType
+	FromUserID    UserID
+	ToUserID      UserID
+	Reason        string
+	Actor         string
+	CreatedAt     time.Time
+}
+
+// ReassignmentStat counts how often a user was reassigned away from a review.
+type ReassignmentStat struct {
+	UserID UserID
+	Count  int64
+}
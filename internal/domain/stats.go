@@ -1,6 +1,113 @@
 package domain
 
+import "time"
+
+type AssignmentGroupBy string
+
+const (
+	GroupByUser AssignmentGroupBy = "user"
+	GroupByTeam AssignmentGroupBy = "team"
+	GroupByDay  AssignmentGroupBy = "day"
+	GroupByWeek AssignmentGroupBy = "week"
+)
+
+func IsAssignmentGroupBy(g AssignmentGroupBy) bool {
+	switch g {
+	case GroupByUser, GroupByTeam, GroupByDay, GroupByWeek:
+		return true
+	}
+	return false
+}
+
+// AssignmentStatsFilter selects current reviewer assignments. The window
+// applies to the creation time of the pull request: From is inclusive, To
+// is exclusive and zero values are unbounded. TeamName matches the
+// reviewer's current team. An empty GroupBy groups by user.
+type AssignmentStatsFilter struct {
+	From     time.Time
+	To       time.Time
+	TeamName TeamName
+	GroupBy  AssignmentGroupBy
+}
+
+// ReviewerAssignmentStat counts assignments in one group. Key is a user
+// id, a team name, a UTC day (2006-01-02) or the Monday starting a UTC
+// week, depending on the grouping.
 type ReviewerAssignmentStat struct {
-	UserID UserID
+	Key    string
 	Count  int64
+	Open   int64
+	Merged int64
+}
+
+// MergeTimesFilter selects merged pull requests by merge time: From is
+// inclusive, To is exclusive and zero values are unbounded. TeamName
+// matches the author's current team.
+type MergeTimesFilter struct {
+	From     time.Time
+	To       time.Time
+	TeamName TeamName
+}
+
+// MergeTime is one merged pull request as a cycle-time sample.
+type MergeTime struct {
+	PullRequestID PullRequestID
+	AuthorID      UserID
+	TeamName      TeamName
+	CreatedAt     time.Time
+	MergedAt      time.Time
+}
+
+func (m MergeTime) Duration() time.Duration {
+	return m.MergedAt.Sub(m.CreatedAt)
+}
+
+// CycleTimeStat summarizes time-to-merge of one group. Key is a team
+// name, an author id, or empty for the overall figures.
+type CycleTimeStat struct {
+	Key   string
+	Count int
+	P50   time.Duration
+	P90   time.Duration
+	P99   time.Duration
+}
+
+type CycleTimeReport struct {
+	Overall  CycleTimeStat
+	ByTeam   []CycleTimeStat
+	ByAuthor []CycleTimeStat
+}
+
+// FairnessFilter selects a team and a window; both bounds are required.
+type FairnessFilter struct {
+	TeamName TeamName
+	From     time.Time
+	To       time.Time
+}
+
+// MemberFairness compares a member's share of the team's assignments with
+// the share expected from the time the member was active in the window.
+type MemberFairness struct {
+	UserID        UserID
+	Username      string
+	ActiveDays    float64
+	Assignments   int64
+	Share         float64
+	ExpectedShare float64
+	// ZScore is the distance from the expected count in binomial standard
+	// deviations; it is 0 when the expected share is 0 or 1.
+	ZScore  float64
+	Outlier bool
+}
+
+// FairnessReport describes how evenly a team's assignments in the window
+// were spread. Gini is computed over assignments per active day of the
+// members that were active at all: 0 means an even load.
+type FairnessReport struct {
+	TeamName TeamName
+	From     time.Time
+	To       time.Time
+	Total    int64
+	Gini     float64
+	Members  []MemberFairness
 }
@@ -9,3 +9,25 @@ type User struct {
 	IsActive bool
 	TeamName TeamName
 }
+
+// UserCursor is the position of a user in a listing ordered by
+// (Username, ID).
+type UserCursor struct {
+	Username string
+	ID       UserID
+}
+
+// UserFilter selects users ordered by username. Empty fields match
+// everything; UsernamePrefix is case-insensitive and After is an
+// exclusive cursor.
+type UserFilter struct {
+	UsernamePrefix string
+	TeamName       TeamName
+	IsActive       *bool
+	After          *UserCursor
+	Limit          int
+}
+
+func (u User) Cursor() UserCursor {
+	return UserCursor{Username: u.Username, ID: u.ID}
+}
@@ -0,0 +1,76 @@
+package domain
+
+import "time"
+
+// WebhookEventTypes are the events that can be subscribed to.
+var WebhookEventTypes = []EventType{
+	EventPRCreated,
+	EventPRReviewerReassigned,
+	EventPRMerged,
+	EventUserDeactivated,
+}
+
+func IsWebhookEventType(t EventType) bool {
+	for _, et := range WebhookEventTypes {
+		if et == t {
+			return true
+		}
+	}
+	return false
+}
+
+// Webhook is a subscription: events of EventTypes are posted to URL
+// and signed with Secret.
+type Webhook struct {
+	ID         int64
+	URL        string
+	Secret     string
+	EventTypes []EventType
+	IsActive   bool
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+type WebhookDeliveryStatus string
+
+const (
+	WebhookDeliveryPending   WebhookDeliveryStatus = "pending"
+	WebhookDeliverySucceeded WebhookDeliveryStatus = "succeeded"
+	WebhookDeliveryFailed    WebhookDeliveryStatus = "failed"
+)
+
+// WebhookDelivery is an entry of the delivery log: one event sent to one
+// webhook, with the outcome of the latest attempt.
+type WebhookDelivery struct {
+	ID        int64
+	WebhookID int64
+	EventID   int64
+	EventType EventType
+	Status    WebhookDeliveryStatus
+	Attempts  int
+	// ResponseStatus is the HTTP status of the latest attempt, 0 if none.
+	ResponseStatus int
+	LastError      string
+	// RedeliveryOf is the delivery this one repeats, if requested via the API.
+	RedeliveryOf  *int64
+	NextAttemptAt time.Time
+	CreatedAt     time.Time
+	DeliveredAt   *time.Time
+}
+
+// WebhookDeliveryFilter selects deliveries, newest first.
+// Empty fields match everything; BeforeID is an exclusive cursor.
+type WebhookDeliveryFilter struct {
+	WebhookID int64
+	Status    WebhookDeliveryStatus
+	BeforeID  int64
+	Limit     int
+}
+
+// WebhookJob is a claimed delivery together with what is needed to send it.
+type WebhookJob struct {
+	Delivery WebhookDelivery
+	URL      string
+	Secret   string
+	Event    OutboxEvent
+}
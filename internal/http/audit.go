@@ -0,0 +1,105 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/terps489/avito_tech_internship/internal/app"
+	"github.com/terps489/avito_tech_internship/internal/domain"
+)
+
+const (
+	defaultAuditLimit = 50
+	maxAuditLimit     = 200
+)
+
+func (s *Server) handleAuditList(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeMethodNotAllowed(w)
+		return
+	}
+
+	q := r.URL.Query()
+	filter := domain.AuditFilter{
+		EntityType: domain.AuditEntity(q.Get("entity_type")),
+		EntityID:   q.Get("entity_id"),
+		Actor:      q.Get("actor"),
+		Limit:      defaultAuditLimit,
+	}
+
+	if v := q.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 || limit > maxAuditLimit {
+			writeJSON(w, http.StatusBadRequest, ErrorResponse{
+				Error: ErrorPayload{
+					Code:    ErrorCodeBadRequest,
+					Message: "limit must be between 1 and " + strconv.Itoa(maxAuditLimit),
+				},
+			})
+			return
+		}
+		filter.Limit = limit
+	}
+
+	if v := q.Get("cursor"); v != "" {
+		cursor, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || cursor < 1 {
+			writeJSON(w, http.StatusBadRequest, ErrorResponse{
+				Error: ErrorPayload{
+					Code:    ErrorCodeBadRequest,
+					Message: "invalid cursor",
+				},
+			})
+			return
+		}
+		filter.BeforeID = cursor
+	}
+
+	events, err := s.service.ListAuditEvents(r.Context(), filter)
+	if err != nil {
+		if errors.Is(err, app.ErrForbidden) {
+			writeForbidden(w)
+			return
+		}
+		writeInternalError(w, err)
+		return
+	}
+
+	resp := struct {
+		Events     []AuditEventDTO `json:"events"`
+		NextCursor *string         `json:"next_cursor,omitempty"`
+	}{
+		Events: make([]AuditEventDTO, 0, len(events)),
+	}
+
+	for _, e := range events {
+		resp.Events = append(resp.Events, AuditEventDTO{
+			ID:         e.ID,
+			Actor:      e.Actor,
+			Action:     string(e.Action),
+			EntityType: string(e.EntityType),
+			EntityID:   e.EntityID,
+			Before:     rawJSON(e.Before),
+			After:      rawJSON(e.After),
+			RequestID:  e.RequestID,
+			CreatedAt:  e.CreatedAt.UTC().Format(time.RFC3339Nano),
+		})
+	}
+
+	if len(events) == filter.Limit {
+		next := strconv.FormatInt(events[len(events)-1].ID, 10)
+		resp.NextCursor = &next
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// rawJSON maps a missing snapshot to JSON null.
+func rawJSON(b []byte) []byte {
+	if len(b) == 0 {
+		return []byte("null")
+	}
+	return b
+}
@@ -0,0 +1,127 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/terps489/avito_tech_internship/internal/app"
+	"github.com/terps489/avito_tech_internship/internal/domain"
+)
+
+// Authenticator turns a bearer token into a principal. It returns an
+// error wrapping app.ErrUnauthenticated for tokens it does not accept.
+// *app.Service authenticates API tokens.
+type Authenticator interface {
+	Authenticate(ctx context.Context, raw string) (domain.Principal, error)
+}
+
+// publicRoutes do not need an API token: probes and metrics are scraped
+// by infrastructure, and provider webhooks carry their own secrets.
+var publicRoutes = map[string]bool{
+	"/health":              true,
+	"/livez":               true,
+	"/readyz":              true,
+	"/metrics":             true,
+	"/integrations/github": true,
+	"/integrations/gitlab": true,
+}
+
+// WithTokenAuth requires an API token in the Authorization: Bearer header
+// on every route except publicRoutes.
+func WithTokenAuth() Option {
+	return func(s *Server) {
+		s.authenticators = append(s.authenticators, s.service)
+	}
+}
+
+// WithAuthenticator accepts bearer tokens that a also accepts, such as
+// JWTs. Authenticators are tried in the order of the options.
+func WithAuthenticator(a Authenticator) Option {
+	return func(s *Server) {
+		s.authenticators = append(s.authenticators, a)
+	}
+}
+
+// withAuth puts the principal of the bearer token into the request
+// context, where app.Service checks it, or answers 401. With rate limits,
+// an IP address that keeps failing gets 429 before its tokens are checked.
+func (s *Server) withAuth(next http.Handler) http.Handler {
+	if len(s.authenticators) == 0 {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if publicRoutes[r.URL.Path] {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if !s.takeAuthAttempt(w, r) {
+			return
+		}
+
+		raw, ok := bearerToken(r)
+		if !ok {
+			writeUnauthorized(w, "bearer token is required")
+			return
+		}
+
+		p, err := s.authenticate(r.Context(), raw)
+		if errors.Is(err, app.ErrUnauthenticated) {
+			s.logger.DebugContext(r.Context(), "authentication failed", "error", err.Error())
+			writeUnauthorized(w, "token is invalid or revoked")
+			return
+		}
+		s.refundAuthAttempt(r)
+		if err != nil {
+			writeInternalError(w, err)
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(app.WithPrincipal(r.Context(), p)))
+	})
+}
+
+// authenticate returns the principal from the first authenticator that
+// accepts raw. When none does, the last rejection is returned.
+func (s *Server) authenticate(ctx context.Context, raw string) (domain.Principal, error) {
+	var err error
+	for _, a := range s.authenticators {
+		var p domain.Principal
+		p, err = a.Authenticate(ctx, raw)
+		if !errors.Is(err, app.ErrUnauthenticated) {
+			return p, err
+		}
+	}
+	return domain.Principal{}, err
+}
+
+func bearerToken(r *http.Request) (string, bool) {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
+
+func writeUnauthorized(w http.ResponseWriter, msg string) {
+	w.Header().Set("WWW-Authenticate", `Bearer realm="avito-review"`)
+	writeJSON(w, http.StatusUnauthorized, ErrorResponse{
+		Error: ErrorPayload{
+			Code:    ErrorCodeUnauthorized,
+			Message: msg,
+		},
+	})
+}
+
+func writeForbidden(w http.ResponseWriter) {
+	writeJSON(w, http.StatusForbidden, ErrorResponse{
+		Error: ErrorPayload{
+			Code:    ErrorCodeForbidden,
+			Message: "token role does not allow this action",
+		},
+	})
+}
@@ -0,0 +1,153 @@
+package http_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/terps489/avito_tech_internship/internal/app"
+	"github.com/terps489/avito_tech_internship/internal/domain"
+	httpTransport "github.com/terps489/avito_tech_internship/internal/http"
+	"github.com/terps489/avito_tech_internship/internal/pubsub"
+	"github.com/terps489/avito_tech_internship/internal/repository/memory"
+)
+
+func TestTokenAuth(t *testing.T) {
+	store := memory.NewStore()
+	svc := app.NewService(app.Repositories{
+		Users:            memory.NewUserRepository(store),
+		Teams:            memory.NewTeamRepository(store),
+		PullRequests:     memory.NewPullRequestRepository(store),
+		Audit:            memory.NewAuditRepository(store),
+		ReviewerEvents:   memory.NewReviewerEventRepository(store),
+		Outbox:           memory.NewOutboxRepository(store),
+		Webhooks:         memory.NewWebhookRepository(store),
+		ExternalAccounts: memory.NewExternalAccountRepository(store),
+		APITokens:        memory.NewAPITokenRepository(store),
+		Tx:               store,
+	})
+	ts := httptest.NewServer(httpTransport.NewServer(":0", svc,
+		httpTransport.WithTokenAuth(),
+		httpTransport.WithEventHub(pubsub.NewHub(16)),
+	).Handler())
+	t.Cleanup(ts.Close)
+
+	ctx := t.Context()
+	adminToken, _, err := svc.CreateAPIToken(ctx, domain.APIToken{Name: "admin", Role: domain.RoleAdmin})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	do := func(method, path, token, body string) *http.Response {
+		t.Helper()
+		req, err := http.NewRequest(method, ts.URL+path, strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_ = resp.Body.Close()
+		return resp
+	}
+
+	if resp := do(http.MethodGet, "/livez", "", ""); resp.StatusCode != http.StatusOK {
+		t.Errorf("/livez without a token: status = %d, want 200", resp.StatusCode)
+	}
+
+	resp := do(http.MethodGet, "/users/list", "", "")
+	if resp.StatusCode != http.StatusUnauthorized || resp.Header.Get("WWW-Authenticate") == "" {
+		t.Errorf("no token: status = %d, WWW-Authenticate = %q; want 401 with a challenge",
+			resp.StatusCode, resp.Header.Get("WWW-Authenticate"))
+	}
+	if resp := do(http.MethodGet, "/users/list", "rvw_wrong", ""); resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("unknown token: status = %d, want 401", resp.StatusCode)
+	}
+
+	team := `{"team_name":"backend","members":[{"user_id":"u1","username":"A","is_active":true},{"user_id":"u2","username":"B","is_active":true}]}`
+	if resp := do(http.MethodPost, "/team/add", adminToken, team); resp.StatusCode != http.StatusCreated {
+		t.Fatalf("admin adds a team: status = %d, want 201", resp.StatusCode)
+	}
+
+	devToken, _, err := svc.CreateAPIToken(ctx, domain.APIToken{Name: "u2", Role: domain.RoleDeveloper, UserID: "u2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp := do(http.MethodPost, "/team/add", devToken, `{"team_name":"x","members":[]}`); resp.StatusCode != http.StatusForbidden {
+		t.Errorf("developer adds a team: status = %d, want 403", resp.StatusCode)
+	}
+	if resp := do(http.MethodPost, "/users/setIsActive", devToken, `{"user_id":"u1","is_active":false}`); resp.StatusCode != http.StatusForbidden {
+		t.Errorf("developer deactivates a user: status = %d, want 403", resp.StatusCode)
+	}
+	if resp := do(http.MethodGet, "/users/get?user_id=u1", devToken, ""); resp.StatusCode != http.StatusOK {
+		t.Errorf("developer reads a user: status = %d, want 200", resp.StatusCode)
+	}
+
+	for _, tc := range []struct{ method, path, body string }{
+		{http.MethodGet, "/webhooks/list", ""},
+		{http.MethodGet, "/webhooks/get?webhook_id=1", ""},
+		{http.MethodGet, "/webhooks/deliveries", ""},
+		{http.MethodPost, "/webhooks/create", `{"url":"https://example.com/hook"}`},
+		{http.MethodPost, "/webhooks/update", `{"webhook_id":1,"is_active":false}`},
+		{http.MethodPost, "/webhooks/delete", `{"webhook_id":1}`},
+		{http.MethodPost, "/webhooks/redeliver", `{"delivery_id":1}`},
+		{http.MethodPost, "/integrations/accounts/set", `{"provider":"github","login":"octo","user_id":"u2"}`},
+		{http.MethodPost, "/integrations/accounts/delete", `{"provider":"github","login":"octo"}`},
+		{http.MethodGet, "/audit", ""},
+		{http.MethodGet, "/events/stream?user_id=u1", ""},
+	} {
+		if resp := do(tc.method, tc.path, devToken, tc.body); resp.StatusCode != http.StatusForbidden {
+			t.Errorf("developer %s %s: status = %d, want 403", tc.method, tc.path, resp.StatusCode)
+		}
+	}
+
+	if resp := do(http.MethodPost, "/team/add", adminToken, `{"team_name":"frontend","members":[{"user_id":"u3","username":"C","is_active":true}]}`); resp.StatusCode != http.StatusCreated {
+		t.Fatalf("admin adds a second team: status = %d, want 201", resp.StatusCode)
+	}
+	if resp := do(http.MethodPost, "/pullRequest/create", adminToken, `{"pull_request_id":"pr-1","pull_request_name":"x","author_id":"u1"}`); resp.StatusCode != http.StatusCreated {
+		t.Fatalf("admin creates a pull request: status = %d, want 201", resp.StatusCode)
+	}
+	outsiderToken, _, err := svc.CreateAPIToken(ctx, domain.APIToken{Name: "u3", Role: domain.RoleDeveloper, UserID: "u3"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp := do(http.MethodPost, "/pullRequest/reassign", outsiderToken, `{"pull_request_id":"pr-1","old_user_id":"u2"}`); resp.StatusCode != http.StatusForbidden {
+		t.Errorf("developer of another team reassigns: status = %d, want 403", resp.StatusCode)
+	}
+
+	leadToken, _, err := svc.CreateAPIToken(ctx, domain.APIToken{Name: "lead", Role: domain.RoleTeamLead, TeamName: "backend"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp := do(http.MethodGet, "/audit", leadToken, ""); resp.StatusCode != http.StatusOK {
+		t.Errorf("team lead reads the audit log: status = %d, want 200", resp.StatusCode)
+	}
+
+	// With auth on, X-Actor-ID must not override the token in the audit log.
+	req, err := http.NewRequest(http.MethodPost, ts.URL+"/webhooks/create", strings.NewReader(`{"url":"https://example.com/hook"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Authorization", "Bearer "+adminToken)
+	req.Header.Set("X-Actor-ID", "u1")
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("admin creates a webhook: status = %d, want 201", resp.StatusCode)
+	}
+	events, err := svc.ListAuditEvents(ctx, domain.AuditFilter{EntityType: domain.AuditEntityWebhook})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 1 || events[0].Actor != "token:admin" {
+		t.Errorf("webhook audit = %+v, want one event by token:admin", events)
+	}
+}
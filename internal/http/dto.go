@@ -1,5 +1,7 @@
 package http
 
+import "encoding/json"
+
 // --- Error DTO ---
 
 type ErrorCode string
@@ -11,6 +13,16 @@ const (
 	ErrorCodeNotAssigned ErrorCode = "NOT_ASSIGNED"
 	ErrorCodeNoCandidate ErrorCode = "NO_CANDIDATE"
 	ErrorCodeNotFound    ErrorCode = "NOT_FOUND"
+	ErrorCodeBadRequest  ErrorCode = "BAD_REQUEST"
+	ErrorCodeInternal    ErrorCode = "INTERNAL"
+
+	ErrorCodeMethodNotAllowed ErrorCode = "METHOD_NOT_ALLOWED"
+	ErrorCodeUnauthorized     ErrorCode = "UNAUTHORIZED"
+	ErrorCodeForbidden        ErrorCode = "FORBIDDEN"
+	ErrorCodeRateLimited      ErrorCode = "RATE_LIMITED"
+
+	ErrorCodeIdempotencyKeyReused     ErrorCode = "IDEMPOTENCY_KEY_REUSED"
+	ErrorCodeIdempotencyKeyInProgress ErrorCode = "IDEMPOTENCY_KEY_IN_PROGRESS"
 )
 
 type ErrorResponse struct {
@@ -42,6 +54,11 @@ type UserDTO struct {
 	IsActive bool   `json:"is_active"`
 }
 
+type UserProfileDTO struct {
+	UserDTO
+	OpenReviews int `json:"open_reviews"`
+}
+
 // --- Pull Requests DTO ---
 
 type PullRequestDTO struct {
@@ -55,10 +72,12 @@ type PullRequestDTO struct {
 }
 
 type PullRequestShortDTO struct {
-	ID       string `json:"pull_request_id"`
-	Name     string `json:"pull_request_name"`
-	AuthorID string `json:"author_id"`
-	Status   string `json:"status"`
+	ID        string  `json:"pull_request_id"`
+	Name      string  `json:"pull_request_name"`
+	AuthorID  string  `json:"author_id"`
+	Status    string  `json:"status"`
+	CreatedAt *string `json:"createdAt,omitempty"`
+	MergedAt  *string `json:"mergedAt,omitempty"`
 }
 
 // --- Requests DTO ---
@@ -81,10 +100,159 @@ type MergePRRequest struct {
 type ReassignReviewerRequest struct {
 	PRID      string `json:"pull_request_id"`
 	OldUserID string `json:"old_user_id"`
+	Reason    string `json:"reason,omitempty"`
 }
 
 // --- Stats DTO ---
 type ReviewerAssignmentDTO struct {
 	UserID string `json:"user_id"`
 	Count  int64  `json:"count"`
+	Open   int64  `json:"open"`
+	Merged int64  `json:"merged"`
+}
+
+type AssignmentGroupDTO struct {
+	Key    string `json:"key"`
+	Count  int64  `json:"count"`
+	Open   int64  `json:"open"`
+	Merged int64  `json:"merged"`
+}
+
+// --- Reviewer history DTO ---
+
+type ReviewerEventDTO struct {
+	Type       string `json:"type"`
+	FromUserID string `json:"from_user_id,omitempty"`
+	ToUserID   string `json:"to_user_id,omitempty"`
+	Reason     string `json:"reason"`
+	Actor      string `json:"actor"`
+	CreatedAt  string `json:"created_at"`
+}
+
+type ReassignmentStatDTO struct {
+	UserID string `json:"user_id"`
+	Count  int64  `json:"count"`
+}
+
+// --- Audit DTO ---
+
+type AuditEventDTO struct {
+	ID         int64           `json:"id"`
+	Actor      string          `json:"actor"`
+	Action     string          `json:"action"`
+	EntityType string          `json:"entity_type"`
+	EntityID   string          `json:"entity_id"`
+	Before     json.RawMessage `json:"before"`
+	After      json.RawMessage `json:"after"`
+	RequestID  string          `json:"request_id,omitempty"`
+	CreatedAt  string          `json:"created_at"`
+}
+
+// --- Webhooks DTO ---
+
+type WebhookDTO struct {
+	ID         int64    `json:"webhook_id"`
+	URL        string   `json:"url"`
+	EventTypes []string `json:"event_types"`
+	IsActive   bool     `json:"is_active"`
+	CreatedAt  string   `json:"created_at"`
+	UpdatedAt  string   `json:"updated_at"`
+}
+
+type WebhookDeliveryDTO struct {
+	ID             int64   `json:"delivery_id"`
+	WebhookID      int64   `json:"webhook_id"`
+	EventID        int64   `json:"event_id"`
+	EventType      string  `json:"event_type"`
+	Status         string  `json:"status"`
+	Attempts       int     `json:"attempts"`
+	ResponseStatus *int    `json:"response_status,omitempty"`
+	LastError      string  `json:"last_error,omitempty"`
+	RedeliveryOf   *int64  `json:"redelivery_of,omitempty"`
+	NextAttemptAt  *string `json:"next_attempt_at,omitempty"`
+	CreatedAt      string  `json:"created_at"`
+	DeliveredAt    *string `json:"delivered_at,omitempty"`
+}
+
+type CreateWebhookRequest struct {
+	URL        string   `json:"url"`
+	Secret     string   `json:"secret,omitempty"`
+	EventTypes []string `json:"event_types,omitempty"`
+}
+
+// UpdateWebhookRequest changes only the fields that are present.
+type UpdateWebhookRequest struct {
+	ID         int64    `json:"webhook_id"`
+	URL        *string  `json:"url,omitempty"`
+	Secret     *string  `json:"secret,omitempty"`
+	EventTypes []string `json:"event_types,omitempty"`
+	IsActive   *bool    `json:"is_active,omitempty"`
+}
+
+type DeleteWebhookRequest struct {
+	ID int64 `json:"webhook_id"`
+}
+
+type RedeliverRequest struct {
+	DeliveryID int64 `json:"delivery_id"`
+}
+
+// --- Integrations DTO ---
+
+type ExternalAccountDTO struct {
+	Provider  string `json:"provider"`
+	Login     string `json:"login"`
+	UserID    string `json:"user_id"`
+	CreatedAt string `json:"created_at"`
+}
+
+type SetExternalAccountRequest struct {
+	Provider string `json:"provider"`
+	Login    string `json:"login"`
+	UserID   string `json:"user_id"`
+}
+
+type DeleteExternalAccountRequest struct {
+	Provider string `json:"provider"`
+	Login    string `json:"login"`
+}
+
+type IntegrationResultDTO struct {
+	Result        string `json:"result"`
+	PullRequestID string `json:"pull_request_id,omitempty"`
+	Reason        string `json:"reason,omitempty"`
+}
+
+// --- Events DTO ---
+
+type ReviewNotificationDTO struct {
+	Type          string `json:"type"`
+	UserID        string `json:"user_id"`
+	PullRequestID string `json:"pull_request_id"`
+	Name          string `json:"pull_request_name"`
+	AuthorID      string `json:"author_id"`
+	Reason        string `json:"reason,omitempty"`
+	At            string `json:"at"`
+}
+
+// --- Stats DTO ---
+
+type CycleTimeDTO struct {
+	TeamName   string  `json:"team_name,omitempty"`
+	AuthorID   string  `json:"author_id,omitempty"`
+	Count      int     `json:"count"`
+	P50Seconds float64 `json:"p50_seconds"`
+	P90Seconds float64 `json:"p90_seconds"`
+	P99Seconds float64 `json:"p99_seconds"`
+}
+
+type MemberFairnessDTO struct {
+	UserID        string  `json:"user_id"`
+	Username      string  `json:"username"`
+	ActiveDays    float64 `json:"active_days"`
+	Assignments   int64   `json:"assignments"`
+	Share         float64 `json:"share"`
+	ExpectedShare float64 `json:"expected_share"`
+	ZScore        float64 `json:"z_score"`
+	Outlier       bool    `json:"outlier"`
 }
@@ -0,0 +1,157 @@
+package http
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/terps489/avito_tech_internship/internal/app"
+	"github.com/terps489/avito_tech_internship/internal/domain"
+	"github.com/terps489/avito_tech_internship/internal/pubsub"
+)
+
+const (
+	// sseRetry is the reconnect delay suggested to clients, in milliseconds.
+	sseRetry = 3000
+	// sseQueue is how many messages a stream may lag behind before the
+	// hub drops it.
+	sseQueue = 64
+)
+
+// handleEventStream streams review notifications for one user as
+// server-sent events. A client resuming with Last-Event-ID gets the
+// messages it missed, or a "reset" event if they are no longer buffered.
+func (s *Server) handleEventStream(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeMethodNotAllowed(w)
+		return
+	}
+
+	if s.hub == nil {
+		writeJSON(w, http.StatusNotFound, ErrorResponse{
+			Error: ErrorPayload{
+				Code:    ErrorCodeNotFound,
+				Message: "event stream is not configured",
+			},
+		})
+		return
+	}
+
+	userID := r.URL.Query().Get("user_id")
+	if userID == "" {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: ErrorPayload{
+				Code:    ErrorCodeBadRequest,
+				Message: "user_id query param is required",
+			},
+		})
+		return
+	}
+
+	if _, err := s.service.GetNotificationRecipient(r.Context(), domain.UserID(userID)); err != nil {
+		if errors.Is(err, app.ErrForbidden) {
+			writeForbidden(w)
+			return
+		}
+		if errors.Is(err, sql.ErrNoRows) {
+			writeJSON(w, http.StatusNotFound, ErrorResponse{
+				Error: ErrorPayload{
+					Code:    ErrorCodeNotFound,
+					Message: "user not found",
+				},
+			})
+			return
+		}
+
+		writeInternalError(w, err)
+		return
+	}
+
+	lastEventID := r.Header.Get("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = r.URL.Query().Get("last_event_id")
+	}
+
+	sub := s.hub.Subscribe(domain.UserID(userID), lastEventID, sseQueue)
+	defer sub.Close()
+
+	rc := http.NewResponseController(w)
+	// Streams outlive the server's write timeout.
+	_ = rc.SetWriteDeadline(time.Time{})
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	if _, err := fmt.Fprintf(w, "retry: %d\n\n", sseRetry); err != nil {
+		return
+	}
+	if sub.Reset {
+		if _, err := io.WriteString(w, "event: reset\ndata: {}\n\n"); err != nil {
+			return
+		}
+	}
+	for _, msg := range sub.Replay {
+		if err := writeSSEMessage(w, msg); err != nil {
+			return
+		}
+	}
+	if err := rc.Flush(); err != nil {
+		return
+	}
+
+	heartbeat := time.NewTicker(s.heartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-s.stopping:
+			// The client reconnects to another instance with Last-Event-ID.
+			return
+		case msg, ok := <-sub.C:
+			if !ok {
+				// Dropped for lagging behind; the client reconnects
+				// with Last-Event-ID and catches up from the buffer.
+				return
+			}
+			if err := writeSSEMessage(w, msg); err != nil {
+				return
+			}
+		case <-heartbeat.C:
+			if _, err := io.WriteString(w, ": heartbeat\n\n"); err != nil {
+				return
+			}
+		}
+
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+func writeSSEMessage(w io.Writer, msg pubsub.Message) error {
+	n := msg.Notification
+	data, err := json.Marshal(ReviewNotificationDTO{
+		Type:          string(n.Type),
+		UserID:        string(n.UserID),
+		PullRequestID: string(n.PullRequestID),
+		Name:          n.Title,
+		AuthorID:      string(n.AuthorID),
+		Reason:        n.Reason,
+		At:            n.At.UTC().Format(time.RFC3339Nano),
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", msg.ID, n.Type, data)
+	return err
+}
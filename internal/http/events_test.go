@@ -0,0 +1,220 @@
+package http_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/terps489/avito_tech_internship/internal/app"
+	"github.com/terps489/avito_tech_internship/internal/domain"
+	httpTransport "github.com/terps489/avito_tech_internship/internal/http"
+	"github.com/terps489/avito_tech_internship/internal/pubsub"
+	"github.com/terps489/avito_tech_internship/internal/repository/memory"
+)
+
+type sseEvent struct {
+	id    string
+	event string
+	data  string
+}
+
+func newEventServer(t *testing.T) (*httptest.Server, *app.Service) {
+	t.Helper()
+
+	hub := pubsub.NewHub(16)
+	store := memory.NewStore()
+	svc := app.NewService(app.Repositories{
+		Users:            memory.NewUserRepository(store),
+		Teams:            memory.NewTeamRepository(store),
+		PullRequests:     memory.NewPullRequestRepository(store),
+		Audit:            memory.NewAuditRepository(store),
+		ReviewerEvents:   memory.NewReviewerEventRepository(store),
+		Outbox:           memory.NewOutboxRepository(store),
+		Webhooks:         memory.NewWebhookRepository(store),
+		ExternalAccounts: memory.NewExternalAccountRepository(store),
+		Tx:               store,
+	}, app.WithPublisher(hub))
+
+	_, _, err := svc.CreateTeamWithMembers(t.Context(), "backend", []domain.User{
+		{ID: "u1", Username: "alice", IsActive: true},
+		{ID: "u2", Username: "bob", IsActive: true},
+		{ID: "u3", Username: "carol", IsActive: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srv := httpTransport.NewServer(":0", svc,
+		httpTransport.WithEventHub(hub),
+		httpTransport.WithHeartbeat(50*time.Millisecond),
+	)
+	ts := httptest.NewServer(srv.Handler())
+	t.Cleanup(ts.Close)
+	return ts, svc
+}
+
+// openStream connects to the stream and returns a channel of parsed events.
+// Comments are reported with event ":".
+func openStream(t *testing.T, ts *httptest.Server, userID, lastEventID string) <-chan sseEvent {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, ts.URL+"/events/stream?user_id="+userID, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, want 200", resp.StatusCode)
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("Content-Type = %q", ct)
+	}
+
+	events := make(chan sseEvent, 16)
+	go func() {
+		defer close(events)
+		defer func() {
+			_ = resp.Body.Close()
+		}()
+
+		sc := bufio.NewScanner(resp.Body)
+		var cur sseEvent
+		for sc.Scan() {
+			line := sc.Text()
+			switch {
+			case line == "":
+				if cur.event != "" {
+					events <- cur
+				}
+				cur = sseEvent{}
+			case strings.HasPrefix(line, ":"):
+				events <- sseEvent{event: ":"}
+			case strings.HasPrefix(line, "id: "):
+				cur.id = strings.TrimPrefix(line, "id: ")
+			case strings.HasPrefix(line, "event: "):
+				cur.event = strings.TrimPrefix(line, "event: ")
+			case strings.HasPrefix(line, "data: "):
+				cur.data = strings.TrimPrefix(line, "data: ")
+			}
+		}
+	}()
+	return events
+}
+
+func nextEvent(t *testing.T, events <-chan sseEvent) sseEvent {
+	t.Helper()
+	for {
+		select {
+		case e, ok := <-events:
+			if !ok {
+				t.Fatal("stream closed")
+			}
+			if e.event == ":" {
+				continue
+			}
+			return e
+		case <-time.After(2 * time.Second):
+			t.Fatal("timed out waiting for event")
+		}
+	}
+}
+
+func TestEventStreamDeliversAndResumes(t *testing.T) {
+	ts, svc := newEventServer(t)
+	ctx := t.Context()
+
+	events := openStream(t, ts, "u2", "")
+
+	// The author's teammates u2 and u3 are both assigned.
+	if _, err := svc.CreatePullRequestWithID(ctx, "pr-1", "Add feature", "u1"); err != nil {
+		t.Fatal(err)
+	}
+
+	assigned := nextEvent(t, events)
+	if assigned.event != string(domain.ReviewAssigned) || !strings.Contains(assigned.data, `"pull_request_id":"pr-1"`) {
+		t.Fatalf("unexpected event %+v", assigned)
+	}
+
+	if _, err := svc.MergePullRequest(ctx, "pr-1"); err != nil {
+		t.Fatal(err)
+	}
+	merged := nextEvent(t, events)
+	if merged.event != string(domain.ReviewPRMerged) {
+		t.Fatalf("unexpected event %+v", merged)
+	}
+
+	// Resuming after the first event replays only what came later.
+	resumed := openStream(t, ts, "u2", assigned.id)
+	replayed := nextEvent(t, resumed)
+	if replayed.id != merged.id || replayed.event != string(domain.ReviewPRMerged) {
+		t.Fatalf("replayed %+v, want %+v", replayed, merged)
+	}
+}
+
+func TestEventStreamResetAndHeartbeat(t *testing.T) {
+	ts, _ := newEventServer(t)
+
+	events := openStream(t, ts, "u2", "1-1")
+	if e := nextEvent(t, events); e.event != "reset" {
+		t.Fatalf("first event = %+v, want reset", e)
+	}
+
+	select {
+	case e := <-events:
+		if e.event != ":" {
+			t.Fatalf("got %+v, want heartbeat", e)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("no heartbeat")
+	}
+}
+
+func TestEventStreamUnknownUser(t *testing.T) {
+	ts, _ := newEventServer(t)
+
+	resp, err := http.Get(ts.URL + "/events/stream?user_id=nobody")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("status = %d, want 404", resp.StatusCode)
+	}
+}
+
+func TestEventStreamWithoutUser(t *testing.T) {
+	ts, _ := newEventServer(t)
+
+	resp, err := http.Get(ts.URL + "/events/stream")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	var body struct {
+		Error struct {
+			Code string `json:"code"`
+		} `json:"error"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusBadRequest || body.Error.Code != "BAD_REQUEST" {
+		t.Errorf("missing user_id: %d %s, want 400 BAD_REQUEST", resp.StatusCode, body.Error.Code)
+	}
+}
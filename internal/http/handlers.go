@@ -36,7 +36,7 @@ func (s *Server) handleTeamAdd(w http.ResponseWriter, r *http.Request) {
 	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
 		writeJSON(w, http.StatusBadRequest, ErrorResponse{
 			Error: ErrorPayload{
-				Code:    ErrorCodeNotFound,
+				Code:    ErrorCodeBadRequest,
 				Message: "invalid json body",
 			},
 		})
@@ -46,7 +46,7 @@ func (s *Server) handleTeamAdd(w http.ResponseWriter, r *http.Request) {
 	if body.TeamName == "" {
 		writeJSON(w, http.StatusBadRequest, ErrorResponse{
 			Error: ErrorPayload{
-				Code:    ErrorCodeNotFound,
+				Code:    ErrorCodeBadRequest,
 				Message: "team_name is required",
 			},
 		})
@@ -63,8 +63,12 @@ func (s *Server) handleTeamAdd(w http.ResponseWriter, r *http.Request) {
 		})
 	}
 
-	team, membersFromDB, err := s.service.CreateTeamWithMembers(domain.TeamName(body.TeamName), members)
+	team, membersFromDB, err := s.service.CreateTeamWithMembers(r.Context(), domain.TeamName(body.TeamName), members)
 	if err != nil {
+		if errors.Is(err, app.ErrForbidden) {
+			writeForbidden(w)
+			return
+		}
 		if errors.Is(err, app.ErrTeamExists) {
 			writeJSON(w, http.StatusBadRequest, ErrorResponse{
 				Error: ErrorPayload{
@@ -75,12 +79,7 @@ func (s *Server) handleTeamAdd(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		writeJSON(w, http.StatusInternalServerError, ErrorResponse{
-			Error: ErrorPayload{
-				Code:    ErrorCodeNotFound,
-				Message: "internal error: " + err.Error(),
-			},
-		})
+		writeInternalError(w, err)
 		return
 	}
 
@@ -114,14 +113,14 @@ func (s *Server) handleTeamGet(w http.ResponseWriter, r *http.Request) {
 	if teamName == "" {
 		writeJSON(w, http.StatusBadRequest, ErrorResponse{
 			Error: ErrorPayload{
-				Code:    ErrorCodeNotFound,
+				Code:    ErrorCodeBadRequest,
 				Message: "team_name query param is required",
 			},
 		})
 		return
 	}
 
-	team, members, err := s.service.GetTeamWithMembers(domain.TeamName(teamName))
+	team, members, err := s.service.GetTeamWithMembers(r.Context(), domain.TeamName(teamName))
 	if err != nil {
 		if errors.Is(err, app.ErrTeamNotFound) {
 			writeJSON(w, http.StatusNotFound, ErrorResponse{
@@ -133,12 +132,7 @@ func (s *Server) handleTeamGet(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		writeJSON(w, http.StatusInternalServerError, ErrorResponse{
-			Error: ErrorPayload{
-				Code:    ErrorCodeNotFound,
-				Message: "internal error: " + err.Error(),
-			},
-		})
+		writeInternalError(w, err)
 		return
 	}
 
@@ -170,7 +164,7 @@ func (s *Server) handleUserSetIsActive(w http.ResponseWriter, r *http.Request) {
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		writeJSON(w, http.StatusBadRequest, ErrorResponse{
 			Error: ErrorPayload{
-				Code:    ErrorCodeNotFound,
+				Code:    ErrorCodeBadRequest,
 				Message: "invalid json body",
 			},
 		})
@@ -180,15 +174,19 @@ func (s *Server) handleUserSetIsActive(w http.ResponseWriter, r *http.Request) {
 	if req.UserID == "" {
 		writeJSON(w, http.StatusBadRequest, ErrorResponse{
 			Error: ErrorPayload{
-				Code:    ErrorCodeNotFound,
+				Code:    ErrorCodeBadRequest,
 				Message: "user_id is required",
 			},
 		})
 		return
 	}
 
-	u, err := s.service.SetUserIsActive(domain.UserID(req.UserID), req.IsActive)
+	u, err := s.service.SetUserIsActive(r.Context(), domain.UserID(req.UserID), req.IsActive)
 	if err != nil {
+		if errors.Is(err, app.ErrForbidden) {
+			writeForbidden(w)
+			return
+		}
 		if errors.Is(err, sql.ErrNoRows) {
 			writeJSON(w, http.StatusNotFound, ErrorResponse{
 				Error: ErrorPayload{
@@ -199,19 +197,101 @@ func (s *Server) handleUserSetIsActive(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		writeJSON(w, http.StatusInternalServerError, ErrorResponse{
+		writeInternalError(w, err)
+		return
+	}
+
+	resp := struct {
+		User UserDTO `json:"user"`
+	}{
+		User: toUserDTO(u),
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *Server) handleUserGet(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeMethodNotAllowed(w)
+		return
+	}
+
+	userID := r.URL.Query().Get("user_id")
+	if userID == "" {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
 			Error: ErrorPayload{
-				Code:    ErrorCodeNotFound,
-				Message: "internal error: " + err.Error(),
+				Code:    ErrorCodeBadRequest,
+				Message: "user_id query param is required",
 			},
 		})
 		return
 	}
 
+	u, openReviews, err := s.service.GetUserProfile(r.Context(), domain.UserID(userID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			writeJSON(w, http.StatusNotFound, ErrorResponse{
+				Error: ErrorPayload{
+					Code:    ErrorCodeNotFound,
+					Message: "user not found",
+				},
+			})
+			return
+		}
+
+		writeInternalError(w, err)
+		return
+	}
+
 	resp := struct {
-		User UserDTO `json:"user"`
+		User UserProfileDTO `json:"user"`
 	}{
-		User: toUserDTO(u),
+		User: UserProfileDTO{
+			UserDTO:     toUserDTO(u),
+			OpenReviews: openReviews,
+		},
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *Server) handleUserList(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeMethodNotAllowed(w)
+		return
+	}
+
+	filter, err := parseUserFilter(r.URL.Query())
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: ErrorPayload{
+				Code:    ErrorCodeBadRequest,
+				Message: err.Error(),
+			},
+		})
+		return
+	}
+
+	users, err := s.service.ListUsers(r.Context(), filter)
+	if err != nil {
+		writeInternalError(w, err)
+		return
+	}
+
+	resp := struct {
+		Users      []UserDTO `json:"users"`
+		NextCursor *string   `json:"next_cursor,omitempty"`
+	}{
+		Users: make([]UserDTO, 0, len(users)),
+	}
+
+	for i := range users {
+		resp.Users = append(resp.Users, toUserDTO(&users[i]))
+	}
+
+	if len(users) > 0 && len(users) == filter.Limit {
+		next := encodeUserCursor(users[len(users)-1])
+		resp.NextCursor = &next
 	}
 
 	writeJSON(w, http.StatusOK, resp)
@@ -227,39 +307,43 @@ func (s *Server) handleUserGetReview(w http.ResponseWriter, r *http.Request) {
 	if userID == "" {
 		writeJSON(w, http.StatusBadRequest, ErrorResponse{
 			Error: ErrorPayload{
-				Code:    ErrorCodeNotFound,
+				Code:    ErrorCodeBadRequest,
 				Message: "user_id query param is required",
 			},
 		})
 		return
 	}
 
-	prs, err := s.service.ListPullRequestsForReviewer(domain.UserID(userID))
+	filter, err := parsePullRequestFilter(r.URL.Query(), domain.PRStatusOpen)
 	if err != nil {
-		writeJSON(w, http.StatusInternalServerError, ErrorResponse{
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
 			Error: ErrorPayload{
-				Code:    ErrorCodeNotFound,
-				Message: "internal error: " + err.Error(),
+				Code:    ErrorCodeBadRequest,
+				Message: err.Error(),
 			},
 		})
 		return
 	}
+	filter.ReviewerID = domain.UserID(userID)
+
+	prs, err := s.service.ListPullRequests(r.Context(), filter)
+	if err != nil {
+		writeInternalError(w, err)
+		return
+	}
 
 	resp := struct {
 		UserID       string                `json:"user_id"`
 		PullRequests []PullRequestShortDTO `json:"pull_requests"`
+		NextCursor   *string               `json:"next_cursor,omitempty"`
 	}{
 		UserID:       userID,
 		PullRequests: make([]PullRequestShortDTO, 0, len(prs)),
+		NextCursor:   nextPullRequestCursor(prs, filter.Limit),
 	}
 
-	for _, pr := range prs {
-		resp.PullRequests = append(resp.PullRequests, PullRequestShortDTO{
-			ID:       string(pr.ID),
-			Name:     pr.Title,
-			AuthorID: string(pr.AuthorID),
-			Status:   string(pr.Status),
-		})
+	for i := range prs {
+		resp.PullRequests = append(resp.PullRequests, toPullRequestShortDTO(&prs[i]))
 	}
 
 	writeJSON(w, http.StatusOK, resp)
@@ -277,7 +361,7 @@ func (s *Server) handlePullRequestCreate(w http.ResponseWriter, r *http.Request)
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		writeJSON(w, http.StatusBadRequest, ErrorResponse{
 			Error: ErrorPayload{
-				Code:    ErrorCodeNotFound,
+				Code:    ErrorCodeBadRequest,
 				Message: "invalid json body",
 			},
 		})
@@ -287,7 +371,7 @@ func (s *Server) handlePullRequestCreate(w http.ResponseWriter, r *http.Request)
 	if req.ID == "" || req.Name == "" || req.Author == "" {
 		writeJSON(w, http.StatusBadRequest, ErrorResponse{
 			Error: ErrorPayload{
-				Code:    ErrorCodeNotFound,
+				Code:    ErrorCodeBadRequest,
 				Message: "pull_request_id, pull_request_name and author_id are required",
 			},
 		})
@@ -295,6 +379,7 @@ func (s *Server) handlePullRequestCreate(w http.ResponseWriter, r *http.Request)
 	}
 
 	pr, err := s.service.CreatePullRequestWithID(
+		r.Context(),
 		domain.PullRequestID(req.ID),
 		req.Name,
 		domain.UserID(req.Author),
@@ -330,12 +415,7 @@ func (s *Server) handlePullRequestCreate(w http.ResponseWriter, r *http.Request)
 			return
 		}
 
-		writeJSON(w, http.StatusInternalServerError, ErrorResponse{
-			Error: ErrorPayload{
-				Code:    ErrorCodeNotFound,
-				Message: "internal error: " + err.Error(),
-			},
-		})
+		writeInternalError(w, err)
 		return
 	}
 
@@ -358,7 +438,7 @@ func (s *Server) handlePullRequestMerge(w http.ResponseWriter, r *http.Request)
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		writeJSON(w, http.StatusBadRequest, ErrorResponse{
 			Error: ErrorPayload{
-				Code:    ErrorCodeNotFound,
+				Code:    ErrorCodeBadRequest,
 				Message: "invalid json body",
 			},
 		})
@@ -368,15 +448,19 @@ func (s *Server) handlePullRequestMerge(w http.ResponseWriter, r *http.Request)
 	if req.ID == "" {
 		writeJSON(w, http.StatusBadRequest, ErrorResponse{
 			Error: ErrorPayload{
-				Code:    ErrorCodeNotFound,
+				Code:    ErrorCodeBadRequest,
 				Message: "pull_request_id is required",
 			},
 		})
 		return
 	}
 
-	pr, err := s.service.MergePullRequest(domain.PullRequestID(req.ID))
+	pr, err := s.service.MergePullRequest(r.Context(), domain.PullRequestID(req.ID))
 	if err != nil {
+		if errors.Is(err, app.ErrForbidden) {
+			writeForbidden(w)
+			return
+		}
 
 		if errors.Is(err, sql.ErrNoRows) {
 			writeJSON(w, http.StatusNotFound, ErrorResponse{
@@ -388,12 +472,7 @@ func (s *Server) handlePullRequestMerge(w http.ResponseWriter, r *http.Request)
 			return
 		}
 
-		writeJSON(w, http.StatusInternalServerError, ErrorResponse{
-			Error: ErrorPayload{
-				Code:    ErrorCodeNotFound,
-				Message: "internal error: " + err.Error(),
-			},
-		})
+		writeInternalError(w, err)
 		return
 	}
 
@@ -416,7 +495,7 @@ func (s *Server) handlePullRequestReassign(w http.ResponseWriter, r *http.Reques
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		writeJSON(w, http.StatusBadRequest, ErrorResponse{
 			Error: ErrorPayload{
-				Code:    ErrorCodeNotFound,
+				Code:    ErrorCodeBadRequest,
 				Message: "invalid json body",
 			},
 		})
@@ -426,7 +505,7 @@ func (s *Server) handlePullRequestReassign(w http.ResponseWriter, r *http.Reques
 	if req.PRID == "" || req.OldUserID == "" {
 		writeJSON(w, http.StatusBadRequest, ErrorResponse{
 			Error: ErrorPayload{
-				Code:    ErrorCodeNotFound,
+				Code:    ErrorCodeBadRequest,
 				Message: "pull_request_id and old_user_id are required",
 			},
 		})
@@ -434,10 +513,17 @@ func (s *Server) handlePullRequestReassign(w http.ResponseWriter, r *http.Reques
 	}
 
 	pr, replacedBy, err := s.service.ReassignReviewer(
+		r.Context(),
 		domain.PullRequestID(req.PRID),
 		domain.UserID(req.OldUserID),
+		req.Reason,
 	)
 	if err != nil {
+		if errors.Is(err, app.ErrForbidden) {
+			writeForbidden(w)
+			return
+		}
+
 		if errors.Is(err, sql.ErrNoRows) {
 			writeJSON(w, http.StatusNotFound, ErrorResponse{
 				Error: ErrorPayload{
@@ -478,12 +564,7 @@ func (s *Server) handlePullRequestReassign(w http.ResponseWriter, r *http.Reques
 			return
 		}
 
-		writeJSON(w, http.StatusInternalServerError, ErrorResponse{
-			Error: ErrorPayload{
-				Code:    ErrorCodeNotFound,
-				Message: "internal error: " + err.Error(),
-			},
-		})
+		writeInternalError(w, err)
 		return
 	}
 
@@ -498,6 +579,146 @@ func (s *Server) handlePullRequestReassign(w http.ResponseWriter, r *http.Reques
 	writeJSON(w, http.StatusOK, resp)
 }
 
+func (s *Server) handlePullRequestHistory(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeMethodNotAllowed(w)
+		return
+	}
+
+	prID := r.URL.Query().Get("pull_request_id")
+	if prID == "" {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: ErrorPayload{
+				Code:    ErrorCodeBadRequest,
+				Message: "pull_request_id query param is required",
+			},
+		})
+		return
+	}
+
+	events, err := s.service.GetPullRequestHistory(r.Context(), domain.PullRequestID(prID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			writeJSON(w, http.StatusNotFound, ErrorResponse{
+				Error: ErrorPayload{
+					Code:    ErrorCodeNotFound,
+					Message: "pull request not found",
+				},
+			})
+			return
+		}
+
+		writeInternalError(w, err)
+		return
+	}
+
+	resp := struct {
+		PullRequestID string             `json:"pull_request_id"`
+		Events        []ReviewerEventDTO `json:"events"`
+	}{
+		PullRequestID: prID,
+		Events:        make([]ReviewerEventDTO, 0, len(events)),
+	}
+
+	for _, e := range events {
+		resp.Events = append(resp.Events, ReviewerEventDTO{
+			Type:       string(e.Type),
+			FromUserID: string(e.FromUserID),
+			ToUserID:   string(e.ToUserID),
+			Reason:     e.Reason,
+			Actor:      e.Actor,
+			CreatedAt:  e.CreatedAt.UTC().Format(time.RFC3339),
+		})
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *Server) handlePullRequestGet(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeMethodNotAllowed(w)
+		return
+	}
+
+	prID := r.URL.Query().Get("pull_request_id")
+	if prID == "" {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: ErrorPayload{
+				Code:    ErrorCodeBadRequest,
+				Message: "pull_request_id query param is required",
+			},
+		})
+		return
+	}
+
+	pr, err := s.service.GetPullRequest(r.Context(), domain.PullRequestID(prID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			writeJSON(w, http.StatusNotFound, ErrorResponse{
+				Error: ErrorPayload{
+					Code:    ErrorCodeNotFound,
+					Message: "pull request not found",
+				},
+			})
+			return
+		}
+
+		writeInternalError(w, err)
+		return
+	}
+
+	resp := struct {
+		PR PullRequestDTO `json:"pr"`
+	}{
+		PR: toPullRequestDTO(pr),
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *Server) handlePullRequestList(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeMethodNotAllowed(w)
+		return
+	}
+
+	q := r.URL.Query()
+	filter, err := parsePullRequestFilter(q, "")
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: ErrorPayload{
+				Code:    ErrorCodeBadRequest,
+				Message: err.Error(),
+			},
+		})
+		return
+	}
+	filter.AuthorID = domain.UserID(q.Get("author_id"))
+	filter.ReviewerID = domain.UserID(q.Get("reviewer_id"))
+	filter.TeamName = domain.TeamName(q.Get("team_name"))
+	filter.TitleContains = q.Get("title")
+
+	prs, err := s.service.ListPullRequests(r.Context(), filter)
+	if err != nil {
+		writeInternalError(w, err)
+		return
+	}
+
+	resp := struct {
+		PullRequests []PullRequestDTO `json:"pull_requests"`
+		NextCursor   *string          `json:"next_cursor,omitempty"`
+	}{
+		PullRequests: make([]PullRequestDTO, 0, len(prs)),
+		NextCursor:   nextPullRequestCursor(prs, filter.Limit),
+	}
+
+	for i := range prs {
+		resp.PullRequests = append(resp.PullRequests, toPullRequestDTO(&prs[i]))
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
 func toPullRequestDTO(pr *domain.PullRequest) PullRequestDTO {
 	dto := PullRequestDTO{
 		ID:                string(pr.ID),
@@ -524,6 +745,27 @@ func toPullRequestDTO(pr *domain.PullRequest) PullRequestDTO {
 	return dto
 }
 
+func toPullRequestShortDTO(pr *domain.PullRequest) PullRequestShortDTO {
+	dto := PullRequestShortDTO{
+		ID:       string(pr.ID),
+		Name:     pr.Title,
+		AuthorID: string(pr.AuthorID),
+		Status:   string(pr.Status),
+	}
+
+	if !pr.CreatedAt.IsZero() {
+		s := pr.CreatedAt.UTC().Format(time.RFC3339)
+		dto.CreatedAt = &s
+	}
+
+	if pr.MergedAt != nil {
+		s := pr.MergedAt.UTC().Format(time.RFC3339)
+		dto.MergedAt = &s
+	}
+
+	return dto
+}
+
 func toUserDTO(u *domain.User) UserDTO {
 	return UserDTO{
 		UserID:   string(u.ID),
@@ -539,25 +781,97 @@ func (s *Server) handleStatsAssignments(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	stats, err := s.service.GetReviewerAssignmentStats()
-	if err != nil {
-		writeJSON(w, http.StatusInternalServerError, ErrorResponse{
+	q := r.URL.Query()
+	filter := domain.AssignmentStatsFilter{
+		TeamName: domain.TeamName(q.Get("team_name")),
+		GroupBy:  domain.GroupByUser,
+	}
+
+	if v := q.Get("group_by"); v != "" {
+		filter.GroupBy = domain.AssignmentGroupBy(v)
+		if !domain.IsAssignmentGroupBy(filter.GroupBy) {
+			writeJSON(w, http.StatusBadRequest, ErrorResponse{
+				Error: ErrorPayload{
+					Code:    ErrorCodeBadRequest,
+					Message: "group_by must be user, team, day or week",
+				},
+			})
+			return
+		}
+	}
+
+	var err error
+	if filter.From, filter.To, err = parseWindow(q); err != nil {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
 			Error: ErrorPayload{
-				Code:    ErrorCodeNotFound,
-				Message: "internal error: " + err.Error(),
+				Code:    ErrorCodeBadRequest,
+				Message: err.Error(),
 			},
 		})
 		return
 	}
 
+	stats, err := s.service.GetReviewerAssignmentStats(r.Context(), filter)
+	if err != nil {
+		writeInternalError(w, err)
+		return
+	}
+
+	resp := struct {
+		GroupBy             string                   `json:"group_by"`
+		Groups              []AssignmentGroupDTO     `json:"groups"`
+		ReviewerAssignments *[]ReviewerAssignmentDTO `json:"reviewer_assignments,omitempty"`
+	}{
+		GroupBy: string(filter.GroupBy),
+		Groups:  make([]AssignmentGroupDTO, 0, len(stats)),
+	}
+
+	for _, st := range stats {
+		resp.Groups = append(resp.Groups, AssignmentGroupDTO{
+			Key:    st.Key,
+			Count:  st.Count,
+			Open:   st.Open,
+			Merged: st.Merged,
+		})
+	}
+
+	// reviewer_assignments predates grouping and is kept for existing clients.
+	if filter.GroupBy == domain.GroupByUser {
+		byUser := make([]ReviewerAssignmentDTO, 0, len(stats))
+		for _, st := range stats {
+			byUser = append(byUser, ReviewerAssignmentDTO{
+				UserID: st.Key,
+				Count:  st.Count,
+				Open:   st.Open,
+				Merged: st.Merged,
+			})
+		}
+		resp.ReviewerAssignments = &byUser
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *Server) handleStatsReassignments(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeMethodNotAllowed(w)
+		return
+	}
+
+	stats, err := s.service.GetReassignmentStats(r.Context())
+	if err != nil {
+		writeInternalError(w, err)
+		return
+	}
+
 	resp := struct {
-		ReviewerAssignments []ReviewerAssignmentDTO `json:"reviewer_assignments"`
+		Reassignments []ReassignmentStatDTO `json:"reassignments"`
 	}{
-		ReviewerAssignments: make([]ReviewerAssignmentDTO, 0, len(stats)),
+		Reassignments: make([]ReassignmentStatDTO, 0, len(stats)),
 	}
 
 	for _, s := range stats {
-		resp.ReviewerAssignments = append(resp.ReviewerAssignments, ReviewerAssignmentDTO{
+		resp.Reassignments = append(resp.Reassignments, ReassignmentStatDTO{
 			UserID: string(s.UserID),
 			Count:  s.Count,
 		})
@@ -0,0 +1,207 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/terps489/avito_tech_internship/internal/app"
+)
+
+const (
+	// maxIdempotencyKeyLen fits a UUID or any sensible client key.
+	maxIdempotencyKeyLen = 255
+	// maxIdempotentBody bounds the request bodies read for hashing.
+	maxIdempotentBody = 1 << 20
+	// idempotencyLeaseMargin is added to the write timeout for how long a
+	// key stays reserved while its request runs, to cover settling the key
+	// after the deadline.
+	idempotencyLeaseMargin = 30 * time.Second
+)
+
+// WithIdempotency lets clients retry POST requests safely: the first
+// response to an Idempotency-Key is stored for ttl and replayed to later
+// requests with the same key and body. Requests with a key are bounded by
+// the write timeout of WithTimeouts, or the default one if that is off,
+// so that a key is not freed while its request still runs.
+func WithIdempotency(ttl time.Duration) Option {
+	return func(s *Server) {
+		s.idempotencyTTL = ttl
+	}
+}
+
+// nonIdempotentRoutes reject Idempotency-Key: their responses carry
+// secrets, which are not stored, so a replay could not be identical.
+var nonIdempotentRoutes = map[string]bool{
+	"/webhooks/create": true,
+}
+
+// withIdempotency runs inside withAuth, so that keys of different clients
+// do not collide; without auth clients are told apart by IP address.
+// Responses with a 5xx status are not stored, so that the request can be
+// retried with the same key.
+func (s *Server) withIdempotency(next http.Handler) http.Handler {
+	if s.idempotencyTTL <= 0 {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get("Idempotency-Key")
+		if r.Method != http.MethodPost || key == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if !validIdempotencyKey(key) {
+			writeJSON(w, http.StatusBadRequest, ErrorResponse{
+				Error: ErrorPayload{
+					Code:    ErrorCodeBadRequest,
+					Message: "Idempotency-Key must be 1 to 255 printable ASCII characters",
+				},
+			})
+			return
+		}
+		if nonIdempotentRoutes[s.route(r)] {
+			writeJSON(w, http.StatusBadRequest, ErrorResponse{
+				Error: ErrorPayload{
+					Code:    ErrorCodeBadRequest,
+					Message: "Idempotency-Key is not supported here: the response contains a secret that is not stored",
+				},
+			})
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxIdempotentBody))
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, ErrorResponse{
+				Error: ErrorPayload{
+					Code:    ErrorCodeBadRequest,
+					Message: "request body is too large or unreadable",
+				},
+			})
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		ctx := r.Context()
+		scope := clientID(r)
+		limit := s.timeouts.Write
+		if limit <= 0 {
+			limit = DefaultTimeouts().Write
+		}
+		stored, err := s.service.BeginIdempotentRequest(ctx, scope, key, requestHash(r, body), limit+idempotencyLeaseMargin)
+		switch {
+		case errors.Is(err, app.ErrIdempotencyKeyReused):
+			writeJSON(w, http.StatusUnprocessableEntity, ErrorResponse{
+				Error: ErrorPayload{
+					Code:    ErrorCodeIdempotencyKeyReused,
+					Message: "Idempotency-Key was already used for a different request",
+				},
+			})
+			return
+		case errors.Is(err, app.ErrIdempotencyKeyInProgress):
+			writeJSON(w, http.StatusConflict, ErrorResponse{
+				Error: ErrorPayload{
+					Code:    ErrorCodeIdempotencyKeyInProgress,
+					Message: "a request with this Idempotency-Key is still in progress",
+				},
+			})
+			return
+		case err != nil:
+			writeInternalError(w, err)
+			return
+		case stored != nil:
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("Idempotent-Replayed", "true")
+			w.WriteHeader(stored.Status)
+			_, _ = w.Write(stored.Body)
+			return
+		}
+
+		// The request must finish before its key is freed, or a retry
+		// would run it a second time.
+		runCtx, cancel := context.WithTimeout(ctx, limit)
+		defer cancel()
+		rec := &bodyRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r.WithContext(runCtx))
+
+		// The response is already sent; the key must be settled even if
+		// the client went away.
+		ctx = context.WithoutCancel(ctx)
+		if rec.status >= http.StatusInternalServerError {
+			err = s.service.ReleaseIdempotencyKey(ctx, scope, key)
+		} else {
+			err = s.service.CompleteIdempotentRequest(ctx, scope, key, rec.status, rec.body.Bytes(), s.idempotencyTTL)
+		}
+		if err != nil {
+			s.logger.ErrorContext(ctx, "failed to store idempotent response", "error", err.Error())
+		}
+	})
+}
+
+func validIdempotencyKey(key string) bool {
+	if len(key) > maxIdempotencyKeyLen {
+		return false
+	}
+	for i := 0; i < len(key); i++ {
+		if key[i] < 0x20 || key[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// requestHash covers the path as well as the body, so that a key reused
+// on another endpoint counts as a different request.
+func requestHash(r *http.Request, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(r.Method + " " + r.URL.RequestURI() + "\n"))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// bodyRecorder keeps a copy of the response for replays and passes
+// recorded errors on to the request log.
+type bodyRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func (r *bodyRecorder) WriteHeader(status int) {
+	if !r.wroteHeader {
+		r.status = status
+		r.wroteHeader = true
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *bodyRecorder) Write(b []byte) (int, error) {
+	r.wroteHeader = true
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
+
+func (r *bodyRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+func (r *bodyRecorder) recordErrorCode(code ErrorCode) {
+	if rec, ok := r.ResponseWriter.(errorRecorder); ok {
+		rec.recordErrorCode(code)
+	}
+}
+
+func (r *bodyRecorder) recordError(err error) {
+	if rec, ok := r.ResponseWriter.(errorRecorder); ok {
+		rec.recordError(err)
+	} else {
+		slog.Error("internal error", slog.Any("error", err))
+	}
+}
@@ -0,0 +1,306 @@
+package http_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/terps489/avito_tech_internship/internal/app"
+	"github.com/terps489/avito_tech_internship/internal/domain"
+	httpTransport "github.com/terps489/avito_tech_internship/internal/http"
+	"github.com/terps489/avito_tech_internship/internal/repository/memory"
+)
+
+func TestIdempotencyKey(t *testing.T) {
+	store := memory.NewStore()
+	svc := app.NewService(app.Repositories{
+		Users:            memory.NewUserRepository(store),
+		Teams:            memory.NewTeamRepository(store),
+		PullRequests:     memory.NewPullRequestRepository(store),
+		Audit:            memory.NewAuditRepository(store),
+		ReviewerEvents:   memory.NewReviewerEventRepository(store),
+		Outbox:           memory.NewOutboxRepository(store),
+		Webhooks:         memory.NewWebhookRepository(store),
+		ExternalAccounts: memory.NewExternalAccountRepository(store),
+		APITokens:        memory.NewAPITokenRepository(store),
+		IdempotencyKeys:  memory.NewIdempotencyRepository(store),
+		Tx:               store,
+	})
+	ts := httptest.NewServer(httpTransport.NewServer(":0", svc, httpTransport.WithIdempotency(time.Hour)).Handler())
+	t.Cleanup(ts.Close)
+
+	members := []domain.User{{ID: "u1", Username: "A", IsActive: true}}
+	for _, id := range []domain.UserID{"u2", "u3", "u4", "u5", "u6"} {
+		members = append(members, domain.User{ID: id, Username: string(id), IsActive: true})
+	}
+	if _, _, err := svc.CreateTeamWithMembers(t.Context(), "backend", members); err != nil {
+		t.Fatal(err)
+	}
+
+	post := func(path, key, body string) (*http.Response, string) {
+		t.Helper()
+		req, err := http.NewRequest(http.MethodPost, ts.URL+path, strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if key != "" {
+			req.Header.Set("Idempotency-Key", key)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer func() { _ = resp.Body.Close() }()
+		data, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp, string(data)
+	}
+
+	create := `{"pull_request_id":"pr-1","pull_request_name":"x","author_id":"u1"}`
+	first, firstBody := post("/pullRequest/create", "create-1", create)
+	if first.StatusCode != http.StatusCreated {
+		t.Fatalf("create: status = %d, want 201", first.StatusCode)
+	}
+	replay, replayBody := post("/pullRequest/create", "create-1", create)
+	if replay.StatusCode != http.StatusCreated || replayBody != firstBody || replay.Header.Get("Idempotent-Replayed") != "true" {
+		t.Errorf("replayed create: %d %q (replayed %q), want 201 %q",
+			replay.StatusCode, replayBody, replay.Header.Get("Idempotent-Replayed"), firstBody)
+	}
+	// Without the header the second create is a conflict, as before.
+	if resp, _ := post("/pullRequest/create", "", create); resp.StatusCode != http.StatusConflict {
+		t.Errorf("create without a key: status = %d, want 409", resp.StatusCode)
+	}
+
+	var created struct {
+		PR struct {
+			Reviewers []string `json:"assigned_reviewers"`
+		} `json:"pr"`
+	}
+	if err := json.Unmarshal([]byte(firstBody), &created); err != nil || len(created.PR.Reviewers) == 0 {
+		t.Fatalf("created PR %s has no reviewers (%v)", firstBody, err)
+	}
+
+	// A retried reassignment must not pick a second replacement.
+	reassign := `{"pull_request_id":"pr-1","old_user_id":"` + created.PR.Reviewers[0] + `"}`
+	first, firstBody = post("/pullRequest/reassign", "reassign-1", reassign)
+	if first.StatusCode != http.StatusOK {
+		t.Fatalf("reassign: status = %d %s, want 200", first.StatusCode, firstBody)
+	}
+	for range 3 {
+		if resp, body := post("/pullRequest/reassign", "reassign-1", reassign); resp.StatusCode != http.StatusOK || body != firstBody {
+			t.Errorf("retried reassign: %d %s, want 200 %s", resp.StatusCode, body, firstBody)
+		}
+	}
+	events, err := svc.ListAuditEvents(t.Context(), domain.AuditFilter{EntityType: domain.AuditEntityPullRequest, EntityID: "pr-1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 2 {
+		t.Errorf("audit has %d events for pr-1, want create and one reassign", len(events))
+	}
+
+	resp, body := post("/pullRequest/create", "create-1", `{"pull_request_id":"pr-2","pull_request_name":"y","author_id":"u1"}`)
+	if resp.StatusCode != http.StatusUnprocessableEntity || !strings.Contains(body, "IDEMPOTENCY_KEY_REUSED") {
+		t.Errorf("key reused with another body: %d %s, want 422 IDEMPOTENCY_KEY_REUSED", resp.StatusCode, body)
+	}
+	if resp, _ := post("/pullRequest/merge", "create-1", create); resp.StatusCode != http.StatusUnprocessableEntity {
+		t.Errorf("key reused on another route: status = %d, want 422", resp.StatusCode)
+	}
+
+	if resp, _ := post("/pullRequest/create", strings.Repeat("k", 256), create); resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("overlong key: status = %d, want 400", resp.StatusCode)
+	}
+}
+
+func TestIdempotencyKeyWithoutAuth(t *testing.T) {
+	store := memory.NewStore()
+	svc := app.NewService(app.Repositories{
+		Users:            memory.NewUserRepository(store),
+		Teams:            memory.NewTeamRepository(store),
+		PullRequests:     memory.NewPullRequestRepository(store),
+		Audit:            memory.NewAuditRepository(store),
+		ReviewerEvents:   memory.NewReviewerEventRepository(store),
+		Outbox:           memory.NewOutboxRepository(store),
+		Webhooks:         memory.NewWebhookRepository(store),
+		ExternalAccounts: memory.NewExternalAccountRepository(store),
+		IdempotencyKeys:  memory.NewIdempotencyRepository(store),
+		Tx:               store,
+	})
+	h := httpTransport.NewServer(":0", svc, httpTransport.WithIdempotency(time.Hour)).Handler()
+
+	members := []domain.User{{ID: "u1", Username: "A", IsActive: true}, {ID: "u2", Username: "B", IsActive: true}}
+	if _, _, err := svc.CreateTeamWithMembers(t.Context(), "backend", members); err != nil {
+		t.Fatal(err)
+	}
+
+	post := func(path, remoteAddr, body string) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		req.RemoteAddr = remoteAddr
+		req.Header.Set("Idempotency-Key", "key-1")
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	create := `{"pull_request_id":"pr-1","pull_request_name":"x","author_id":"u1"}`
+	first := post("/pullRequest/create", "10.0.0.1:1234", create)
+	if first.Code != http.StatusCreated {
+		t.Fatalf("create: %d %s, want 201", first.Code, first.Body.String())
+	}
+
+	// Another client with the same key and body runs the request itself.
+	other := post("/pullRequest/create", "10.0.0.2:1234", create)
+	if other.Code != http.StatusConflict || other.Header().Get("Idempotent-Replayed") != "" {
+		t.Errorf("create by another client: %d %s, want 409 PR_EXISTS", other.Code, other.Body.String())
+	}
+
+	replay := post("/pullRequest/create", "10.0.0.1:5678", create)
+	if replay.Code != http.StatusCreated || replay.Header().Get("Idempotent-Replayed") != "true" || replay.Body.String() != first.Body.String() {
+		t.Errorf("replayed create: %d %s (replayed %q), want the first response",
+			replay.Code, replay.Body.String(), replay.Header().Get("Idempotent-Replayed"))
+	}
+
+	// The webhook secret is not stored, so the route cannot replay.
+	if rec := post("/webhooks/create", "10.0.0.1:1234", `{"url":"https://example.com/hook"}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("webhook create with a key: %d %s, want 400", rec.Code, rec.Body.String())
+	}
+	hooks, err := svc.ListWebhooks(t.Context())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hooks) != 0 {
+		t.Errorf("webhooks = %+v, want none created", hooks)
+	}
+}
+
+func TestIdempotentRequestIsLogged(t *testing.T) {
+	store := memory.NewStore()
+	svc := app.NewService(app.Repositories{
+		Users:            brokenUsers{memory.NewUserRepository(store)},
+		Teams:            memory.NewTeamRepository(store),
+		PullRequests:     memory.NewPullRequestRepository(store),
+		Audit:            memory.NewAuditRepository(store),
+		ReviewerEvents:   memory.NewReviewerEventRepository(store),
+		Outbox:           memory.NewOutboxRepository(store),
+		Webhooks:         memory.NewWebhookRepository(store),
+		ExternalAccounts: memory.NewExternalAccountRepository(store),
+		IdempotencyKeys:  memory.NewIdempotencyRepository(store),
+		Tx:               store,
+	})
+	var logs bytes.Buffer
+	h := httpTransport.NewServer(":0", svc,
+		httpTransport.WithLogger(slog.New(slog.NewJSONHandler(&logs, nil))),
+		httpTransport.WithIdempotency(time.Hour),
+	).Handler()
+
+	for _, tc := range []struct {
+		path, body string
+		want       map[string]any
+	}{
+		{"/pullRequest/create", `{"pull_request_id":"pr-1"}`, map[string]any{
+			"status": float64(400), "error_code": "BAD_REQUEST",
+		}},
+		{"/users/setIsActive", `{"user_id":"u1","is_active":false}`, map[string]any{
+			"status": float64(500), "error_code": "INTERNAL", "error": "connection refused by 10.0.0.7",
+		}},
+	} {
+		logs.Reset()
+		req := httptest.NewRequest(http.MethodPost, tc.path, strings.NewReader(tc.body))
+		req.Header.Set("Idempotency-Key", "k-"+tc.path)
+		h.ServeHTTP(httptest.NewRecorder(), req)
+
+		var entry map[string]any
+		if err := json.Unmarshal(logs.Bytes(), &entry); err != nil {
+			t.Fatalf("%s: log line %q: %v", tc.path, logs.String(), err)
+		}
+		for k, v := range tc.want {
+			if entry[k] != v {
+				t.Errorf("%s: log %s = %v, want %v", tc.path, k, entry[k], v)
+			}
+		}
+	}
+}
+
+// blockedUsers holds GetByID until release is closed and reports the
+// deadline of the request it runs for.
+type blockedUsers struct {
+	app.UserRepository
+	entered chan time.Time
+	release chan struct{}
+}
+
+func (u blockedUsers) GetByID(ctx context.Context, id domain.UserID) (*domain.User, error) {
+	deadline, _ := ctx.Deadline()
+	u.entered <- deadline
+	<-u.release
+	return u.UserRepository.GetByID(ctx, id)
+}
+
+func TestIdempotencyKeyHeldForWriteTimeout(t *testing.T) {
+	store := memory.NewStore()
+	users := blockedUsers{memory.NewUserRepository(store), make(chan time.Time, 1), make(chan struct{})}
+	// The first request blocks inside a transaction, which locks store;
+	// keys live apart so that the retry can get to them.
+	keys := memory.NewIdempotencyRepository(memory.NewStore())
+	svc := app.NewService(app.Repositories{
+		Users:            users,
+		Teams:            memory.NewTeamRepository(store),
+		PullRequests:     memory.NewPullRequestRepository(store),
+		Audit:            memory.NewAuditRepository(store),
+		ReviewerEvents:   memory.NewReviewerEventRepository(store),
+		Outbox:           memory.NewOutboxRepository(store),
+		Webhooks:         memory.NewWebhookRepository(store),
+		ExternalAccounts: memory.NewExternalAccountRepository(store),
+		IdempotencyKeys:  keys,
+		Tx:               store,
+	})
+	timeouts := httpTransport.DefaultTimeouts()
+	timeouts.Write = 10 * time.Minute
+	h := httpTransport.NewServer(":0", svc,
+		httpTransport.WithTimeouts(timeouts),
+		httpTransport.WithIdempotency(time.Hour),
+	).Handler()
+
+	post := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/users/setIsActive", strings.NewReader(`{"user_id":"u1","is_active":false}`))
+		req.RemoteAddr = "10.0.0.1:1234"
+		req.Header.Set("Idempotency-Key", "slow-1")
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	start := time.Now()
+	done := make(chan *httptest.ResponseRecorder)
+	go func() { done <- post() }()
+	deadline := <-users.entered
+
+	// The first request is still running; its key must stay reserved for
+	// longer than the write timeout that bounds it.
+	if deadline.IsZero() || deadline.Before(start.Add(timeouts.Write)) || deadline.After(time.Now().Add(timeouts.Write)) {
+		t.Errorf("request deadline = %v, want the write timeout from now", deadline)
+	}
+	k, err := keys.Get(t.Context(), "ip:10.0.0.1", "slow-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !k.ExpiresAt.After(deadline) {
+		t.Errorf("pending key expires at %v, before the request deadline %v", k.ExpiresAt, deadline)
+	}
+	if rec := post(); rec.Code != http.StatusConflict || !strings.Contains(rec.Body.String(), "IDEMPOTENCY_KEY_IN_PROGRESS") {
+		t.Errorf("retry while in flight: %d %s, want 409 IDEMPOTENCY_KEY_IN_PROGRESS", rec.Code, rec.Body.String())
+	}
+
+	close(users.release)
+	<-done
+}
@@ -0,0 +1,333 @@
+package http
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/terps489/avito_tech_internship/internal/app"
+	"github.com/terps489/avito_tech_internship/internal/domain"
+	"github.com/terps489/avito_tech_internship/internal/integration"
+)
+
+// maxIntegrationBody bounds provider payloads; pull request events
+// are far below it.
+const maxIntegrationBody = 5 << 20
+
+// Results reported back to the provider.
+const (
+	integrationCreated = "created"
+	integrationExists  = "exists"
+	integrationMerged  = "merged"
+	integrationIgnored = "ignored"
+)
+
+func (s *Server) handleGitHubWebhook(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeMethodNotAllowed(w)
+		return
+	}
+
+	if s.githubSecret == "" {
+		writeJSON(w, http.StatusNotFound, ErrorResponse{
+			Error: ErrorPayload{
+				Code:    ErrorCodeNotFound,
+				Message: "github integration is not configured",
+			},
+		})
+		return
+	}
+
+	body, ok := readIntegrationBody(w, r)
+	if !ok {
+		return
+	}
+
+	if !integration.VerifyGitHubSignature(s.githubSecret, body, r.Header.Get(integration.GitHubSignatureHeader)) {
+		writeJSON(w, http.StatusUnauthorized, ErrorResponse{
+			Error: ErrorPayload{
+				Code:    ErrorCodeNotFound,
+				Message: "invalid signature",
+			},
+		})
+		return
+	}
+
+	e, err := integration.ParseGitHub(r.Header.Get(integration.GitHubEventHeader), body)
+	s.applyIntegrationEvent(w, r, e, err)
+}
+
+func (s *Server) handleGitLabWebhook(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeMethodNotAllowed(w)
+		return
+	}
+
+	if s.gitlabToken == "" {
+		writeJSON(w, http.StatusNotFound, ErrorResponse{
+			Error: ErrorPayload{
+				Code:    ErrorCodeNotFound,
+				Message: "gitlab integration is not configured",
+			},
+		})
+		return
+	}
+
+	if !integration.VerifyGitLabToken(s.gitlabToken, r.Header.Get(integration.GitLabTokenHeader)) {
+		writeJSON(w, http.StatusUnauthorized, ErrorResponse{
+			Error: ErrorPayload{
+				Code:    ErrorCodeNotFound,
+				Message: "invalid token",
+			},
+		})
+		return
+	}
+
+	body, ok := readIntegrationBody(w, r)
+	if !ok {
+		return
+	}
+
+	e, err := integration.ParseGitLab(r.Header.Get(integration.GitLabEventHeader), body)
+	s.applyIntegrationEvent(w, r, e, err)
+}
+
+func readIntegrationBody(w http.ResponseWriter, r *http.Request) ([]byte, bool) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxIntegrationBody))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: ErrorPayload{
+				Code:    ErrorCodeBadRequest,
+				Message: "failed to read body",
+			},
+		})
+		return nil, false
+	}
+	return body, true
+}
+
+// applyIntegrationEvent runs a parsed provider event. Redelivered events
+// succeed without changes, so providers can retry safely.
+func (s *Server) applyIntegrationEvent(w http.ResponseWriter, r *http.Request, e integration.PullRequestEvent, parseErr error) {
+	if parseErr != nil {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: ErrorPayload{
+				Code:    ErrorCodeBadRequest,
+				Message: parseErr.Error(),
+			},
+		})
+		return
+	}
+
+	resp := IntegrationResultDTO{
+		PullRequestID: string(e.PullRequestID),
+	}
+
+	ctx := r.Context()
+	if e.SenderLogin != "" {
+		ctx = app.WithActor(ctx, string(e.Provider)+":"+e.SenderLogin)
+	}
+
+	switch e.Kind {
+	case integration.KindIgnored:
+		resp.Result = integrationIgnored
+		resp.Reason = e.Reason
+
+	case integration.KindOpened:
+		authorID, err := s.service.ResolveExternalLogin(ctx, e.Provider, e.AuthorLogin)
+		if err != nil {
+			if errors.Is(err, app.ErrLoginNotMapped) {
+				writeJSON(w, http.StatusUnprocessableEntity, ErrorResponse{
+					Error: ErrorPayload{
+						Code:    ErrorCodeNotFound,
+						Message: err.Error(),
+					},
+				})
+				return
+			}
+
+			writeInternalError(w, err)
+			return
+		}
+
+		_, err = s.service.CreatePullRequestWithID(ctx, e.PullRequestID, e.Title, authorID)
+		switch {
+		case err == nil:
+			resp.Result = integrationCreated
+		case errors.Is(err, app.ErrPRExists):
+			resp.Result = integrationExists
+		case errors.Is(err, sql.ErrNoRows):
+			writeJSON(w, http.StatusUnprocessableEntity, ErrorResponse{
+				Error: ErrorPayload{
+					Code:    ErrorCodeNotFound,
+					Message: "mapped author not found",
+				},
+			})
+			return
+		case errors.Is(err, app.ErrAuthorNotActive):
+			writeJSON(w, http.StatusConflict, ErrorResponse{
+				Error: ErrorPayload{
+					Code:    ErrorCodeNoCandidate,
+					Message: "author is not active",
+				},
+			})
+			return
+		default:
+			writeInternalError(w, err)
+			return
+		}
+
+	case integration.KindMerged:
+		if _, err := s.service.MergePullRequest(ctx, e.PullRequestID); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				writeJSON(w, http.StatusNotFound, ErrorResponse{
+					Error: ErrorPayload{
+						Code:    ErrorCodeNotFound,
+						Message: "pull request not found",
+					},
+				})
+				return
+			}
+
+			writeInternalError(w, err)
+			return
+		}
+		resp.Result = integrationMerged
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// ---------- Login mapping ----------
+
+func (s *Server) handleExternalAccountSet(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeMethodNotAllowed(w)
+		return
+	}
+
+	var body SetExternalAccountRequest
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: ErrorPayload{
+				Code:    ErrorCodeBadRequest,
+				Message: "invalid json body",
+			},
+		})
+		return
+	}
+
+	if body.Provider == "" || body.Login == "" || body.UserID == "" {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: ErrorPayload{
+				Code:    ErrorCodeBadRequest,
+				Message: "provider, login and user_id are required",
+			},
+		})
+		return
+	}
+
+	account, err := s.service.SetExternalAccount(r.Context(),
+		domain.ExternalProvider(body.Provider), body.Login, domain.UserID(body.UserID))
+	if err != nil {
+		writeExternalAccountError(w, err, "user not found")
+		return
+	}
+
+	resp := struct {
+		Account ExternalAccountDTO `json:"account"`
+	}{
+		Account: toExternalAccountDTO(account),
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *Server) handleExternalAccountList(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeMethodNotAllowed(w)
+		return
+	}
+
+	provider := domain.ExternalProvider(r.URL.Query().Get("provider"))
+
+	accounts, err := s.service.ListExternalAccounts(r.Context(), provider)
+	if err != nil {
+		writeExternalAccountError(w, err, "")
+		return
+	}
+
+	resp := struct {
+		Accounts []ExternalAccountDTO `json:"accounts"`
+	}{
+		Accounts: make([]ExternalAccountDTO, 0, len(accounts)),
+	}
+
+	for i := range accounts {
+		resp.Accounts = append(resp.Accounts, toExternalAccountDTO(&accounts[i]))
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *Server) handleExternalAccountDelete(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeMethodNotAllowed(w)
+		return
+	}
+
+	var body DeleteExternalAccountRequest
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.Provider == "" || body.Login == "" {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: ErrorPayload{
+				Code:    ErrorCodeBadRequest,
+				Message: "provider and login are required",
+			},
+		})
+		return
+	}
+
+	if err := s.service.DeleteExternalAccount(r.Context(), domain.ExternalProvider(body.Provider), body.Login); err != nil {
+		writeExternalAccountError(w, err, "mapping not found")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"status": "deleted",
+	})
+}
+
+func writeExternalAccountError(w http.ResponseWriter, err error, notFound string) {
+	switch {
+	case errors.Is(err, app.ErrForbidden):
+		writeForbidden(w)
+	case errors.Is(err, app.ErrUnknownProvider):
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: ErrorPayload{
+				Code:    ErrorCodeBadRequest,
+				Message: err.Error(),
+			},
+		})
+	case notFound != "" && errors.Is(err, sql.ErrNoRows):
+		writeJSON(w, http.StatusNotFound, ErrorResponse{
+			Error: ErrorPayload{
+				Code:    ErrorCodeNotFound,
+				Message: notFound,
+			},
+		})
+	default:
+		writeInternalError(w, err)
+	}
+}
+
+func toExternalAccountDTO(a *domain.ExternalAccount) ExternalAccountDTO {
+	return ExternalAccountDTO{
+		Provider:  string(a.Provider),
+		Login:     a.Login,
+		UserID:    string(a.UserID),
+		CreatedAt: a.CreatedAt.UTC().Format(time.RFC3339),
+	}
+}
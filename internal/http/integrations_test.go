@@ -0,0 +1,212 @@
+package http_test
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/terps489/avito_tech_internship/internal/app"
+	"github.com/terps489/avito_tech_internship/internal/domain"
+	httpTransport "github.com/terps489/avito_tech_internship/internal/http"
+	"github.com/terps489/avito_tech_internship/internal/repository/memory"
+)
+
+const (
+	githubSecret = "gh-secret"
+	gitlabToken  = "gl-token"
+)
+
+func newIntegrationServer(t *testing.T) (http.Handler, *app.Service) {
+	t.Helper()
+	ctx := t.Context()
+
+	store := memory.NewStore()
+	svc := app.NewService(app.Repositories{
+		Users:            memory.NewUserRepository(store),
+		Teams:            memory.NewTeamRepository(store),
+		PullRequests:     memory.NewPullRequestRepository(store),
+		Audit:            memory.NewAuditRepository(store),
+		ReviewerEvents:   memory.NewReviewerEventRepository(store),
+		Outbox:           memory.NewOutboxRepository(store),
+		Webhooks:         memory.NewWebhookRepository(store),
+		ExternalAccounts: memory.NewExternalAccountRepository(store),
+		Tx:               store,
+	})
+
+	_, _, err := svc.CreateTeamWithMembers(ctx, "backend", []domain.User{
+		{ID: "u1", Username: "alice", IsActive: true},
+		{ID: "u2", Username: "bob", IsActive: true},
+		{ID: "u3", Username: "carol", IsActive: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srv := httpTransport.NewServer(":0", svc,
+		httpTransport.WithGitHubSecret(githubSecret),
+		httpTransport.WithGitLabToken(gitlabToken),
+	)
+	return srv.Handler(), svc
+}
+
+func fixture(t *testing.T, name string) []byte {
+	t.Helper()
+	b, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func githubSignature(body []byte) string {
+	mac := hmac.New(sha256.New, []byte(githubSecret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+type result struct {
+	status int
+	body   map[string]any
+}
+
+func replayGitHub(t *testing.T, h http.Handler, event, name string, signature func([]byte) string) result {
+	t.Helper()
+	body := fixture(t, filepath.Join("github", name))
+
+	req := httptest.NewRequest(http.MethodPost, "/integrations/github", bytes.NewReader(body))
+	req.Header.Set("X-GitHub-Event", event)
+	req.Header.Set("X-Hub-Signature-256", signature(body))
+	return serve(t, h, req)
+}
+
+func replayGitLab(t *testing.T, h http.Handler, token, name string) result {
+	t.Helper()
+	body := fixture(t, filepath.Join("gitlab", name))
+
+	req := httptest.NewRequest(http.MethodPost, "/integrations/gitlab", bytes.NewReader(body))
+	req.Header.Set("X-Gitlab-Event", "Merge Request Hook")
+	req.Header.Set("X-Gitlab-Token", token)
+	return serve(t, h, req)
+}
+
+func serve(t *testing.T, h http.Handler, req *http.Request) result {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	res := result{status: rec.Code}
+	if err := json.Unmarshal(rec.Body.Bytes(), &res.body); err != nil {
+		t.Fatalf("response is not json: %q", rec.Body.String())
+	}
+	return res
+}
+
+func wantResult(t *testing.T, got result, status int, outcome string) {
+	t.Helper()
+	if got.status != status || got.body["result"] != outcome {
+		t.Fatalf("got %d %v, want %d with result %q", got.status, got.body, status, outcome)
+	}
+}
+
+func TestGitHubPullRequestLifecycle(t *testing.T) {
+	h, svc := newIntegrationServer(t)
+	ctx := t.Context()
+
+	if _, err := svc.SetExternalAccount(ctx, domain.ProviderGitHub, "octocat", "u1"); err != nil {
+		t.Fatal(err)
+	}
+
+	res := replayGitHub(t, h, "pull_request", "pull_request_opened.json", githubSignature)
+	wantResult(t, res, http.StatusOK, "created")
+
+	const prID = domain.PullRequestID("github:octo-org/hello-world#42")
+	if res.body["pull_request_id"] != string(prID) {
+		t.Fatalf("pull_request_id = %v, want %s", res.body["pull_request_id"], prID)
+	}
+
+	history, err := svc.GetPullRequestHistory(ctx, prID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 2 {
+		t.Fatalf("history = %+v, want two auto-assigned reviewers", history)
+	}
+	if history[0].Actor != "github:Octocat" {
+		t.Fatalf("actor = %q, want the GitHub sender", history[0].Actor)
+	}
+
+	// GitHub redelivers on timeouts; a replay must not fail.
+	res = replayGitHub(t, h, "pull_request", "pull_request_opened.json", githubSignature)
+	wantResult(t, res, http.StatusOK, "exists")
+
+	res = replayGitHub(t, h, "pull_request", "pull_request_closed_merged.json", githubSignature)
+	wantResult(t, res, http.StatusOK, "merged")
+
+	prs, err := svc.ListPullRequests(ctx, domain.PullRequestFilter{ReviewerID: history[0].ToUserID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(prs) != 1 || prs[0].Status != domain.PRStatusMerged {
+		t.Fatalf("reviewer PRs = %+v, want the merged PR", prs)
+	}
+
+	res = replayGitHub(t, h, "pull_request", "pull_request_closed_merged.json", githubSignature)
+	wantResult(t, res, http.StatusOK, "merged")
+}
+
+func TestGitHubIgnoredEvents(t *testing.T) {
+	h, _ := newIntegrationServer(t)
+
+	res := replayGitHub(t, h, "ping", "ping.json", githubSignature)
+	wantResult(t, res, http.StatusOK, "ignored")
+
+	res = replayGitHub(t, h, "pull_request", "pull_request_closed_unmerged.json", githubSignature)
+	wantResult(t, res, http.StatusOK, "ignored")
+}
+
+func TestGitHubRejectsBadSignature(t *testing.T) {
+	h, _ := newIntegrationServer(t)
+
+	forged := func([]byte) string { return "sha256=" + hex.EncodeToString(make([]byte, sha256.Size)) }
+	res := replayGitHub(t, h, "pull_request", "pull_request_opened.json", forged)
+	if res.status != http.StatusUnauthorized {
+		t.Fatalf("status = %d, want 401", res.status)
+	}
+}
+
+func TestGitHubUnmappedLogin(t *testing.T) {
+	h, _ := newIntegrationServer(t)
+
+	res := replayGitHub(t, h, "pull_request", "pull_request_opened.json", githubSignature)
+	if res.status != http.StatusUnprocessableEntity {
+		t.Fatalf("status = %d %v, want 422", res.status, res.body)
+	}
+}
+
+func TestGitLabMergeRequestLifecycle(t *testing.T) {
+	h, svc := newIntegrationServer(t)
+
+	if _, err := svc.SetExternalAccount(t.Context(), domain.ProviderGitLab, "jdoe", "u2"); err != nil {
+		t.Fatal(err)
+	}
+
+	if res := replayGitLab(t, h, "wrong", "merge_request_open.json"); res.status != http.StatusUnauthorized {
+		t.Fatalf("status with wrong token = %d, want 401", res.status)
+	}
+
+	res := replayGitLab(t, h, gitlabToken, "merge_request_open.json")
+	wantResult(t, res, http.StatusOK, "created")
+	if res.body["pull_request_id"] != "gitlab:platform/billing!7" {
+		t.Fatalf("pull_request_id = %v", res.body["pull_request_id"])
+	}
+
+	res = replayGitLab(t, h, gitlabToken, "merge_request_merge.json")
+	wantResult(t, res, http.StatusOK, "merged")
+}
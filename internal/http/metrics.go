@@ -0,0 +1,34 @@
+package http
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/terps489/avito_tech_internship/internal/metrics"
+)
+
+// unmatchedRoute labels requests no route matched.
+const unmatchedRoute = "unmatched"
+
+// WithMetrics enables /metrics, served from reg, and records request
+// counts and latencies there.
+func WithMetrics(reg *metrics.Registry) Option {
+	return func(s *Server) {
+		s.metrics = reg
+		s.httpRequests = reg.NewCounterVec("http_requests_total",
+			"HTTP requests, by route and status.", "route", "status")
+		s.httpDuration = reg.NewHistogramVec("http_request_duration_seconds",
+			"HTTP request latency, by route and status.", metrics.DefaultBuckets, "route", "status")
+		s.httpRateLimited = reg.NewCounterVec("http_rate_limited_total",
+			"Requests refused with 429, by route.", "route")
+	}
+}
+
+func (s *Server) observeRequest(route string, status int, elapsed time.Duration) {
+	if s.metrics == nil {
+		return
+	}
+	label := strconv.Itoa(status)
+	s.httpRequests.Inc(route, label)
+	s.httpDuration.Observe(elapsed.Seconds(), route, label)
+}
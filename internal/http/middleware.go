@@ -0,0 +1,158 @@
+package http
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/terps489/avito_tech_internship/internal/app"
+)
+
+var tracer = otel.Tracer("github.com/terps489/avito_tech_internship/internal/http")
+
+// maxRequestIDLen bounds client-supplied request ids; longer or
+// non-printable ones are replaced with a generated id.
+const maxRequestIDLen = 128
+
+// withRequestLog assigns every request an id, taken from X-Request-ID or
+// generated, echoes it in the response and puts it into the context. The
+// request runs in a server span that continues the caller's traceparent.
+// When the request is done it is logged and, if enabled, counted in
+// metrics.
+func (s *Server) withRequestLog(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get("X-Request-ID")
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+		w.Header().Set("X-Request-ID", id)
+		ctx := app.WithRequestID(r.Context(), id)
+
+		route := s.route(r)
+		ctx = otel.GetTextMapPropagator().Extract(ctx, propagation.HeaderCarrier(r.Header))
+		ctx, span := tracer.Start(ctx, r.Method+" "+route,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPRequestMethodKey.String(r.Method),
+				semconv.HTTPRoute(route),
+				semconv.URLPath(r.URL.Path),
+			),
+		)
+		defer span.End()
+
+		rec := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+		start := time.Now()
+		next.ServeHTTP(rec, r.WithContext(ctx))
+		elapsed := time.Since(start)
+
+		s.observeRequest(route, rec.status, elapsed)
+
+		span.SetAttributes(semconv.HTTPResponseStatusCode(rec.status))
+		if rec.status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(rec.status))
+		}
+		if rec.err != nil {
+			span.RecordError(rec.err)
+		}
+
+		attrs := []slog.Attr{
+			slog.String("request_id", id),
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+			slog.String("route", route),
+			slog.Int("status", rec.status),
+			slog.Float64("latency_ms", float64(elapsed.Microseconds())/1000),
+		}
+		if sc := span.SpanContext(); sc.IsValid() {
+			attrs = append(attrs, slog.String("trace_id", sc.TraceID().String()))
+		}
+		if rec.errorCode != "" {
+			attrs = append(attrs, slog.String("error_code", string(rec.errorCode)))
+		}
+		level := slog.LevelInfo
+		if rec.err != nil {
+			attrs = append(attrs, slog.String("error", rec.err.Error()))
+			level = slog.LevelError
+		}
+		s.logger.LogAttrs(ctx, level, "http request", attrs...)
+	})
+}
+
+// route returns the mux pattern r matches, or unmatchedRoute, so that
+// arbitrary paths do not blow up log and metric cardinality.
+func (s *Server) route(r *http.Request) string {
+	if _, pattern := s.mux.Handler(r); pattern != "" {
+		return pattern
+	}
+	return unmatchedRoute
+}
+
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLen {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// errorRecorder is implemented by the response writers the middleware
+// wraps around handlers, so that writeJSON and writeInternalError can
+// pass the error of a response to the request log through any of them.
+type errorRecorder interface {
+	recordErrorCode(code ErrorCode)
+	recordError(err error)
+}
+
+// responseRecorder remembers what a handler responded with for the
+// request log. Unwrap keeps http.ResponseController working, which the
+// event stream relies on.
+type responseRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	errorCode   ErrorCode
+	err         error
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	if !r.wroteHeader {
+		r.status = status
+		r.wroteHeader = true
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	r.wroteHeader = true
+	return r.ResponseWriter.Write(b)
+}
+
+func (r *responseRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+func (r *responseRecorder) recordErrorCode(code ErrorCode) {
+	r.errorCode = code
+}
+
+func (r *responseRecorder) recordError(err error) {
+	r.err = err
+}
@@ -0,0 +1,176 @@
+package http_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/terps489/avito_tech_internship/internal/app"
+	"github.com/terps489/avito_tech_internship/internal/domain"
+	httpTransport "github.com/terps489/avito_tech_internship/internal/http"
+	"github.com/terps489/avito_tech_internship/internal/repository/memory"
+)
+
+// brokenUsers fails every lookup, standing in for a database outage.
+type brokenUsers struct {
+	app.UserRepository
+}
+
+func (brokenUsers) GetByID(context.Context, domain.UserID) (*domain.User, error) {
+	return nil, errors.New("connection refused by 10.0.0.7")
+}
+
+func newLoggedServer(t *testing.T, logs *bytes.Buffer) *httptest.Server {
+	t.Helper()
+
+	store := memory.NewStore()
+	svc := app.NewService(app.Repositories{
+		Users:            brokenUsers{memory.NewUserRepository(store)},
+		Teams:            memory.NewTeamRepository(store),
+		PullRequests:     memory.NewPullRequestRepository(store),
+		Audit:            memory.NewAuditRepository(store),
+		ReviewerEvents:   memory.NewReviewerEventRepository(store),
+		Outbox:           memory.NewOutboxRepository(store),
+		Webhooks:         memory.NewWebhookRepository(store),
+		ExternalAccounts: memory.NewExternalAccountRepository(store),
+		Tx:               store,
+	})
+
+	srv := httpTransport.NewServer(":0", svc,
+		httpTransport.WithLogger(slog.New(slog.NewJSONHandler(logs, nil))),
+	)
+	ts := httptest.NewServer(srv.Handler())
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func TestRequestIDIsPropagated(t *testing.T) {
+	var logs bytes.Buffer
+	ts := newLoggedServer(t, &logs)
+
+	req, _ := http.NewRequest(http.MethodGet, ts.URL+"/health", nil)
+	req.Header.Set("X-Request-ID", "req-42")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Body.Close()
+	if got := resp.Header.Get("X-Request-ID"); got != "req-42" {
+		t.Errorf("X-Request-ID = %q, want req-42", got)
+	}
+
+	resp, err = http.Get(ts.URL + "/health")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Body.Close()
+	if got := resp.Header.Get("X-Request-ID"); len(got) != 32 {
+		t.Errorf("generated X-Request-ID = %q, want 32 hex digits", got)
+	}
+}
+
+func TestInternalErrorIsLoggedNotLeaked(t *testing.T) {
+	var logs bytes.Buffer
+	ts := newLoggedServer(t, &logs)
+
+	req, _ := http.NewRequest(http.MethodGet, ts.URL+"/users/get?user_id=u1", nil)
+	req.Header.Set("X-Request-ID", "req-500")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+
+	if resp.StatusCode != http.StatusInternalServerError {
+		t.Fatalf("status = %d, want 500", resp.StatusCode)
+	}
+	if strings.Contains(string(body), "10.0.0.7") {
+		t.Errorf("response leaks the cause: %s", body)
+	}
+
+	var entry map[string]any
+	if err := json.Unmarshal(logs.Bytes(), &entry); err != nil {
+		t.Fatalf("log line %q: %v", logs.String(), err)
+	}
+	want := map[string]any{
+		"level":      "ERROR",
+		"request_id": "req-500",
+		"method":     "GET",
+		"path":       "/users/get",
+		"route":      "/users/get",
+		"status":     float64(500),
+		"error_code": "INTERNAL",
+		"error":      "connection refused by 10.0.0.7",
+	}
+	for k, v := range want {
+		if entry[k] != v {
+			t.Errorf("log %s = %v, want %v", k, entry[k], v)
+		}
+	}
+}
+
+func TestTraceparentIsContinued(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	var logs bytes.Buffer
+	ts := newLoggedServer(t, &logs)
+
+	const (
+		traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+		spanID  = "00f067aa0ba902b7"
+	)
+	req, _ := http.NewRequest(http.MethodGet, ts.URL+"/users/get?user_id=u1", nil)
+	req.Header.Set("traceparent", "00-"+traceID+"-"+spanID+"-01")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Body.Close()
+
+	spans := make(map[string]sdktrace.ReadOnlySpan)
+	for _, s := range recorder.Ended() {
+		spans[s.Name()] = s
+	}
+
+	server, ok := spans["GET /users/get"]
+	if !ok {
+		t.Fatalf("no server span among %v", spans)
+	}
+	if got := server.SpanContext().TraceID().String(); got != traceID {
+		t.Errorf("trace id = %s, want %s", got, traceID)
+	}
+	if got := server.Parent().SpanID().String(); got != spanID {
+		t.Errorf("parent span id = %s, want %s", got, spanID)
+	}
+	if server.Status().Code != codes.Error {
+		t.Errorf("server span status = %v, want Error for a 500", server.Status().Code)
+	}
+
+	service, ok := spans["Service.GetUserProfile"]
+	if !ok {
+		t.Fatalf("no service span among %v", spans)
+	}
+	if service.Parent().SpanID() != server.SpanContext().SpanID() {
+		t.Error("service span is not a child of the server span")
+	}
+
+	if !strings.Contains(logs.String(), `"trace_id":"`+traceID+`"`) {
+		t.Errorf("request log lacks the trace id: %s", logs.String())
+	}
+}
@@ -0,0 +1,172 @@
+package http
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/terps489/avito_tech_internship/internal/domain"
+)
+
+const (
+	defaultPullRequestLimit = 50
+	maxPullRequestLimit     = 200
+
+	defaultUserLimit = 50
+	maxUserLimit     = 200
+)
+
+// statusAll disables the status filter of pull request listings.
+const statusAll = "ALL"
+
+// encodePullRequestCursor makes an opaque cursor pointing at pr.
+func encodePullRequestCursor(pr domain.PullRequest) string {
+	raw := pr.CreatedAt.UTC().Format(time.RFC3339Nano) + "|" + string(pr.ID)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodePullRequestCursor(s string) (*domain.PullRequestCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, errors.New("invalid cursor")
+	}
+	ts, id, ok := strings.Cut(string(raw), "|")
+	if !ok || id == "" {
+		return nil, errors.New("invalid cursor")
+	}
+	createdAt, err := time.Parse(time.RFC3339Nano, ts)
+	if err != nil {
+		return nil, errors.New("invalid cursor")
+	}
+	return &domain.PullRequestCursor{CreatedAt: createdAt, ID: domain.PullRequestID(id)}, nil
+}
+
+// parsePullRequestFilter reads the listing parameters shared by pull
+// request endpoints: status, created_from, created_to, order, limit and
+// cursor. defaultStatus applies when status is absent. The returned error
+// is meant for the client.
+func parsePullRequestFilter(q url.Values, defaultStatus domain.PRStatus) (domain.PullRequestFilter, error) {
+	filter := domain.PullRequestFilter{
+		Status: defaultStatus,
+		Limit:  defaultPullRequestLimit,
+	}
+
+	switch v := q.Get("status"); v {
+	case "":
+	case statusAll:
+		filter.Status = ""
+	case string(domain.PRStatusOpen), string(domain.PRStatusMerged):
+		filter.Status = domain.PRStatus(v)
+	default:
+		return filter, errors.New("status must be OPEN, MERGED or ALL")
+	}
+
+	if v := q.Get("created_from"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return filter, errors.New("created_from must be an RFC 3339 timestamp")
+		}
+		filter.CreatedFrom = t
+	}
+	if v := q.Get("created_to"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return filter, errors.New("created_to must be an RFC 3339 timestamp")
+		}
+		filter.CreatedTo = t
+	}
+
+	switch q.Get("order") {
+	case "", "asc":
+	case "desc":
+		filter.Desc = true
+	default:
+		return filter, errors.New("order must be asc or desc")
+	}
+
+	if v := q.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 || limit > maxPullRequestLimit {
+			return filter, errors.New("limit must be between 1 and " + strconv.Itoa(maxPullRequestLimit))
+		}
+		filter.Limit = limit
+	}
+
+	if v := q.Get("cursor"); v != "" {
+		cursor, err := decodePullRequestCursor(v)
+		if err != nil {
+			return filter, err
+		}
+		filter.After = cursor
+	}
+
+	return filter, nil
+}
+
+// nextPullRequestCursor returns the cursor of the following page, or nil
+// if prs is the last one.
+func nextPullRequestCursor(prs []domain.PullRequest, limit int) *string {
+	if len(prs) == 0 || len(prs) < limit {
+		return nil
+	}
+	next := encodePullRequestCursor(prs[len(prs)-1])
+	return &next
+}
+
+// encodeUserCursor makes an opaque cursor pointing at u.
+func encodeUserCursor(u domain.User) string {
+	raw, _ := json.Marshal([]string{u.Username, string(u.ID)})
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func decodeUserCursor(s string) (*domain.UserCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, errors.New("invalid cursor")
+	}
+	var parts []string
+	if err := json.Unmarshal(raw, &parts); err != nil || len(parts) != 2 || parts[1] == "" {
+		return nil, errors.New("invalid cursor")
+	}
+	return &domain.UserCursor{Username: parts[0], ID: domain.UserID(parts[1])}, nil
+}
+
+// parseUserFilter reads the parameters of /users/list: username, team_name,
+// is_active, limit and cursor. The returned error is meant for the client.
+func parseUserFilter(q url.Values) (domain.UserFilter, error) {
+	filter := domain.UserFilter{
+		UsernamePrefix: q.Get("username"),
+		TeamName:       domain.TeamName(q.Get("team_name")),
+		Limit:          defaultUserLimit,
+	}
+
+	if v := q.Get("is_active"); v != "" {
+		active, err := strconv.ParseBool(v)
+		if err != nil {
+			return filter, errors.New("is_active must be true or false")
+		}
+		filter.IsActive = &active
+	}
+
+	if v := q.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 || limit > maxUserLimit {
+			return filter, errors.New("limit must be between 1 and " + strconv.Itoa(maxUserLimit))
+		}
+		filter.Limit = limit
+	}
+
+	if v := q.Get("cursor"); v != "" {
+		cursor, err := decodeUserCursor(v)
+		if err != nil {
+			return filter, err
+		}
+		filter.After = cursor
+	}
+
+	return filter, nil
+}
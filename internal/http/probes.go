@@ -0,0 +1,78 @@
+package http
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// readinessTimeout bounds each readiness check.
+const readinessTimeout = 2 * time.Second
+
+// ReadinessCheck is a dependency /readyz probes, e.g. a database ping.
+type ReadinessCheck struct {
+	Name  string
+	Check func(ctx context.Context) error
+}
+
+// WithReadinessChecks makes /readyz run checks. Without checks /readyz
+// answers like /livez.
+func WithReadinessChecks(checks ...ReadinessCheck) Option {
+	return func(s *Server) {
+		s.readiness = append(s.readiness, checks...)
+	}
+}
+
+// handleLivez tells whether the process is up. It does not look at
+// dependencies, so a database outage does not get the pod restarted.
+func (s *Server) handleLivez(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeMethodNotAllowed(w)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"status": "ok",
+	})
+}
+
+// handleReadyz runs the readiness checks and answers 503 if any fails or
+// the server is shutting down. Failure details go to the log rather than
+// to the caller.
+func (s *Server) handleReadyz(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeMethodNotAllowed(w)
+		return
+	}
+
+	if s.draining.Load() {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]any{
+			"status": "draining",
+			"checks": map[string]string{},
+		})
+		return
+	}
+
+	status, code := "ok", http.StatusOK
+	checks := make(map[string]string, len(s.readiness))
+	for _, c := range s.readiness {
+		ctx, cancel := context.WithTimeout(r.Context(), readinessTimeout)
+		err := c.Check(ctx)
+		cancel()
+
+		if err != nil {
+			s.logger.WarnContext(r.Context(), "readiness check failed",
+				slog.String("check", c.Name), slog.Any("error", err))
+			checks[c.Name] = "fail"
+			status, code = "unavailable", http.StatusServiceUnavailable
+			continue
+		}
+		checks[c.Name] = "ok"
+	}
+
+	writeJSON(w, code, map[string]any{
+		"status": status,
+		"checks": checks,
+	})
+}
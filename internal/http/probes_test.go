@@ -0,0 +1,87 @@
+package http_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/terps489/avito_tech_internship/internal/app"
+	httpTransport "github.com/terps489/avito_tech_internship/internal/http"
+	"github.com/terps489/avito_tech_internship/internal/repository/memory"
+)
+
+func newProbeServer(t *testing.T, checks ...httpTransport.ReadinessCheck) *httptest.Server {
+	t.Helper()
+
+	store := memory.NewStore()
+	svc := app.NewService(app.Repositories{
+		Users:            memory.NewUserRepository(store),
+		Teams:            memory.NewTeamRepository(store),
+		PullRequests:     memory.NewPullRequestRepository(store),
+		Audit:            memory.NewAuditRepository(store),
+		ReviewerEvents:   memory.NewReviewerEventRepository(store),
+		Outbox:           memory.NewOutboxRepository(store),
+		Webhooks:         memory.NewWebhookRepository(store),
+		ExternalAccounts: memory.NewExternalAccountRepository(store),
+		Tx:               store,
+	})
+
+	srv := httpTransport.NewServer(":0", svc, httpTransport.WithReadinessChecks(checks...))
+	ts := httptest.NewServer(srv.Handler())
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+type probeResponse struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks"`
+}
+
+func getProbe(t *testing.T, url string) (int, probeResponse) {
+	t.Helper()
+
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	var body probeResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+	return resp.StatusCode, body
+}
+
+func TestReadyz(t *testing.T) {
+	dbUp := true
+	ts := newProbeServer(t,
+		httpTransport.ReadinessCheck{Name: "database", Check: func(context.Context) error {
+			if !dbUp {
+				return errors.New("connection refused")
+			}
+			return nil
+		}},
+		httpTransport.ReadinessCheck{Name: "migrations", Check: func(context.Context) error { return nil }},
+	)
+
+	code, body := getProbe(t, ts.URL+"/readyz")
+	if code != http.StatusOK || body.Status != "ok" || body.Checks["database"] != "ok" {
+		t.Errorf("ready: %d %+v", code, body)
+	}
+
+	dbUp = false
+	code, body = getProbe(t, ts.URL+"/readyz")
+	if code != http.StatusServiceUnavailable || body.Status != "unavailable" ||
+		body.Checks["database"] != "fail" || body.Checks["migrations"] != "ok" {
+		t.Errorf("database down: %d %+v", code, body)
+	}
+
+	// Liveness ignores dependencies.
+	if code, body := getProbe(t, ts.URL+"/livez"); code != http.StatusOK || body.Status != "ok" {
+		t.Errorf("livez with database down: %d %+v", code, body)
+	}
+}
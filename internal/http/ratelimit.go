@@ -0,0 +1,153 @@
+package http
+
+import (
+	"context"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/terps489/avito_tech_internship/internal/app"
+	"github.com/terps489/avito_tech_internship/internal/metrics"
+	"github.com/terps489/avito_tech_internship/internal/ratelimit"
+)
+
+// rateLimitExempt are polled by infrastructure and must not be refused.
+var rateLimitExempt = map[string]bool{
+	"/health":  true,
+	"/livez":   true,
+	"/readyz":  true,
+	"/metrics": true,
+}
+
+// RateLimits are token buckets per client and route. Routes are mux
+// patterns such as "/pullRequest/create"; routes not listed use Default.
+// AuthFailures limits requests per IP address that fail authentication,
+// so that bearer tokens cannot be guessed at the rate of the routes.
+type RateLimits struct {
+	Default      ratelimit.Limit
+	Routes       map[string]ratelimit.Limit
+	AuthFailures ratelimit.Limit
+}
+
+func (l RateLimits) forRoute(route string) ratelimit.Limit {
+	if lim, ok := l.Routes[route]; ok {
+		return lim
+	}
+	return l.Default
+}
+
+// WithRateLimits answers 429 to clients over their limits. A client is
+// the API token or SSO user of the request, or its IP address without
+// one.
+func WithRateLimits(l RateLimits) Option {
+	return func(s *Server) {
+		s.rateLimits = l
+		s.limiter = ratelimit.New()
+	}
+}
+
+// withRateLimit runs after withAuth, so that the principal is known.
+func (s *Server) withRateLimit(next http.Handler) http.Handler {
+	if s.limiter == nil {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route := s.route(r)
+		if rateLimitExempt[route] {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		ok, wait := s.limiter.Allow(route+" "+clientID(r), s.rateLimits.forRoute(route))
+		if !ok {
+			if s.metrics != nil {
+				s.httpRateLimited.Inc(route)
+			}
+			writeRateLimited(w, wait)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// clientID names the caller for per-client state: its principal, or its
+// IP address without one.
+func clientID(r *http.Request) string {
+	if id := principalID(r.Context()); id != "" {
+		return id
+	}
+	return clientIP(r)
+}
+
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return "ip:" + r.RemoteAddr
+	}
+	return "ip:" + host
+}
+
+// principalID names the authenticated caller for per-client state, or
+// is empty without authentication.
+func principalID(ctx context.Context) string {
+	p, ok := app.PrincipalFromContext(ctx)
+	if !ok {
+		return ""
+	}
+	if p.TokenID != 0 {
+		return "token:" + strconv.FormatInt(p.TokenID, 10)
+	}
+	return "user:" + string(p.UserID)
+}
+
+// takeAuthAttempt charges an authentication attempt from r to the bucket
+// of its IP address, before the token is checked, and reports whether the
+// request may go on. Attempts that succeed are refunded with
+// refundAuthAttempt, so that only failures use the bucket up.
+func (s *Server) takeAuthAttempt(w http.ResponseWriter, r *http.Request) bool {
+	if s.limiter == nil {
+		return true
+	}
+
+	ok, wait := s.limiter.Allow(authAttemptKey(r), s.rateLimits.AuthFailures)
+	if !ok {
+		if s.metrics != nil {
+			s.httpRateLimited.Inc(s.route(r))
+		}
+		writeRateLimited(w, wait)
+	}
+	return ok
+}
+
+func (s *Server) refundAuthAttempt(r *http.Request) {
+	if s.limiter != nil {
+		s.limiter.Refund(authAttemptKey(r))
+	}
+}
+
+func authAttemptKey(r *http.Request) string {
+	return "auth " + clientIP(r)
+}
+
+func writeRateLimited(w http.ResponseWriter, wait time.Duration) {
+	// Retry-After takes whole seconds; rounding down would invite a retry
+	// that is refused again.
+	w.Header().Set("Retry-After", strconv.Itoa(max(1, int(math.Ceil(wait.Seconds())))))
+	writeJSON(w, http.StatusTooManyRequests, ErrorResponse{
+		Error: ErrorPayload{
+			Code:    ErrorCodeRateLimited,
+			Message: "rate limit exceeded, retry later",
+		},
+	})
+}
+
+func (s *Server) registerRateLimitMetrics() {
+	s.metrics.NewFunc("http_rate_limit_buckets",
+		"Clients and routes with a rate limit bucket in memory.", metrics.TypeGauge, nil,
+		func(context.Context) ([]metrics.Sample, error) {
+			return []metrics.Sample{{Value: float64(s.limiter.Len())}}, nil
+		})
+}
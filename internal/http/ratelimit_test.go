@@ -0,0 +1,169 @@
+package http_test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/terps489/avito_tech_internship/internal/app"
+	"github.com/terps489/avito_tech_internship/internal/domain"
+	httpTransport "github.com/terps489/avito_tech_internship/internal/http"
+	"github.com/terps489/avito_tech_internship/internal/metrics"
+	"github.com/terps489/avito_tech_internship/internal/ratelimit"
+	"github.com/terps489/avito_tech_internship/internal/repository/memory"
+)
+
+func TestRateLimits(t *testing.T) {
+	store := memory.NewStore()
+	svc := app.NewService(app.Repositories{
+		Users:            memory.NewUserRepository(store),
+		Teams:            memory.NewTeamRepository(store),
+		PullRequests:     memory.NewPullRequestRepository(store),
+		Audit:            memory.NewAuditRepository(store),
+		ReviewerEvents:   memory.NewReviewerEventRepository(store),
+		Outbox:           memory.NewOutboxRepository(store),
+		Webhooks:         memory.NewWebhookRepository(store),
+		ExternalAccounts: memory.NewExternalAccountRepository(store),
+		APITokens:        memory.NewAPITokenRepository(store),
+		Tx:               store,
+	})
+	// The rates are low enough that no token refills during the test.
+	srv := httpTransport.NewServer(":0", svc,
+		httpTransport.WithMetrics(metrics.NewRegistry()),
+		httpTransport.WithRateLimits(httpTransport.RateLimits{
+			Default: ratelimit.Limit{Rate: 0.01, Burst: 3},
+			Routes: map[string]ratelimit.Limit{
+				"/users/list": {Rate: 0.01, Burst: 1},
+				"/team/get":   {},
+			},
+		}),
+	)
+	ts := httptest.NewServer(srv.Handler())
+	t.Cleanup(ts.Close)
+
+	get := func(path string) *http.Response {
+		t.Helper()
+		resp, err := http.Get(ts.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { _ = resp.Body.Close() })
+		return resp
+	}
+
+	if resp := get("/users/list"); resp.StatusCode != http.StatusOK {
+		t.Fatalf("first request: status = %d, want 200", resp.StatusCode)
+	}
+	resp := get("/users/list")
+	if resp.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("over the route limit: status = %d, want 429", resp.StatusCode)
+	}
+	if ra := resp.Header.Get("Retry-After"); ra != "100" {
+		t.Errorf("Retry-After = %q, want 100", ra)
+	}
+	var body struct {
+		Error struct {
+			Code string `json:"code"`
+		} `json:"error"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil || body.Error.Code != "RATE_LIMITED" {
+		t.Errorf("body code = %q (%v), want RATE_LIMITED", body.Error.Code, err)
+	}
+
+	// Other routes have buckets of their own.
+	for i := range 3 {
+		if resp := get("/pullRequest/list"); resp.StatusCode != http.StatusOK {
+			t.Errorf("request %d within the default burst: status = %d", i+1, resp.StatusCode)
+		}
+	}
+	if resp := get("/pullRequest/list"); resp.StatusCode != http.StatusTooManyRequests {
+		t.Errorf("over the default limit: status = %d, want 429", resp.StatusCode)
+	}
+
+	for range 5 {
+		if resp := get("/livez"); resp.StatusCode != http.StatusOK {
+			t.Fatalf("/livez: status = %d, probes are not limited", resp.StatusCode)
+		}
+		if resp := get("/team/get?team_name=x"); resp.StatusCode == http.StatusTooManyRequests {
+			t.Fatal("/team/get has no limit but was refused")
+		}
+	}
+
+	data, err := io.ReadAll(get("/metrics").Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`http_rate_limited_total{route="/users/list"} 1`,
+		`http_rate_limited_total{route="/pullRequest/list"} 1`,
+	} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("metrics lack %s", want)
+		}
+	}
+}
+
+func TestRateLimitsAuthFailures(t *testing.T) {
+	store := memory.NewStore()
+	svc := app.NewService(app.Repositories{
+		Users:            memory.NewUserRepository(store),
+		Teams:            memory.NewTeamRepository(store),
+		PullRequests:     memory.NewPullRequestRepository(store),
+		Audit:            memory.NewAuditRepository(store),
+		ReviewerEvents:   memory.NewReviewerEventRepository(store),
+		Outbox:           memory.NewOutboxRepository(store),
+		Webhooks:         memory.NewWebhookRepository(store),
+		ExternalAccounts: memory.NewExternalAccountRepository(store),
+		APITokens:        memory.NewAPITokenRepository(store),
+		Tx:               store,
+	})
+	ts := httptest.NewServer(httpTransport.NewServer(":0", svc,
+		httpTransport.WithTokenAuth(),
+		httpTransport.WithRateLimits(httpTransport.RateLimits{
+			AuthFailures: ratelimit.Limit{Rate: 0.01, Burst: 3},
+		}),
+	).Handler())
+	t.Cleanup(ts.Close)
+
+	token, _, err := svc.CreateAPIToken(t.Context(), domain.APIToken{Name: "admin", Role: domain.RoleAdmin})
+	if err != nil {
+		t.Fatal(err)
+	}
+	get := func(token string) int {
+		t.Helper()
+		req, err := http.NewRequest(http.MethodGet, ts.URL+"/users/list", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_ = resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	// Successful requests do not count.
+	for range 5 {
+		if code := get(token); code != http.StatusOK {
+			t.Fatalf("valid token: status = %d, want 200", code)
+		}
+	}
+	for i := range 3 {
+		if code := get("rvw_guess"); code != http.StatusUnauthorized {
+			t.Errorf("guess %d: status = %d, want 401", i+1, code)
+		}
+	}
+	if code := get("rvw_guess"); code != http.StatusTooManyRequests {
+		t.Errorf("guess over the limit: status = %d, want 429", code)
+	}
+	// The token is not even checked any more, so a right guess does not
+	// get through either.
+	if code := get(token); code != http.StatusTooManyRequests {
+		t.Errorf("valid token from the same address: status = %d, want 429", code)
+	}
+}
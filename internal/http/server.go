@@ -1,36 +1,226 @@
 package http
 
 import (
+	"context"
 	"encoding/json"
-	"log"
+	"errors"
+	"log/slog"
+	"net"
 	"net/http"
+	"sync/atomic"
+	"time"
 
 	"github.com/terps489/avito_tech_internship/internal/app"
+	"github.com/terps489/avito_tech_internship/internal/metrics"
+	"github.com/terps489/avito_tech_internship/internal/pubsub"
+	"github.com/terps489/avito_tech_internship/internal/ratelimit"
 )
 
 type Server struct {
 	addr    string
 	service *app.Service
 	mux     *http.ServeMux
+
+	githubSecret   string
+	gitlabToken    string
+	authenticators []Authenticator
+	rateLimits     RateLimits
+	limiter        *ratelimit.Limiter
+	idempotencyTTL time.Duration
+
+	hub       *pubsub.Hub
+	heartbeat time.Duration
+
+	logger    *slog.Logger
+	readiness []ReadinessCheck
+
+	timeouts      Timeouts
+	shutdownHooks []func(context.Context) error
+	draining      atomic.Bool
+	// stopping is closed when the server starts shutting down, so that
+	// long-lived event streams let go of their connections.
+	stopping chan struct{}
+
+	metrics         *metrics.Registry
+	httpRequests    *metrics.CounterVec
+	httpDuration    *metrics.HistogramVec
+	httpRateLimited *metrics.CounterVec
+}
+
+// Option configures optional parts of Server.
+type Option func(*Server)
+
+// WithGitHubSecret enables the GitHub webhook endpoint. Requests must be
+// signed with secret.
+func WithGitHubSecret(secret string) Option {
+	return func(s *Server) {
+		s.githubSecret = secret
+	}
+}
+
+// WithGitLabToken enables the GitLab webhook endpoint. Requests must
+// carry token in X-Gitlab-Token.
+func WithGitLabToken(token string) Option {
+	return func(s *Server) {
+		s.gitlabToken = token
+	}
+}
+
+// WithEventHub enables /events/stream, which streams notifications
+// published to hub.
+func WithEventHub(hub *pubsub.Hub) Option {
+	return func(s *Server) {
+		s.hub = hub
+	}
+}
+
+// WithHeartbeat sets how often idle event streams send a keep-alive comment.
+func WithHeartbeat(d time.Duration) Option {
+	return func(s *Server) {
+		s.heartbeat = d
+	}
+}
+
+// Timeouts configure the underlying http.Server and its shutdown.
+type Timeouts struct {
+	ReadHeader time.Duration
+	Read       time.Duration
+	Write      time.Duration
+	Idle       time.Duration
+	// DrainDelay is how long the server keeps serving with /readyz failing
+	// before it stops accepting connections, so that load balancers move
+	// traffic elsewhere first.
+	DrainDelay time.Duration
+	// Shutdown bounds the wait for in-flight requests, and then separately
+	// the shutdown hooks.
+	Shutdown time.Duration
+}
+
+func DefaultTimeouts() Timeouts {
+	return Timeouts{
+		ReadHeader: 5 * time.Second,
+		Read:       15 * time.Second,
+		Write:      30 * time.Second,
+		Idle:       120 * time.Second,
+		DrainDelay: 0,
+		Shutdown:   20 * time.Second,
+	}
+}
+
+// WithTimeouts replaces DefaultTimeouts.
+func WithTimeouts(t Timeouts) Option {
+	return func(s *Server) {
+		s.timeouts = t
+	}
 }
 
-func NewServer(addr string, svc *app.Service) *Server {
+// WithShutdownHook adds fn to the functions Run calls, in the order they
+// were added, once in-flight requests are done, e.g. to close the
+// database.
+func WithShutdownHook(fn func(context.Context) error) Option {
+	return func(s *Server) {
+		s.shutdownHooks = append(s.shutdownHooks, fn)
+	}
+}
+
+// WithLogger sets the logger for request logs; slog.Default() is used
+// otherwise.
+func WithLogger(l *slog.Logger) Option {
+	return func(s *Server) {
+		s.logger = l
+	}
+}
+
+func NewServer(addr string, svc *app.Service, opts ...Option) *Server {
 	s := &Server{
-		addr:    addr,
-		service: svc,
-		mux:     http.NewServeMux(),
+		addr:      addr,
+		service:   svc,
+		mux:       http.NewServeMux(),
+		heartbeat: 15 * time.Second,
+		logger:    slog.Default(),
+		timeouts:  DefaultTimeouts(),
+		stopping:  make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(s)
 	}
 	s.registerRoutes()
 	return s
 }
 
-func (s *Server) Run() error {
-	log.Printf("starting http server on %s", s.addr)
-	return http.ListenAndServe(s.addr, s.mux)
+// Run listens on the server address and serves until ctx is done, then
+// shuts down gracefully; see Serve.
+func (s *Server) Run(ctx context.Context) error {
+	ln, err := net.Listen("tcp", s.addr)
+	if err != nil {
+		return err
+	}
+	return s.Serve(ctx, ln)
+}
+
+// Serve serves on ln until ctx is done. It then fails /readyz for
+// DrainDelay, stops accepting connections, waits up to Shutdown for
+// in-flight requests and finally runs the shutdown hooks.
+func (s *Server) Serve(ctx context.Context, ln net.Listener) error {
+	srv := &http.Server{
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: s.timeouts.ReadHeader,
+		ReadTimeout:       s.timeouts.Read,
+		WriteTimeout:      s.timeouts.Write,
+		IdleTimeout:       s.timeouts.Idle,
+		ErrorLog:          slog.NewLogLogger(s.logger.Handler(), slog.LevelWarn),
+	}
+	srv.RegisterOnShutdown(func() { close(s.stopping) })
+
+	serveErr := make(chan error, 1)
+	go func() {
+		s.logger.Info("starting http server", slog.String("addr", ln.Addr().String()))
+		serveErr <- srv.Serve(ln)
+	}()
+
+	select {
+	case err := <-serveErr:
+		return err
+	case <-ctx.Done():
+	}
+
+	s.draining.Store(true)
+	if s.timeouts.DrainDelay > 0 {
+		s.logger.Info("draining before shutdown", slog.Duration("delay", s.timeouts.DrainDelay))
+		time.Sleep(s.timeouts.DrainDelay)
+	}
+
+	s.logger.Info("shutting down http server", slog.Duration("timeout", s.timeouts.Shutdown))
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), s.timeouts.Shutdown)
+	defer cancel()
+
+	var errs []error
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		s.logger.Warn("in-flight requests did not finish in time", slog.Any("error", err))
+		errs = append(errs, err, srv.Close())
+	}
+	if err := <-serveErr; !errors.Is(err, http.ErrServerClosed) {
+		errs = append(errs, err)
+	}
+
+	hookCtx, cancelHooks := context.WithTimeout(context.Background(), s.timeouts.Shutdown)
+	defer cancelHooks()
+	for _, hook := range s.shutdownHooks {
+		errs = append(errs, hook(hookCtx))
+	}
+
+	return errors.Join(errs...)
+}
+
+// Handler returns the root handler with all middleware applied.
+func (s *Server) Handler() http.Handler {
+	return s.withRequestLog(s.withAuth(s.withRateLimit(s.withRequestContext(s.withIdempotency(s.mux)))))
 }
 
 func (s *Server) registerRoutes() {
 	s.mux.HandleFunc("/health", s.handleHealth)
+	s.mux.HandleFunc("/livez", s.handleLivez)
+	s.mux.HandleFunc("/readyz", s.handleReadyz)
 
 	// Teams
 	s.mux.HandleFunc("/team/add", s.handleTeamAdd)
@@ -38,15 +228,73 @@ func (s *Server) registerRoutes() {
 
 	// Stats
 	s.mux.HandleFunc("/stats/assignments", s.handleStatsAssignments)
+	s.mux.HandleFunc("/stats/reassignments", s.handleStatsReassignments)
+	s.mux.HandleFunc("/stats/cycleTime", s.handleStatsCycleTime)
+	s.mux.HandleFunc("/stats/fairness", s.handleStatsFairness)
 
 	// Users
 	s.mux.HandleFunc("/users/setIsActive", s.handleUserSetIsActive)
 	s.mux.HandleFunc("/users/getReview", s.handleUserGetReview)
+	s.mux.HandleFunc("/users/get", s.handleUserGet)
+	s.mux.HandleFunc("/users/list", s.handleUserList)
 
 	// Pull Requests
 	s.mux.HandleFunc("/pullRequest/create", s.handlePullRequestCreate)
 	s.mux.HandleFunc("/pullRequest/merge", s.handlePullRequestMerge)
 	s.mux.HandleFunc("/pullRequest/reassign", s.handlePullRequestReassign)
+	s.mux.HandleFunc("/pullRequest/history", s.handlePullRequestHistory)
+	s.mux.HandleFunc("/pullRequest/get", s.handlePullRequestGet)
+	s.mux.HandleFunc("/pullRequest/list", s.handlePullRequestList)
+
+	// Audit
+	s.mux.HandleFunc("/audit", s.handleAuditList)
+
+	// Webhooks
+	s.mux.HandleFunc("/webhooks/create", s.handleWebhookCreate)
+	s.mux.HandleFunc("/webhooks/list", s.handleWebhookList)
+	s.mux.HandleFunc("/webhooks/get", s.handleWebhookGet)
+	s.mux.HandleFunc("/webhooks/update", s.handleWebhookUpdate)
+	s.mux.HandleFunc("/webhooks/delete", s.handleWebhookDelete)
+	s.mux.HandleFunc("/webhooks/deliveries", s.handleWebhookDeliveries)
+	s.mux.HandleFunc("/webhooks/redeliver", s.handleWebhookRedeliver)
+
+	// Integrations
+	s.mux.HandleFunc("/integrations/github", s.handleGitHubWebhook)
+	s.mux.HandleFunc("/integrations/gitlab", s.handleGitLabWebhook)
+	s.mux.HandleFunc("/integrations/accounts/set", s.handleExternalAccountSet)
+	s.mux.HandleFunc("/integrations/accounts/list", s.handleExternalAccountList)
+	s.mux.HandleFunc("/integrations/accounts/delete", s.handleExternalAccountDelete)
+
+	// Events
+	s.mux.HandleFunc("/events/stream", s.handleEventStream)
+
+	// Metrics
+	if s.metrics != nil {
+		s.mux.Handle("/metrics", s.metrics.Handler())
+		if s.limiter != nil {
+			s.registerRateLimitMetrics()
+		}
+	}
+}
+
+// withRequestContext puts the caller identity into the request context,
+// where app.Service picks it up for the audit log. With token auth the
+// actor is the token's user, or its name. The X-Actor-ID header is only
+// trusted when auth is disabled; otherwise anyone could forge the actor.
+func (s *Server) withRequestContext(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		if p, ok := app.PrincipalFromContext(ctx); ok {
+			actor := string(p.UserID)
+			if actor == "" {
+				actor = "token:" + p.Name
+			}
+			ctx = app.WithActor(ctx, actor)
+		} else if actor := r.Header.Get("X-Actor-ID"); actor != "" && len(s.authenticators) == 0 {
+			ctx = app.WithActor(ctx, actor)
+		}
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
 }
 
 // ---------- Helpers ----------
@@ -55,20 +303,43 @@ func writeJSON(w http.ResponseWriter, status int, v any) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
 
+	if e, ok := v.(ErrorResponse); ok {
+		if rec, ok := w.(errorRecorder); ok {
+			rec.recordErrorCode(e.Error.Code)
+		}
+	}
+
 	if v == nil {
 		return
 	}
 
 	if err := json.NewEncoder(w).Encode(v); err != nil {
-		log.Printf("failed to write json response: %v", err)
+		slog.Error("failed to write json response", slog.Any("error", err))
+	}
+}
+
+// writeInternalError answers 500 without exposing err to the client; err
+// goes to the request log together with the request id.
+func writeInternalError(w http.ResponseWriter, err error) {
+	if rec, ok := w.(errorRecorder); ok {
+		rec.recordError(err)
+	} else {
+		slog.Error("internal error", slog.Any("error", err))
 	}
+
+	writeJSON(w, http.StatusInternalServerError, ErrorResponse{
+		Error: ErrorPayload{
+			Code:    ErrorCodeInternal,
+			Message: "internal error",
+		},
+	})
 }
 
 func writeMethodNotAllowed(w http.ResponseWriter) {
-	writeJSON(w, http.StatusMethodNotAllowed, map[string]any{
-		"error": map[string]any{
-			"code":    "METHOD_NOT_ALLOWED",
-			"message": "method not allowed",
+	writeJSON(w, http.StatusMethodNotAllowed, ErrorResponse{
+		Error: ErrorPayload{
+			Code:    ErrorCodeMethodNotAllowed,
+			Message: "method not allowed",
 		},
 	})
 }
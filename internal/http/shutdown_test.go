@@ -0,0 +1,112 @@
+package http_test
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/terps489/avito_tech_internship/internal/app"
+	httpTransport "github.com/terps489/avito_tech_internship/internal/http"
+	"github.com/terps489/avito_tech_internship/internal/repository/memory"
+)
+
+func serveUntilCancelled(t *testing.T, opts ...httpTransport.Option) (string, context.CancelFunc, <-chan error) {
+	t.Helper()
+
+	store := memory.NewStore()
+	svc := app.NewService(app.Repositories{
+		Users:            memory.NewUserRepository(store),
+		Teams:            memory.NewTeamRepository(store),
+		PullRequests:     memory.NewPullRequestRepository(store),
+		Audit:            memory.NewAuditRepository(store),
+		ReviewerEvents:   memory.NewReviewerEventRepository(store),
+		Outbox:           memory.NewOutboxRepository(store),
+		Webhooks:         memory.NewWebhookRepository(store),
+		ExternalAccounts: memory.NewExternalAccountRepository(store),
+		Tx:               store,
+	})
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	done := make(chan error, 1)
+	srv := httpTransport.NewServer(ln.Addr().String(), svc, opts...)
+	go func() { done <- srv.Serve(ctx, ln) }()
+	return "http://" + ln.Addr().String(), cancel, done
+}
+
+func TestServeFinishesInFlightRequestsBeforeHooks(t *testing.T) {
+	started := make(chan struct{})
+	var finished, hookSawFinished atomic.Bool
+
+	slow := httpTransport.ReadinessCheck{Name: "slow", Check: func(context.Context) error {
+		close(started)
+		time.Sleep(300 * time.Millisecond)
+		finished.Store(true)
+		return nil
+	}}
+	url, cancel, done := serveUntilCancelled(t,
+		httpTransport.WithReadinessChecks(slow),
+		httpTransport.WithShutdownHook(func(context.Context) error {
+			hookSawFinished.Store(finished.Load())
+			return nil
+		}),
+	)
+
+	type result struct {
+		code int
+		body probeResponse
+	}
+	res := make(chan result, 1)
+	go func() {
+		code, body := getProbe(t, url+"/readyz")
+		res <- result{code, body}
+	}()
+
+	<-started
+	cancel()
+
+	got := <-res
+	if got.code != http.StatusOK || got.body.Status != "ok" {
+		t.Fatalf("in-flight request = %d %+v, want 200 ok", got.code, got.body)
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("Serve returned %v", err)
+	}
+	if !hookSawFinished.Load() {
+		t.Fatal("shutdown hook ran before the in-flight request finished")
+	}
+}
+
+func TestServeFailsReadinessWhileDraining(t *testing.T) {
+	timeouts := httpTransport.DefaultTimeouts()
+	timeouts.DrainDelay = 500 * time.Millisecond
+	url, cancel, done := serveUntilCancelled(t, httpTransport.WithTimeouts(timeouts))
+
+	if code, _ := getProbe(t, url+"/readyz"); code != http.StatusOK {
+		t.Fatalf("before shutdown: status = %d, want 200", code)
+	}
+
+	cancel()
+	time.Sleep(100 * time.Millisecond)
+
+	code, body := getProbe(t, url+"/readyz")
+	if code != http.StatusServiceUnavailable || body.Status != "draining" {
+		t.Fatalf("while draining: %d %+v, want 503 draining", code, body)
+	}
+	if code, _ := getProbe(t, url+"/livez"); code != http.StatusOK {
+		t.Fatalf("livez while draining: status = %d, want 200", code)
+	}
+
+	if err := <-done; err != nil {
+		t.Fatalf("Serve returned %v", err)
+	}
+}
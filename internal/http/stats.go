@@ -0,0 +1,184 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/terps489/avito_tech_internship/internal/app"
+	"github.com/terps489/avito_tech_internship/internal/domain"
+)
+
+func (s *Server) handleStatsCycleTime(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeMethodNotAllowed(w)
+		return
+	}
+
+	q := r.URL.Query()
+	filter := domain.MergeTimesFilter{
+		TeamName: domain.TeamName(q.Get("team_name")),
+	}
+
+	var err error
+	if filter.From, filter.To, err = parseWindow(q); err != nil {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: ErrorPayload{
+				Code:    ErrorCodeBadRequest,
+				Message: err.Error(),
+			},
+		})
+		return
+	}
+
+	report, err := s.service.GetCycleTimeReport(r.Context(), filter)
+	if err != nil {
+		writeInternalError(w, err)
+		return
+	}
+
+	resp := struct {
+		Overall CycleTimeDTO   `json:"overall"`
+		Teams   []CycleTimeDTO `json:"teams"`
+		Authors []CycleTimeDTO `json:"authors"`
+	}{
+		Overall: toCycleTimeDTO(report.Overall),
+		Teams:   make([]CycleTimeDTO, 0, len(report.ByTeam)),
+		Authors: make([]CycleTimeDTO, 0, len(report.ByAuthor)),
+	}
+
+	for _, st := range report.ByTeam {
+		dto := toCycleTimeDTO(st)
+		dto.TeamName = st.Key
+		resp.Teams = append(resp.Teams, dto)
+	}
+	for _, st := range report.ByAuthor {
+		dto := toCycleTimeDTO(st)
+		dto.AuthorID = st.Key
+		resp.Authors = append(resp.Authors, dto)
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// defaultFairnessWindow is the window of /stats/fairness when from is absent.
+const defaultFairnessWindow = 30 * 24 * time.Hour
+
+func (s *Server) handleStatsFairness(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeMethodNotAllowed(w)
+		return
+	}
+
+	q := r.URL.Query()
+	filter := domain.FairnessFilter{
+		TeamName: domain.TeamName(q.Get("team_name")),
+	}
+	if filter.TeamName == "" {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: ErrorPayload{
+				Code:    ErrorCodeBadRequest,
+				Message: "team_name is required",
+			},
+		})
+		return
+	}
+
+	var err error
+	if filter.From, filter.To, err = parseWindow(q); err == nil {
+		if filter.To.IsZero() {
+			filter.To = time.Now().UTC()
+		}
+		if filter.From.IsZero() {
+			filter.From = filter.To.Add(-defaultFairnessWindow)
+		}
+		if !filter.From.Before(filter.To) {
+			err = errors.New("from must be before to")
+		}
+	}
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: ErrorPayload{
+				Code:    ErrorCodeBadRequest,
+				Message: err.Error(),
+			},
+		})
+		return
+	}
+
+	report, err := s.service.GetFairnessReport(r.Context(), filter)
+	if err != nil {
+		if errors.Is(err, app.ErrTeamNotFound) {
+			writeJSON(w, http.StatusNotFound, ErrorResponse{
+				Error: ErrorPayload{
+					Code:    ErrorCodeNotFound,
+					Message: "team not found",
+				},
+			})
+			return
+		}
+
+		writeInternalError(w, err)
+		return
+	}
+
+	resp := struct {
+		TeamName         string              `json:"team_name"`
+		From             time.Time           `json:"from"`
+		To               time.Time           `json:"to"`
+		TotalAssignments int64               `json:"total_assignments"`
+		Gini             float64             `json:"gini"`
+		Members          []MemberFairnessDTO `json:"members"`
+	}{
+		TeamName:         string(report.TeamName),
+		From:             report.From,
+		To:               report.To,
+		TotalAssignments: report.Total,
+		Gini:             report.Gini,
+		Members:          make([]MemberFairnessDTO, 0, len(report.Members)),
+	}
+
+	for _, m := range report.Members {
+		resp.Members = append(resp.Members, MemberFairnessDTO{
+			UserID:        string(m.UserID),
+			Username:      m.Username,
+			ActiveDays:    m.ActiveDays,
+			Assignments:   m.Assignments,
+			Share:         m.Share,
+			ExpectedShare: m.ExpectedShare,
+			ZScore:        m.ZScore,
+			Outlier:       m.Outlier,
+		})
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func toCycleTimeDTO(st domain.CycleTimeStat) CycleTimeDTO {
+	return CycleTimeDTO{
+		Count:      st.Count,
+		P50Seconds: st.P50.Seconds(),
+		P90Seconds: st.P90.Seconds(),
+		P99Seconds: st.P99.Seconds(),
+	}
+}
+
+// parseWindow reads the from/to parameters of statistics endpoints as
+// RFC 3339 timestamps. Either may be absent.
+func parseWindow(q url.Values) (from, to time.Time, err error) {
+	if v := q.Get("from"); v != "" {
+		if from, err = time.Parse(time.RFC3339, v); err != nil {
+			return from, to, errors.New("from must be an RFC 3339 timestamp")
+		}
+	}
+	if v := q.Get("to"); v != "" {
+		if to, err = time.Parse(time.RFC3339, v); err != nil {
+			return from, to, errors.New("to must be an RFC 3339 timestamp")
+		}
+	}
+	if !from.IsZero() && !to.IsZero() && !from.Before(to) {
+		return from, to, errors.New("from must be before to")
+	}
+	return from, to, nil
+}
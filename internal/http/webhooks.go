@@ -0,0 +1,398 @@
+package http
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/terps489/avito_tech_internship/internal/app"
+	"github.com/terps489/avito_tech_internship/internal/domain"
+)
+
+const (
+	defaultDeliveriesLimit = 50
+	maxDeliveriesLimit     = 200
+)
+
+func (s *Server) handleWebhookCreate(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeMethodNotAllowed(w)
+		return
+	}
+
+	var body CreateWebhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: ErrorPayload{
+				Code:    ErrorCodeBadRequest,
+				Message: "invalid json body",
+			},
+		})
+		return
+	}
+
+	webhook, err := s.service.CreateWebhook(r.Context(), body.URL, body.Secret, toEventTypes(body.EventTypes))
+	if err != nil {
+		writeWebhookError(w, err)
+		return
+	}
+
+	// The secret is only ever returned here.
+	resp := struct {
+		Webhook WebhookDTO `json:"webhook"`
+		Secret  string     `json:"secret"`
+	}{
+		Webhook: toWebhookDTO(webhook),
+		Secret:  webhook.Secret,
+	}
+
+	writeJSON(w, http.StatusCreated, resp)
+}
+
+func (s *Server) handleWebhookList(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeMethodNotAllowed(w)
+		return
+	}
+
+	webhooks, err := s.service.ListWebhooks(r.Context())
+	if errors.Is(err, app.ErrForbidden) {
+		writeForbidden(w)
+		return
+	}
+	if err != nil {
+		writeInternalError(w, err)
+		return
+	}
+
+	resp := struct {
+		Webhooks []WebhookDTO `json:"webhooks"`
+	}{
+		Webhooks: make([]WebhookDTO, 0, len(webhooks)),
+	}
+
+	for i := range webhooks {
+		resp.Webhooks = append(resp.Webhooks, toWebhookDTO(&webhooks[i]))
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *Server) handleWebhookGet(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeMethodNotAllowed(w)
+		return
+	}
+
+	id, err := strconv.ParseInt(r.URL.Query().Get("webhook_id"), 10, 64)
+	if err != nil || id < 1 {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: ErrorPayload{
+				Code:    ErrorCodeBadRequest,
+				Message: "webhook_id query param is required",
+			},
+		})
+		return
+	}
+
+	webhook, err := s.service.GetWebhook(r.Context(), id)
+	if err != nil {
+		writeWebhookError(w, err)
+		return
+	}
+
+	resp := struct {
+		Webhook WebhookDTO `json:"webhook"`
+	}{
+		Webhook: toWebhookDTO(webhook),
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *Server) handleWebhookUpdate(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeMethodNotAllowed(w)
+		return
+	}
+
+	var body UpdateWebhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: ErrorPayload{
+				Code:    ErrorCodeBadRequest,
+				Message: "invalid json body",
+			},
+		})
+		return
+	}
+
+	if body.ID < 1 {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: ErrorPayload{
+				Code:    ErrorCodeBadRequest,
+				Message: "webhook_id is required",
+			},
+		})
+		return
+	}
+
+	webhook, err := s.service.UpdateWebhook(r.Context(), body.ID, app.WebhookUpdate{
+		URL:        body.URL,
+		Secret:     body.Secret,
+		EventTypes: toEventTypes(body.EventTypes),
+		IsActive:   body.IsActive,
+	})
+	if err != nil {
+		writeWebhookError(w, err)
+		return
+	}
+
+	resp := struct {
+		Webhook WebhookDTO `json:"webhook"`
+	}{
+		Webhook: toWebhookDTO(webhook),
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *Server) handleWebhookDelete(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeMethodNotAllowed(w)
+		return
+	}
+
+	var body DeleteWebhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.ID < 1 {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: ErrorPayload{
+				Code:    ErrorCodeBadRequest,
+				Message: "webhook_id is required",
+			},
+		})
+		return
+	}
+
+	webhook, err := s.service.DeleteWebhook(r.Context(), body.ID)
+	if err != nil {
+		writeWebhookError(w, err)
+		return
+	}
+
+	resp := struct {
+		Webhook WebhookDTO `json:"webhook"`
+	}{
+		Webhook: toWebhookDTO(webhook),
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *Server) handleWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeMethodNotAllowed(w)
+		return
+	}
+
+	q := r.URL.Query()
+	filter := domain.WebhookDeliveryFilter{
+		Status: domain.WebhookDeliveryStatus(q.Get("status")),
+		Limit:  defaultDeliveriesLimit,
+	}
+
+	if v := q.Get("webhook_id"); v != "" {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || id < 1 {
+			writeJSON(w, http.StatusBadRequest, ErrorResponse{
+				Error: ErrorPayload{
+					Code:    ErrorCodeBadRequest,
+					Message: "invalid webhook_id",
+				},
+			})
+			return
+		}
+		filter.WebhookID = id
+	}
+
+	if v := q.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 || limit > maxDeliveriesLimit {
+			writeJSON(w, http.StatusBadRequest, ErrorResponse{
+				Error: ErrorPayload{
+					Code:    ErrorCodeBadRequest,
+					Message: "limit must be between 1 and " + strconv.Itoa(maxDeliveriesLimit),
+				},
+			})
+			return
+		}
+		filter.Limit = limit
+	}
+
+	if v := q.Get("cursor"); v != "" {
+		cursor, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || cursor < 1 {
+			writeJSON(w, http.StatusBadRequest, ErrorResponse{
+				Error: ErrorPayload{
+					Code:    ErrorCodeBadRequest,
+					Message: "invalid cursor",
+				},
+			})
+			return
+		}
+		filter.BeforeID = cursor
+	}
+
+	deliveries, err := s.service.ListWebhookDeliveries(r.Context(), filter)
+	if errors.Is(err, app.ErrForbidden) {
+		writeForbidden(w)
+		return
+	}
+	if err != nil {
+		writeInternalError(w, err)
+		return
+	}
+
+	resp := struct {
+		Deliveries []WebhookDeliveryDTO `json:"deliveries"`
+		NextCursor *string              `json:"next_cursor,omitempty"`
+	}{
+		Deliveries: make([]WebhookDeliveryDTO, 0, len(deliveries)),
+	}
+
+	for i := range deliveries {
+		resp.Deliveries = append(resp.Deliveries, toWebhookDeliveryDTO(&deliveries[i]))
+	}
+
+	if len(deliveries) == filter.Limit {
+		next := strconv.FormatInt(deliveries[len(deliveries)-1].ID, 10)
+		resp.NextCursor = &next
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *Server) handleWebhookRedeliver(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeMethodNotAllowed(w)
+		return
+	}
+
+	var body RedeliverRequest
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.DeliveryID < 1 {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: ErrorPayload{
+				Code:    ErrorCodeBadRequest,
+				Message: "delivery_id is required",
+			},
+		})
+		return
+	}
+
+	delivery, err := s.service.RedeliverWebhookDelivery(r.Context(), body.DeliveryID)
+	if err != nil {
+		if errors.Is(err, app.ErrForbidden) {
+			writeForbidden(w)
+			return
+		}
+		if errors.Is(err, sql.ErrNoRows) {
+			writeJSON(w, http.StatusNotFound, ErrorResponse{
+				Error: ErrorPayload{
+					Code:    ErrorCodeNotFound,
+					Message: "delivery not found",
+				},
+			})
+			return
+		}
+
+		writeInternalError(w, err)
+		return
+	}
+
+	resp := struct {
+		Delivery WebhookDeliveryDTO `json:"delivery"`
+	}{
+		Delivery: toWebhookDeliveryDTO(delivery),
+	}
+
+	writeJSON(w, http.StatusAccepted, resp)
+}
+
+func writeWebhookError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, app.ErrForbidden):
+		writeForbidden(w)
+	case errors.Is(err, sql.ErrNoRows):
+		writeJSON(w, http.StatusNotFound, ErrorResponse{
+			Error: ErrorPayload{
+				Code:    ErrorCodeNotFound,
+				Message: "webhook not found",
+			},
+		})
+	case errors.Is(err, app.ErrInvalidWebhookURL), errors.Is(err, app.ErrUnsupportedEventType):
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: ErrorPayload{
+				Code:    ErrorCodeBadRequest,
+				Message: err.Error(),
+			},
+		})
+	default:
+		writeInternalError(w, err)
+	}
+}
+
+func toEventTypes(types []string) []domain.EventType {
+	if types == nil {
+		return nil
+	}
+	out := make([]domain.EventType, 0, len(types))
+	for _, t := range types {
+		out = append(out, domain.EventType(t))
+	}
+	return out
+}
+
+func toWebhookDTO(wh *domain.Webhook) WebhookDTO {
+	dto := WebhookDTO{
+		ID:         wh.ID,
+		URL:        wh.URL,
+		EventTypes: make([]string, 0, len(wh.EventTypes)),
+		IsActive:   wh.IsActive,
+		CreatedAt:  wh.CreatedAt.UTC().Format(time.RFC3339),
+		UpdatedAt:  wh.UpdatedAt.UTC().Format(time.RFC3339),
+	}
+	for _, t := range wh.EventTypes {
+		dto.EventTypes = append(dto.EventTypes, string(t))
+	}
+	return dto
+}
+
+func toWebhookDeliveryDTO(d *domain.WebhookDelivery) WebhookDeliveryDTO {
+	dto := WebhookDeliveryDTO{
+		ID:           d.ID,
+		WebhookID:    d.WebhookID,
+		EventID:      d.EventID,
+		EventType:    string(d.EventType),
+		Status:       string(d.Status),
+		Attempts:     d.Attempts,
+		LastError:    d.LastError,
+		RedeliveryOf: d.RedeliveryOf,
+		CreatedAt:    d.CreatedAt.UTC().Format(time.RFC3339),
+	}
+	if d.ResponseStatus != 0 {
+		status := d.ResponseStatus
+		dto.ResponseStatus = &status
+	}
+	if d.Status == domain.WebhookDeliveryPending {
+		next := d.NextAttemptAt.UTC().Format(time.RFC3339)
+		dto.NextAttemptAt = &next
+	}
+	if d.DeliveredAt != nil {
+		delivered := d.DeliveredAt.UTC().Format(time.RFC3339)
+		dto.DeliveredAt = &delivered
+	}
+	return dto
+}
@@ -0,0 +1,81 @@
+package integration
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	"github.com/terps489/avito_tech_internship/internal/domain"
+)
+
+// GitHub request headers.
+const (
+	GitHubEventHeader     = "X-GitHub-Event"
+	GitHubSignatureHeader = "X-Hub-Signature-256"
+)
+
+type githubPullRequestPayload struct {
+	Action      string `json:"action"`
+	Number      int    `json:"number"`
+	PullRequest struct {
+		Title  string `json:"title"`
+		Merged bool   `json:"merged"`
+		User   struct {
+			Login string `json:"login"`
+		} `json:"user"`
+	} `json:"pull_request"`
+	Repository struct {
+		FullName string `json:"full_name"`
+	} `json:"repository"`
+	Sender struct {
+		Login string `json:"login"`
+	} `json:"sender"`
+}
+
+// VerifyGitHubSignature checks the X-Hub-Signature-256 header:
+// "sha256=" followed by the hex HMAC-SHA256 of the body.
+func VerifyGitHubSignature(secret string, body []byte, header string) bool {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	expected := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+	return hmac.Equal([]byte(expected), []byte(header))
+}
+
+// ParseGitHub parses a webhook with the given X-GitHub-Event header.
+// Events other than pull_request are ignored.
+func ParseGitHub(event string, body []byte) (PullRequestEvent, error) {
+	e := PullRequestEvent{Provider: domain.ProviderGitHub}
+
+	if event != "pull_request" {
+		e.Reason = fmt.Sprintf("event %q is not handled", event)
+		return e, nil
+	}
+
+	var p githubPullRequestPayload
+	if err := json.Unmarshal(body, &p); err != nil {
+		return e, fmt.Errorf("%w: %v", ErrMalformedPayload, err)
+	}
+	if p.Number == 0 || p.Repository.FullName == "" {
+		return e, fmt.Errorf("%w: missing pull request number or repository", ErrMalformedPayload)
+	}
+
+	e.PullRequestID = domain.PullRequestID(fmt.Sprintf("%s:%s#%d", domain.ProviderGitHub, p.Repository.FullName, p.Number))
+	e.Title = p.PullRequest.Title
+	e.AuthorLogin = p.PullRequest.User.Login
+	e.SenderLogin = p.Sender.Login
+
+	switch {
+	case p.Action == "opened" || p.Action == "reopened":
+		e.Kind = KindOpened
+	case p.Action == "closed" && p.PullRequest.Merged:
+		e.Kind = KindMerged
+	case p.Action == "closed":
+		e.Reason = "closed without merge"
+	default:
+		e.Reason = fmt.Sprintf("action %q is not handled", p.Action)
+	}
+
+	return e, nil
+}
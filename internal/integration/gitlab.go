@@ -0,0 +1,75 @@
+package integration
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+
+	"github.com/terps489/avito_tech_internship/internal/domain"
+)
+
+// GitLab request headers.
+const (
+	GitLabEventHeader = "X-Gitlab-Event"
+	GitLabTokenHeader = "X-Gitlab-Token"
+)
+
+type gitlabMergeRequestPayload struct {
+	ObjectKind string `json:"object_kind"`
+	User       struct {
+		Username string `json:"username"`
+	} `json:"user"`
+	Project struct {
+		PathWithNamespace string `json:"path_with_namespace"`
+	} `json:"project"`
+	ObjectAttributes struct {
+		IID    int    `json:"iid"`
+		Title  string `json:"title"`
+		Action string `json:"action"`
+	} `json:"object_attributes"`
+}
+
+// VerifyGitLabToken checks the X-Gitlab-Token header. GitLab sends
+// the configured secret token as is instead of a signature.
+func VerifyGitLabToken(token, header string) bool {
+	return subtle.ConstantTimeCompare([]byte(token), []byte(header)) == 1
+}
+
+// ParseGitLab parses a webhook with the given X-Gitlab-Event header.
+// Events other than merge request hooks are ignored.
+//
+// GitLab payloads carry only the numeric id of the merge request author,
+// so for opened events the author is the user who triggered the event.
+func ParseGitLab(event string, body []byte) (PullRequestEvent, error) {
+	e := PullRequestEvent{Provider: domain.ProviderGitLab}
+
+	if event != "Merge Request Hook" {
+		e.Reason = fmt.Sprintf("event %q is not handled", event)
+		return e, nil
+	}
+
+	var p gitlabMergeRequestPayload
+	if err := json.Unmarshal(body, &p); err != nil {
+		return e, fmt.Errorf("%w: %v", ErrMalformedPayload, err)
+	}
+	if p.ObjectKind != "merge_request" || p.ObjectAttributes.IID == 0 || p.Project.PathWithNamespace == "" {
+		return e, fmt.Errorf("%w: missing merge request iid or project", ErrMalformedPayload)
+	}
+
+	e.PullRequestID = domain.PullRequestID(fmt.Sprintf("%s:%s!%d",
+		domain.ProviderGitLab, p.Project.PathWithNamespace, p.ObjectAttributes.IID))
+	e.Title = p.ObjectAttributes.Title
+	e.AuthorLogin = p.User.Username
+	e.SenderLogin = p.User.Username
+
+	switch p.ObjectAttributes.Action {
+	case "open", "reopen":
+		e.Kind = KindOpened
+	case "merge":
+		e.Kind = KindMerged
+	default:
+		e.Reason = fmt.Sprintf("action %q is not handled", p.ObjectAttributes.Action)
+	}
+
+	return e, nil
+}
@@ -0,0 +1,38 @@
+// Package integration parses pull request webhooks sent by GitHub and
+// GitLab. It only understands payloads; mapping logins to users and
+// applying the events is left to the caller.
+package integration
+
+import (
+	"errors"
+
+	"github.com/terps489/avito_tech_internship/internal/domain"
+)
+
+var ErrMalformedPayload = errors.New("malformed webhook payload")
+
+type Kind int
+
+const (
+	// KindIgnored is an event that does not change anything here.
+	KindIgnored Kind = iota
+	// KindOpened maps to Service.CreatePullRequestWithID.
+	KindOpened
+	// KindMerged maps to Service.MergePullRequest.
+	KindMerged
+)
+
+// PullRequestEvent is a provider webhook reduced to what the service needs.
+type PullRequestEvent struct {
+	Kind     Kind
+	Provider domain.ExternalProvider
+	// PullRequestID is "<provider>:<repository>#<number>" for GitHub and
+	// "<provider>:<project>!<iid>" for GitLab, so ids never clash.
+	PullRequestID domain.PullRequestID
+	Title         string
+	AuthorLogin   string
+	// SenderLogin is whoever triggered the event on the provider side.
+	SenderLogin string
+	// Reason explains why an event is ignored.
+	Reason string
+}
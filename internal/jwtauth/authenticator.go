@@ -0,0 +1,42 @@
+package jwtauth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/terps489/avito_tech_internship/internal/app"
+	"github.com/terps489/avito_tech_internship/internal/domain"
+)
+
+// Users turns a verified user id into a principal.
+type Users interface {
+	AuthenticateUser(ctx context.Context, id domain.UserID) (domain.Principal, error)
+}
+
+// Authenticator accepts JWTs in place of API tokens. Bearer tokens that
+// are not shaped like a JWT are left to the other authenticators.
+type Authenticator struct {
+	verifier *Verifier
+	users    Users
+}
+
+func NewAuthenticator(v *Verifier, users Users) *Authenticator {
+	return &Authenticator{verifier: v, users: users}
+}
+
+func (a *Authenticator) Authenticate(ctx context.Context, raw string) (domain.Principal, error) {
+	if strings.Count(raw, ".") != 2 {
+		return domain.Principal{}, app.ErrUnauthenticated
+	}
+
+	id, err := a.verifier.UserID(ctx, raw)
+	if errors.Is(err, ErrInvalidToken) {
+		return domain.Principal{}, fmt.Errorf("%w: %v", app.ErrUnauthenticated, err)
+	}
+	if err != nil {
+		return domain.Principal{}, err
+	}
+	return a.users.AuthenticateUser(ctx, id)
+}
@@ -0,0 +1,116 @@
+package jwtauth_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/terps489/avito_tech_internship/internal/app"
+	"github.com/terps489/avito_tech_internship/internal/domain"
+	httpTransport "github.com/terps489/avito_tech_internship/internal/http"
+	"github.com/terps489/avito_tech_internship/internal/jwtauth"
+	"github.com/terps489/avito_tech_internship/internal/repository/memory"
+)
+
+func TestAuthenticatorOverHTTP(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(path, jwks(t, ecJWK("k1", &key.PublicKey)), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	v, err := jwtauth.New(t.Context(), jwtauth.Config{JWKS: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	store := memory.NewStore()
+	svc := app.NewService(app.Repositories{
+		Users:            memory.NewUserRepository(store),
+		Teams:            memory.NewTeamRepository(store),
+		PullRequests:     memory.NewPullRequestRepository(store),
+		Audit:            memory.NewAuditRepository(store),
+		ReviewerEvents:   memory.NewReviewerEventRepository(store),
+		Outbox:           memory.NewOutboxRepository(store),
+		Webhooks:         memory.NewWebhookRepository(store),
+		ExternalAccounts: memory.NewExternalAccountRepository(store),
+		APITokens:        memory.NewAPITokenRepository(store),
+		Tx:               store,
+	})
+	ts := httptest.NewServer(httpTransport.NewServer(":0", svc,
+		httpTransport.WithTokenAuth(),
+		httpTransport.WithAuthenticator(jwtauth.NewAuthenticator(v, svc)),
+	).Handler())
+	t.Cleanup(ts.Close)
+
+	ctx := t.Context()
+	if _, _, err := svc.CreateTeamWithMembers(ctx, "backend", []domain.User{
+		{ID: "u1", Username: "A", IsActive: true},
+		{ID: "u2", Username: "B", IsActive: true},
+		{ID: "u3", Username: "C", IsActive: true},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	adminToken, _, err := svc.CreateAPIToken(ctx, domain.APIToken{Name: "admin", Role: domain.RoleAdmin})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	post := func(path, token, body string) int {
+		t.Helper()
+		req, err := http.NewRequest(http.MethodPost, ts.URL+path, strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_ = resp.Body.Close()
+		return resp.StatusCode
+	}
+	jwtFor := func(user string) string {
+		return sign(t, "ES256", "k1", key, map[string]any{"sub": user, "exp": time.Now().Add(time.Hour).Unix()})
+	}
+
+	pr := `{"pull_request_id":"pr-1","pull_request_name":"x","author_id":"u1"}`
+	if code := post("/pullRequest/create", jwtFor("u1"), pr); code != http.StatusCreated {
+		t.Fatalf("create with a jwt: status = %d, want 201", code)
+	}
+	if code := post("/pullRequest/merge", jwtFor("u2"), `{"pull_request_id":"pr-1"}`); code != http.StatusForbidden {
+		t.Errorf("merge by someone else: status = %d, want 403", code)
+	}
+	if code := post("/pullRequest/merge", jwtFor("nobody"), `{"pull_request_id":"pr-1"}`); code != http.StatusUnauthorized {
+		t.Errorf("jwt of an unknown user: status = %d, want 401", code)
+	}
+	if code := post("/pullRequest/merge", jwtFor("u1"), `{"pull_request_id":"pr-1"}`); code != http.StatusOK {
+		t.Errorf("merge by the author: status = %d, want 200", code)
+	}
+	// API tokens keep working next to JWTs.
+	if code := post("/users/setIsActive", adminToken, `{"user_id":"u3","is_active":false}`); code != http.StatusOK {
+		t.Errorf("admin api token: status = %d, want 200", code)
+	}
+
+	events, err := svc.ListAuditEvents(ctx, domain.AuditFilter{EntityType: domain.AuditEntityPullRequest})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range events {
+		if e.Actor != "u1" {
+			t.Errorf("audit %s: actor = %q, want u1", e.Action, e.Actor)
+		}
+	}
+	if len(events) == 0 {
+		t.Error("no audit events for the pull request")
+	}
+}
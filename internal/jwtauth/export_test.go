@@ -0,0 +1,8 @@
+package jwtauth
+
+import "time"
+
+// SetNow replaces the clock of v.
+func SetNow(v *Verifier, now func() time.Time) {
+	v.now = now
+}
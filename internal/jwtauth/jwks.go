@@ -0,0 +1,125 @@
+package jwtauth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// KeySet holds the signing keys of a JWKS by key id.
+type KeySet struct {
+	keys map[string]crypto.PublicKey
+}
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// ParseJWKS reads a JSON Web Key Set. RSA keys and EC keys on P-256 are
+// kept; encryption keys and other key types are skipped.
+func ParseJWKS(data []byte) (*KeySet, error) {
+	var doc struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("parse jwks: %w", err)
+	}
+
+	ks := &KeySet{keys: make(map[string]crypto.PublicKey, len(doc.Keys))}
+	for i, k := range doc.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+
+		var (
+			key crypto.PublicKey
+			err error
+		)
+		switch k.Kty {
+		case "RSA":
+			key, err = rsaKey(k)
+		case "EC":
+			if k.Crv != "P-256" {
+				continue
+			}
+			key, err = ecKey(k)
+		default:
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("jwks key %d (kid %q): %w", i, k.Kid, err)
+		}
+		ks.keys[k.Kid] = key
+	}
+
+	if len(ks.keys) == 0 {
+		return nil, errors.New("jwks has no RSA or P-256 signing keys")
+	}
+	return ks, nil
+}
+
+// Len returns the number of keys.
+func (ks *KeySet) Len() int {
+	return len(ks.keys)
+}
+
+func (ks *KeySet) key(kid string) (crypto.PublicKey, bool) {
+	k, ok := ks.keys[kid]
+	return k, ok
+}
+
+func rsaKey(k jwk) (*rsa.PublicKey, error) {
+	n, err := base64.RawURLEncoding.DecodeString(k.N)
+	if err != nil {
+		return nil, fmt.Errorf("modulus: %w", err)
+	}
+	e, err := base64.RawURLEncoding.DecodeString(k.E)
+	if err != nil {
+		return nil, fmt.Errorf("exponent: %w", err)
+	}
+	if len(n) == 0 || len(e) == 0 || len(e) > 4 {
+		return nil, errors.New("malformed RSA key")
+	}
+
+	exp := 0
+	for _, b := range e {
+		exp = exp<<8 | int(b)
+	}
+	key := &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: exp}
+	if key.N.BitLen() < 2048 {
+		return nil, fmt.Errorf("RSA key of %d bits is too short", key.N.BitLen())
+	}
+	return key, nil
+}
+
+func ecKey(k jwk) (*ecdsa.PublicKey, error) {
+	x, err := base64.RawURLEncoding.DecodeString(k.X)
+	if err != nil {
+		return nil, fmt.Errorf("x: %w", err)
+	}
+	y, err := base64.RawURLEncoding.DecodeString(k.Y)
+	if err != nil {
+		return nil, fmt.Errorf("y: %w", err)
+	}
+	if len(x) != 32 || len(y) != 32 {
+		return nil, errors.New("malformed P-256 key")
+	}
+
+	// The uncompressed point encoding is 0x04 || X || Y; parsing it checks
+	// that the point is on the curve.
+	point := append(append([]byte{4}, x...), y...)
+	return ecdsa.ParseUncompressedPublicKey(elliptic.P256(), point)
+}
@@ -0,0 +1,266 @@
+// Package jwtauth validates JWTs issued by the company SSO against its
+// JWKS and maps them to users.
+package jwtauth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/terps489/avito_tech_internship/internal/domain"
+)
+
+var ErrInvalidToken = errors.New("invalid jwt")
+
+const (
+	// keysMaxAge is how long keys fetched from a URL are used before they
+	// are fetched again, so that removed keys stop being accepted.
+	keysMaxAge = time.Hour
+	// minRefetchInterval limits refetches caused by unknown key ids, which
+	// anyone can put into a token.
+	minRefetchInterval = time.Minute
+	maxJWKSSize        = 1 << 20
+)
+
+// Config describes where the keys come from and which tokens to accept.
+type Config struct {
+	// JWKS is a file path or an http(s) URL.
+	JWKS string
+	// Issuer and Audience are checked against iss and aud when set.
+	Issuer   string
+	Audience string
+	// UserClaim holds the user id. Defaults to "sub".
+	UserClaim string
+	// Leeway allows for clock skew when checking exp and nbf.
+	Leeway time.Duration
+}
+
+// Verifier checks RS256 and ES256 signatures and the standard claims.
+// Keys loaded from a URL are refetched when they get old or when a token
+// names an unknown key id.
+type Verifier struct {
+	cfg    Config
+	client *http.Client
+	now    func() time.Time
+
+	mu        sync.RWMutex
+	keys      *KeySet
+	fetchedAt time.Time
+}
+
+// New loads the keys of cfg.JWKS and fails if they cannot be read.
+func New(ctx context.Context, cfg Config) (*Verifier, error) {
+	if cfg.UserClaim == "" {
+		cfg.UserClaim = "sub"
+	}
+	v := &Verifier{
+		cfg:    cfg,
+		client: &http.Client{Timeout: 10 * time.Second},
+		now:    time.Now,
+	}
+
+	keys, err := v.load(ctx)
+	if err != nil {
+		return nil, err
+	}
+	v.keys = keys
+	v.fetchedAt = v.now()
+	return v, nil
+}
+
+func (v *Verifier) remote() bool {
+	return strings.HasPrefix(v.cfg.JWKS, "http://") || strings.HasPrefix(v.cfg.JWKS, "https://")
+}
+
+func (v *Verifier) load(ctx context.Context) (*KeySet, error) {
+	if !v.remote() {
+		data, err := os.ReadFile(v.cfg.JWKS)
+		if err != nil {
+			return nil, fmt.Errorf("read jwks: %w", err)
+		}
+		return ParseJWKS(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, v.cfg.JWKS, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetch jwks: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetch jwks: status %d", resp.StatusCode)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxJWKSSize))
+	if err != nil {
+		return nil, fmt.Errorf("fetch jwks: %w", err)
+	}
+	return ParseJWKS(data)
+}
+
+// key returns the key with kid, refetching remote keys if needed. A failed
+// refetch keeps the old keys.
+func (v *Verifier) key(ctx context.Context, kid string) (crypto.PublicKey, bool) {
+	v.mu.RLock()
+	key, ok := v.keys.key(kid)
+	age := v.now().Sub(v.fetchedAt)
+	v.mu.RUnlock()
+
+	if !v.remote() || (ok && age < keysMaxAge) || (!ok && age < minRefetchInterval) {
+		return key, ok
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	// Another request may have refetched in the meantime.
+	if v.now().Sub(v.fetchedAt) >= minRefetchInterval {
+		keys, err := v.load(ctx)
+		if err != nil {
+			slog.WarnContext(ctx, "jwt: keeping old keys", slog.String("jwks", v.cfg.JWKS), slog.Any("error", err))
+		} else {
+			v.keys = keys
+		}
+		v.fetchedAt = v.now()
+	}
+	return v.keys.key(kid)
+}
+
+// UserID verifies raw and returns the user of its user claim. Errors
+// other than a failed context wrap ErrInvalidToken.
+func (v *Verifier) UserID(ctx context.Context, raw string) (domain.UserID, error) {
+	claims, err := v.verify(ctx, raw)
+	if err != nil {
+		return "", err
+	}
+
+	id, _ := claims[v.cfg.UserClaim].(string)
+	if strings.TrimSpace(id) == "" {
+		return "", fmt.Errorf("%w: claim %q is missing or not a string", ErrInvalidToken, v.cfg.UserClaim)
+	}
+	return domain.UserID(id), nil
+}
+
+func (v *Verifier) verify(ctx context.Context, raw string) (map[string]any, error) {
+	parts := strings.Split(raw, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("%w: not a compact jws", ErrInvalidToken)
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, fmt.Errorf("%w: header: %v", ErrInvalidToken, err)
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("%w: signature: %v", ErrInvalidToken, err)
+	}
+
+	key, ok := v.key(ctx, header.Kid)
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown key id %q", ErrInvalidToken, header.Kid)
+	}
+
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	// The algorithm must match the key type, so that a token cannot pick a
+	// weaker check than the key was meant for.
+	switch k := key.(type) {
+	case *rsa.PublicKey:
+		if header.Alg != "RS256" {
+			return nil, fmt.Errorf("%w: alg %q for an RSA key", ErrInvalidToken, header.Alg)
+		}
+		if err := rsa.VerifyPKCS1v15(k, crypto.SHA256, digest[:], sig); err != nil {
+			return nil, fmt.Errorf("%w: bad signature", ErrInvalidToken)
+		}
+	case *ecdsa.PublicKey:
+		if header.Alg != "ES256" {
+			return nil, fmt.Errorf("%w: alg %q for an EC key", ErrInvalidToken, header.Alg)
+		}
+		// JWS uses the fixed-size r || s encoding, not ASN.1.
+		if len(sig) != 64 {
+			return nil, fmt.Errorf("%w: bad signature", ErrInvalidToken)
+		}
+		r, s := new(big.Int).SetBytes(sig[:32]), new(big.Int).SetBytes(sig[32:])
+		if !ecdsa.Verify(k, digest[:], r, s) {
+			return nil, fmt.Errorf("%w: bad signature", ErrInvalidToken)
+		}
+	default:
+		return nil, fmt.Errorf("%w: unsupported key", ErrInvalidToken)
+	}
+
+	var claims map[string]any
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, fmt.Errorf("%w: claims: %v", ErrInvalidToken, err)
+	}
+	if err := v.checkClaims(claims); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+func (v *Verifier) checkClaims(claims map[string]any) error {
+	now := v.now()
+
+	exp, ok := claims["exp"].(float64)
+	if !ok {
+		return fmt.Errorf("%w: exp is required", ErrInvalidToken)
+	}
+	if now.After(time.Unix(int64(exp), 0).Add(v.cfg.Leeway)) {
+		return fmt.Errorf("%w: expired", ErrInvalidToken)
+	}
+	if nbf, ok := claims["nbf"].(float64); ok && now.Add(v.cfg.Leeway).Before(time.Unix(int64(nbf), 0)) {
+		return fmt.Errorf("%w: not valid yet", ErrInvalidToken)
+	}
+
+	if v.cfg.Issuer != "" {
+		if iss, _ := claims["iss"].(string); iss != v.cfg.Issuer {
+			return fmt.Errorf("%w: issuer %q", ErrInvalidToken, iss)
+		}
+	}
+	if v.cfg.Audience != "" && !hasAudience(claims["aud"], v.cfg.Audience) {
+		return fmt.Errorf("%w: audience does not include %q", ErrInvalidToken, v.cfg.Audience)
+	}
+	return nil
+}
+
+// hasAudience handles aud as either a string or an array of strings.
+func hasAudience(aud any, want string) bool {
+	switch a := aud.(type) {
+	case string:
+		return a == want
+	case []any:
+		for _, v := range a {
+			if s, ok := v.(string); ok && s == want {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func decodeSegment(seg string, v any) error {
+	data, err := base64.RawURLEncoding.DecodeString(seg)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
@@ -0,0 +1,221 @@
+package jwtauth_test
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/terps489/avito_tech_internship/internal/jwtauth"
+)
+
+var b64 = base64.RawURLEncoding
+
+func rsaJWK(kid string, k *rsa.PublicKey) map[string]string {
+	return map[string]string{
+		"kty": "RSA", "kid": kid, "use": "sig",
+		"n": b64.EncodeToString(k.N.Bytes()),
+		"e": b64.EncodeToString(big.NewInt(int64(k.E)).Bytes()),
+	}
+}
+
+func ecJWK(kid string, k *ecdsa.PublicKey) map[string]string {
+	point, err := k.Bytes()
+	if err != nil {
+		panic(err)
+	}
+	return map[string]string{
+		"kty": "EC", "kid": kid, "crv": "P-256",
+		"x": b64.EncodeToString(point[1:33]),
+		"y": b64.EncodeToString(point[33:]),
+	}
+}
+
+func jwks(t *testing.T, keys ...map[string]string) []byte {
+	t.Helper()
+	data, err := json.Marshal(map[string]any{"keys": keys})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+// sign builds a compact JWS of claims with key, which is an *rsa.PrivateKey
+// for RS256 or an *ecdsa.PrivateKey for ES256.
+func sign(t *testing.T, alg, kid string, key crypto.Signer, claims map[string]any) string {
+	t.Helper()
+
+	header, _ := json.Marshal(map[string]string{"alg": alg, "kid": kid, "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	input := b64.EncodeToString(header) + "." + b64.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(input))
+
+	var sig []byte
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		var err error
+		if sig, err = rsa.SignPKCS1v15(rand.Reader, k, crypto.SHA256, digest[:]); err != nil {
+			t.Fatal(err)
+		}
+	case *ecdsa.PrivateKey:
+		r, s, err := ecdsa.Sign(rand.Reader, k, digest[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		sig = make([]byte, 64)
+		r.FillBytes(sig[:32])
+		s.FillBytes(sig[32:])
+	}
+	return input + "." + b64.EncodeToString(sig)
+}
+
+func TestVerifier(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(path, jwks(t, rsaJWK("r1", &rsaKey.PublicKey), ecJWK("e1", &ecKey.PublicKey)), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	v, err := jwtauth.New(t.Context(), jwtauth.Config{
+		JWKS:      path,
+		Issuer:    "https://sso.example.com",
+		Audience:  "reviewer",
+		UserClaim: "preferred_username",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	claims := func(edit func(map[string]any)) map[string]any {
+		c := map[string]any{
+			"iss":                "https://sso.example.com",
+			"aud":                []string{"reviewer", "other"},
+			"exp":                time.Now().Add(time.Hour).Unix(),
+			"sub":                "0f8e1c",
+			"preferred_username": "u1",
+		}
+		if edit != nil {
+			edit(c)
+		}
+		return c
+	}
+
+	for _, tc := range []struct {
+		name  string
+		token string
+	}{
+		{"RS256", sign(t, "RS256", "r1", rsaKey, claims(nil))},
+		{"ES256", sign(t, "ES256", "e1", ecKey, claims(nil))},
+		{"single audience", sign(t, "RS256", "r1", rsaKey, claims(func(c map[string]any) { c["aud"] = "reviewer" }))},
+	} {
+		id, err := v.UserID(t.Context(), tc.token)
+		if err != nil || id != "u1" {
+			t.Errorf("%s: UserID() = %q, %v; want u1", tc.name, id, err)
+		}
+	}
+
+	// Swap the subject of a valid token, keeping its signature.
+	valid := strings.Split(sign(t, "RS256", "r1", rsaKey, claims(nil)), ".")
+	forged, _ := json.Marshal(claims(func(c map[string]any) { c["preferred_username"] = "u2" }))
+	tampered := valid[0] + "." + b64.EncodeToString(forged) + "." + valid[2]
+
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		name  string
+		token string
+	}{
+		{"garbage", "not-a-jwt"},
+		{"tampered payload", tampered},
+		{"foreign key", sign(t, "RS256", "r1", otherKey, claims(nil))},
+		{"unknown kid", sign(t, "RS256", "r9", rsaKey, claims(nil))},
+		{"alg does not match key", sign(t, "ES256", "r1", ecKey, claims(nil))},
+		{"expired", sign(t, "RS256", "r1", rsaKey, claims(func(c map[string]any) { c["exp"] = time.Now().Add(-time.Hour).Unix() }))},
+		{"no exp", sign(t, "RS256", "r1", rsaKey, claims(func(c map[string]any) { delete(c, "exp") }))},
+		{"not yet valid", sign(t, "RS256", "r1", rsaKey, claims(func(c map[string]any) { c["nbf"] = time.Now().Add(time.Hour).Unix() }))},
+		{"wrong issuer", sign(t, "RS256", "r1", rsaKey, claims(func(c map[string]any) { c["iss"] = "https://evil.example.com" }))},
+		{"wrong audience", sign(t, "RS256", "r1", rsaKey, claims(func(c map[string]any) { c["aud"] = "other" }))},
+		{"no user claim", sign(t, "RS256", "r1", rsaKey, claims(func(c map[string]any) { delete(c, "preferred_username") }))},
+	} {
+		if _, err := v.UserID(t.Context(), tc.token); !errors.Is(err, jwtauth.ErrInvalidToken) {
+			t.Errorf("%s: err = %v, want ErrInvalidToken", tc.name, err)
+		}
+	}
+}
+
+func TestVerifierRefetchesRotatedKeys(t *testing.T) {
+	oldKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	newKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		current atomic.Value
+		fetches atomic.Int32
+	)
+	current.Store(jwks(t, ecJWK("old", &oldKey.PublicKey)))
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		_, _ = w.Write(current.Load().([]byte))
+	}))
+	t.Cleanup(ts.Close)
+
+	v, err := jwtauth.New(t.Context(), jwtauth.Config{JWKS: ts.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims := map[string]any{"sub": "u1", "exp": time.Now().Add(time.Hour).Unix()}
+
+	current.Store(jwks(t, ecJWK("new", &newKey.PublicKey)))
+	// Right after the first fetch an unknown kid does not cause another one.
+	if _, err := v.UserID(t.Context(), sign(t, "ES256", "new", newKey, claims)); !errors.Is(err, jwtauth.ErrInvalidToken) {
+		t.Errorf("new key right after startup: err = %v, want ErrInvalidToken", err)
+	}
+	if n := fetches.Load(); n != 1 {
+		t.Errorf("fetches = %d, want 1", n)
+	}
+	if id, err := v.UserID(t.Context(), sign(t, "ES256", "old", oldKey, claims)); err != nil || id != "u1" {
+		t.Errorf("old key: UserID() = %q, %v", id, err)
+	}
+
+	// A minute later it does, and picks up the rotated key.
+	jwtauth.SetNow(v, func() time.Time { return time.Now().Add(2 * time.Minute) })
+	if id, err := v.UserID(t.Context(), sign(t, "ES256", "new", newKey, claims)); err != nil || id != "u1" {
+		t.Errorf("new key after a minute: UserID() = %q, %v", id, err)
+	}
+	if n := fetches.Load(); n != 2 {
+		t.Errorf("fetches = %d, want 2", n)
+	}
+	if _, err := v.UserID(t.Context(), sign(t, "ES256", "old", oldKey, claims)); !errors.Is(err, jwtauth.ErrInvalidToken) {
+		t.Errorf("removed key: err = %v, want ErrInvalidToken", err)
+	}
+}
@@ -0,0 +1,316 @@
+// Package metrics implements the part of the Prometheus text exposition
+// format the service needs: labelled counters and histograms updated in
+// process, and values collected at scrape time.
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Type is the Prometheus metric type.
+type Type string
+
+const (
+	TypeCounter   Type = "counter"
+	TypeGauge     Type = "gauge"
+	TypeHistogram Type = "histogram"
+)
+
+// DefaultBuckets are latency buckets in seconds, as in the Prometheus
+// client libraries.
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// Sample is one value of a collected metric.
+type Sample struct {
+	LabelValues []string
+	Value       float64
+}
+
+// CollectFunc returns the current samples of a metric at scrape time.
+type CollectFunc func(ctx context.Context) ([]Sample, error)
+
+type collector interface {
+	write(ctx context.Context, w *bytes.Buffer) error
+}
+
+// Registry holds metrics and renders them for /metrics.
+type Registry struct {
+	mu         sync.Mutex
+	collectors []collector
+}
+
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+func (r *Registry) register(c collector) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.collectors = append(r.collectors, c)
+}
+
+// NewCounterVec registers a counter with the given label names.
+func (r *Registry) NewCounterVec(name, help string, labels ...string) *CounterVec {
+	c := &CounterVec{desc: desc{name, help, labels}, values: make(map[string]*counterSeries)}
+	r.register(c)
+	return c
+}
+
+// NewHistogramVec registers a histogram with the given upper bounds,
+// which must be sorted, and label names.
+func (r *Registry) NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	h := &HistogramVec{desc: desc{name, help, labels}, buckets: buckets, values: make(map[string]*histogramSeries)}
+	r.register(h)
+	return h
+}
+
+// NewFunc registers a metric whose samples fn computes on every scrape.
+func (r *Registry) NewFunc(name, help string, typ Type, labels []string, fn CollectFunc) {
+	r.register(&funcCollector{desc: desc{name, help, labels}, typ: typ, fn: fn})
+}
+
+// WriteTo renders all metrics in registration order. A failing collector
+// is skipped so that one broken source does not hide the others; the
+// first such error is returned.
+func (r *Registry) WriteTo(ctx context.Context, w io.Writer) error {
+	r.mu.Lock()
+	collectors := slices.Clone(r.collectors)
+	r.mu.Unlock()
+
+	var (
+		buf      bytes.Buffer
+		firstErr error
+	)
+	for _, c := range collectors {
+		if err := c.write(ctx, &buf); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	if _, err := buf.WriteTo(w); err != nil {
+		return err
+	}
+	return firstErr
+}
+
+// Handler serves the registry in the text exposition format.
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		_ = r.WriteTo(req.Context(), w)
+	})
+}
+
+// ---------- Counter ----------
+
+type CounterVec struct {
+	desc
+
+	mu     sync.Mutex
+	values map[string]*counterSeries
+}
+
+type counterSeries struct {
+	labelValues []string
+	value       float64
+}
+
+// Add increases the series with the given label values by v.
+func (c *CounterVec) Add(v float64, labelValues ...string) {
+	key := c.key(labelValues)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	s, ok := c.values[key]
+	if !ok {
+		s = &counterSeries{labelValues: slices.Clone(labelValues)}
+		c.values[key] = s
+	}
+	s.value += v
+}
+
+// Inc increases the series with the given label values by one.
+func (c *CounterVec) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+func (c *CounterVec) write(_ context.Context, w *bytes.Buffer) error {
+	c.mu.Lock()
+	samples := make([]Sample, 0, len(c.values))
+	for _, s := range c.values {
+		samples = append(samples, Sample{LabelValues: s.labelValues, Value: s.value})
+	}
+	c.mu.Unlock()
+
+	c.header(w, TypeCounter)
+	c.samples(w, "", samples)
+	return nil
+}
+
+// ---------- Histogram ----------
+
+type HistogramVec struct {
+	desc
+	buckets []float64
+
+	mu     sync.Mutex
+	values map[string]*histogramSeries
+}
+
+type histogramSeries struct {
+	labelValues []string
+	counts      []uint64
+	count       uint64
+	sum         float64
+}
+
+// Observe records v in the series with the given label values.
+func (h *HistogramVec) Observe(v float64, labelValues ...string) {
+	key := h.key(labelValues)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	s, ok := h.values[key]
+	if !ok {
+		s = &histogramSeries{
+			labelValues: slices.Clone(labelValues),
+			counts:      make([]uint64, len(h.buckets)),
+		}
+		h.values[key] = s
+	}
+	for i, le := range h.buckets {
+		if v <= le {
+			s.counts[i]++
+		}
+	}
+	s.count++
+	s.sum += v
+}
+
+func (h *HistogramVec) write(_ context.Context, w *bytes.Buffer) error {
+	h.mu.Lock()
+	series := make([]histogramSeries, 0, len(h.values))
+	for _, s := range h.values {
+		series = append(series, histogramSeries{
+			labelValues: s.labelValues,
+			counts:      slices.Clone(s.counts),
+			count:       s.count,
+			sum:         s.sum,
+		})
+	}
+	h.mu.Unlock()
+
+	slices.SortFunc(series, func(a, b histogramSeries) int {
+		return slices.Compare(a.labelValues, b.labelValues)
+	})
+
+	h.header(w, TypeHistogram)
+	names := append(slices.Clone(h.labels), "le")
+	for _, s := range series {
+		for i, le := range h.buckets {
+			writeSample(w, h.name+"_bucket", names, append(slices.Clone(s.labelValues), formatFloat(le)), float64(s.counts[i]))
+		}
+		writeSample(w, h.name+"_bucket", names, append(slices.Clone(s.labelValues), "+Inf"), float64(s.count))
+		writeSample(w, h.name+"_sum", h.labels, s.labelValues, s.sum)
+		writeSample(w, h.name+"_count", h.labels, s.labelValues, float64(s.count))
+	}
+	return nil
+}
+
+// ---------- Collected at scrape time ----------
+
+type funcCollector struct {
+	desc
+	typ Type
+	fn  CollectFunc
+}
+
+func (f *funcCollector) write(ctx context.Context, w *bytes.Buffer) error {
+	samples, err := f.fn(ctx)
+	if err != nil {
+		return fmt.Errorf("collect %s: %w", f.name, err)
+	}
+
+	f.header(w, f.typ)
+	f.samples(w, "", samples)
+	return nil
+}
+
+// ---------- Rendering ----------
+
+type desc struct {
+	name   string
+	help   string
+	labels []string
+}
+
+func (d desc) key(labelValues []string) string {
+	if len(labelValues) != len(d.labels) {
+		panic(fmt.Sprintf("metrics: %s expects %d label values, got %d", d.name, len(d.labels), len(labelValues)))
+	}
+	return strings.Join(labelValues, "\xff")
+}
+
+func (d desc) header(w *bytes.Buffer, typ Type) {
+	fmt.Fprintf(w, "# HELP %s %s\n", d.name, escapeHelp(d.help))
+	fmt.Fprintf(w, "# TYPE %s %s\n", d.name, typ)
+}
+
+func (d desc) samples(w *bytes.Buffer, suffix string, samples []Sample) {
+	slices.SortFunc(samples, func(a, b Sample) int {
+		return slices.Compare(a.LabelValues, b.LabelValues)
+	})
+	for _, s := range samples {
+		writeSample(w, d.name+suffix, d.labels, s.LabelValues, s.Value)
+	}
+}
+
+func writeSample(w *bytes.Buffer, name string, labels, values []string, v float64) {
+	w.WriteString(name)
+	if len(labels) > 0 {
+		w.WriteByte('{')
+		for i, l := range labels {
+			if i > 0 {
+				w.WriteByte(',')
+			}
+			w.WriteString(l)
+			w.WriteString(`="`)
+			w.WriteString(escapeLabel(values[i]))
+			w.WriteByte('"')
+		}
+		w.WriteByte('}')
+	}
+	w.WriteByte(' ')
+	w.WriteString(formatFloat(v))
+	w.WriteByte('\n')
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+var (
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+func escapeHelp(s string) string  { return helpEscaper.Replace(s) }
+func escapeLabel(s string) string { return labelEscaper.Replace(s) }
@@ -0,0 +1,62 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestRegistryWriteTo(t *testing.T) {
+	reg := NewRegistry()
+
+	requests := reg.NewCounterVec("requests_total", "Requests.", "route")
+	requests.Inc("/b")
+	requests.Inc("/a")
+	requests.Add(2, "/b")
+
+	latency := reg.NewHistogramVec("latency_seconds", "Latency.", []float64{0.1, 1}, "route")
+	latency.Observe(0.05, "/a")
+	latency.Observe(0.5, "/a")
+	latency.Observe(3, "/a")
+
+	reg.NewFunc("broken", "Fails.", TypeGauge, nil, func(context.Context) ([]Sample, error) {
+		return nil, errors.New("boom")
+	})
+	reg.NewFunc("teams", "Teams.", TypeGauge, []string{"team"}, func(context.Context) ([]Sample, error) {
+		return []Sample{{LabelValues: []string{`a"b\c`}, Value: 1.5}}, nil
+	})
+
+	var sb strings.Builder
+	if err := reg.WriteTo(context.Background(), &sb); err == nil {
+		t.Error("WriteTo: expected the collector error")
+	}
+
+	want := `# HELP requests_total Requests.
+# TYPE requests_total counter
+requests_total{route="/a"} 1
+requests_total{route="/b"} 3
+# HELP latency_seconds Latency.
+# TYPE latency_seconds histogram
+latency_seconds_bucket{route="/a",le="0.1"} 1
+latency_seconds_bucket{route="/a",le="1"} 2
+latency_seconds_bucket{route="/a",le="+Inf"} 3
+latency_seconds_sum{route="/a"} 3.55
+latency_seconds_count{route="/a"} 3
+# HELP teams Teams.
+# TYPE teams gauge
+teams{team="a\"b\\c"} 1.5
+`
+	if got := sb.String(); got != want {
+		t.Errorf("WriteTo output:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestLabelCountMismatchPanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("expected a panic")
+		}
+	}()
+	NewRegistry().NewCounterVec("c", "C.", "a", "b").Inc("only-one")
+}
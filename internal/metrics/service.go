@@ -0,0 +1,84 @@
+package metrics
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/terps489/avito_tech_internship/internal/domain"
+)
+
+// Business counts review events per team; it implements app.Metrics.
+type Business struct {
+	created     *CounterVec
+	merged      *CounterVec
+	reassigned  *CounterVec
+	noCandidate *CounterVec
+}
+
+func NewBusiness(r *Registry) *Business {
+	return &Business{
+		created: r.NewCounterVec("pull_requests_created_total",
+			"Pull requests created, by the author's team.", "team"),
+		merged: r.NewCounterVec("pull_requests_merged_total",
+			"Pull requests merged, by the author's team.", "team"),
+		reassigned: r.NewCounterVec("reviewer_reassignments_total",
+			"Reviewers replaced on a pull request, by the reviewer's team.", "team"),
+		noCandidate: r.NewCounterVec("reviewer_no_candidate_total",
+			"Reassignments that failed with NO_CANDIDATE, by the reviewer's team.", "team"),
+	}
+}
+
+func (b *Business) PullRequestCreated(team domain.TeamName) { b.created.Inc(string(team)) }
+func (b *Business) PullRequestMerged(team domain.TeamName)  { b.merged.Inc(string(team)) }
+func (b *Business) ReviewerReassigned(team domain.TeamName) { b.reassigned.Inc(string(team)) }
+func (b *Business) NoCandidate(team domain.TeamName)        { b.noCandidate.Inc(string(team)) }
+
+// AssignmentStatsSource is satisfied by app.Service.
+type AssignmentStatsSource interface {
+	GetReviewerAssignmentStats(ctx context.Context, filter domain.AssignmentStatsFilter) ([]domain.ReviewerAssignmentStat, error)
+}
+
+// RegisterOpenReviews adds a gauge of open review assignments per
+// reviewer's team, queried on every scrape.
+func RegisterOpenReviews(r *Registry, src AssignmentStatsSource) {
+	r.NewFunc("reviewer_open_reviews", "Reviewer assignments on open pull requests, by the reviewer's team.",
+		TypeGauge, []string{"team"}, func(ctx context.Context) ([]Sample, error) {
+			stats, err := src.GetReviewerAssignmentStats(ctx, domain.AssignmentStatsFilter{GroupBy: domain.GroupByTeam})
+			if err != nil {
+				return nil, err
+			}
+			samples := make([]Sample, 0, len(stats))
+			for _, st := range stats {
+				samples = append(samples, Sample{LabelValues: []string{st.Key}, Value: float64(st.Open)})
+			}
+			return samples, nil
+		})
+}
+
+// RegisterDBStats exposes the connection pool statistics of db.
+func RegisterDBStats(r *Registry, db *sql.DB) {
+	stat := func(name, help string, typ Type, value func(sql.DBStats) float64) {
+		r.NewFunc(name, help, typ, nil, func(context.Context) ([]Sample, error) {
+			return []Sample{{Value: value(db.Stats())}}, nil
+		})
+	}
+
+	stat("db_max_open_connections", "Maximum number of open connections to the database.", TypeGauge,
+		func(s sql.DBStats) float64 { return float64(s.MaxOpenConnections) })
+	stat("db_open_connections", "Established connections, in use and idle.", TypeGauge,
+		func(s sql.DBStats) float64 { return float64(s.OpenConnections) })
+	stat("db_in_use_connections", "Connections currently in use.", TypeGauge,
+		func(s sql.DBStats) float64 { return float64(s.InUse) })
+	stat("db_idle_connections", "Idle connections.", TypeGauge,
+		func(s sql.DBStats) float64 { return float64(s.Idle) })
+	stat("db_wait_count_total", "Connections waited for.", TypeCounter,
+		func(s sql.DBStats) float64 { return float64(s.WaitCount) })
+	stat("db_wait_duration_seconds_total", "Time spent waiting for new connections.", TypeCounter,
+		func(s sql.DBStats) float64 { return s.WaitDuration.Seconds() })
+	stat("db_max_idle_closed_total", "Connections closed due to SetMaxIdleConns.", TypeCounter,
+		func(s sql.DBStats) float64 { return float64(s.MaxIdleClosed) })
+	stat("db_max_idle_time_closed_total", "Connections closed due to SetConnMaxIdleTime.", TypeCounter,
+		func(s sql.DBStats) float64 { return float64(s.MaxIdleTimeClosed) })
+	stat("db_max_lifetime_closed_total", "Connections closed due to SetConnMaxLifetime.", TypeCounter,
+		func(s sql.DBStats) float64 { return float64(s.MaxLifetimeClosed) })
+}
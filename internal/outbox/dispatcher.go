@@ -0,0 +1,202 @@
+// Package outbox delivers domain events persisted by app.Service to sinks.
+//
+// Delivery is at least once: a sink may see an event again if the process
+// stops between delivering it and recording the delivery, so sinks should
+// deduplicate by event id. Successful deliveries are recorded per sink and
+// never repeated, and a claimed event is leased so concurrent dispatchers
+// do not process it at the same time.
+package outbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/terps489/avito_tech_internship/internal/domain"
+)
+
+// Store is the dispatcher side of the outbox.
+type Store interface {
+	// ClaimPending leases up to limit pending events that are due and
+	// increments their attempt counter. A leased event is not returned
+	// again until the lease expires or the event is marked.
+	ClaimPending(ctx context.Context, limit int, lease time.Duration) ([]domain.OutboxEvent, error)
+	// DeliveredSinks lists the sinks that already received the event.
+	DeliveredSinks(ctx context.Context, eventID int64) ([]string, error)
+	// MarkDelivered records that sink received the event. It is idempotent.
+	MarkDelivered(ctx context.Context, eventID int64, sink string) error
+	// MarkProcessed completes the event once every sink received it.
+	MarkProcessed(ctx context.Context, eventID int64) error
+	// MarkFailed schedules a retry at retryAt, or gives up if dead is set.
+	MarkFailed(ctx context.Context, eventID int64, retryAt time.Time, lastErr string, dead bool) error
+}
+
+// Sink receives events. Names identify sinks in the delivery bookkeeping
+// and must stay stable across restarts.
+type Sink interface {
+	Name() string
+	Deliver(ctx context.Context, e domain.OutboxEvent) error
+}
+
+type Config struct {
+	PollInterval time.Duration
+	BatchSize    int
+	// Lease is how long a claimed event stays hidden from other dispatchers.
+	Lease time.Duration
+	// MaxAttempts is the number of attempts after which an event is dead.
+	MaxAttempts int
+	BaseBackoff time.Duration
+	MaxBackoff  time.Duration
+}
+
+func DefaultConfig() Config {
+	return Config{
+		PollInterval: time.Second,
+		BatchSize:    100,
+		Lease:        30 * time.Second,
+		MaxAttempts:  10,
+		BaseBackoff:  time.Second,
+		MaxBackoff:   10 * time.Minute,
+	}
+}
+
+type Dispatcher struct {
+	store Store
+	sinks []Sink
+	cfg   Config
+	now   func() time.Time
+}
+
+func NewDispatcher(store Store, sinks []Sink, cfg Config) (*Dispatcher, error) {
+	seen := make(map[string]struct{}, len(sinks))
+	for _, s := range sinks {
+		if _, dup := seen[s.Name()]; dup {
+			return nil, fmt.Errorf("outbox: duplicate sink name %q", s.Name())
+		}
+		seen[s.Name()] = struct{}{}
+	}
+
+	return &Dispatcher{
+		store: store,
+		sinks: sinks,
+		cfg:   cfg,
+		now:   time.Now,
+	}, nil
+}
+
+// Run dispatches events until ctx is canceled.
+func (d *Dispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		n, err := d.DispatchOnce(ctx)
+		if err != nil && !errors.Is(err, context.Canceled) {
+			slog.ErrorContext(ctx, "outbox: dispatch failed", slog.Any("error", err))
+		}
+
+		// A full batch means there is probably more work waiting.
+		if err == nil && n == d.cfg.BatchSize {
+			if ctx.Err() != nil {
+				return
+			}
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// DispatchOnce claims one batch of events and delivers it.
+// It returns the number of claimed events.
+func (d *Dispatcher) DispatchOnce(ctx context.Context) (int, error) {
+	events, err := d.store.ClaimPending(ctx, d.cfg.BatchSize, d.cfg.Lease)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, e := range events {
+		if err := d.dispatch(ctx, e); err != nil {
+			return len(events), err
+		}
+	}
+
+	return len(events), nil
+}
+
+func (d *Dispatcher) dispatch(ctx context.Context, e domain.OutboxEvent) error {
+	delivered, err := d.store.DeliveredSinks(ctx, e.ID)
+	if err != nil {
+		return err
+	}
+
+	done := make(map[string]struct{}, len(delivered))
+	for _, name := range delivered {
+		done[name] = struct{}{}
+	}
+
+	var errs []error
+	for _, sink := range d.sinks {
+		if _, ok := done[sink.Name()]; ok {
+			continue
+		}
+
+		if err := sink.Deliver(ctx, e); err != nil {
+			slog.WarnContext(ctx, "outbox: delivery failed",
+				slog.Int64("event_id", e.ID),
+				slog.String("event_type", string(e.Type)),
+				slog.String("request_id", e.RequestID),
+				slog.String("sink", sink.Name()),
+				slog.Int("attempt", e.Attempts),
+				slog.Any("error", err),
+			)
+			errs = append(errs, fmt.Errorf("%s: %w", sink.Name(), err))
+			continue
+		}
+
+		if err := d.store.MarkDelivered(ctx, e.ID, sink.Name()); err != nil {
+			return err
+		}
+	}
+
+	if len(errs) == 0 {
+		return d.store.MarkProcessed(ctx, e.ID)
+	}
+
+	deliveryErr := errors.Join(errs...)
+	dead := e.Attempts >= d.cfg.MaxAttempts
+	if dead {
+		slog.ErrorContext(ctx, "outbox: event dropped",
+			slog.Int64("event_id", e.ID),
+			slog.String("event_type", string(e.Type)),
+			slog.String("request_id", e.RequestID),
+			slog.Int("attempts", e.Attempts),
+			slog.Any("error", deliveryErr),
+		)
+	}
+
+	retryAt := d.now().Add(Backoff(e.Attempts, d.cfg.BaseBackoff, d.cfg.MaxBackoff))
+	return d.store.MarkFailed(ctx, e.ID, retryAt, deliveryErr.Error(), dead)
+}
+
+// Backoff returns the exponential delay before the attempt following
+// attempt number attempt (starting at 1): base, 2*base, 4*base... up to max.
+func Backoff(attempt int, base, max time.Duration) time.Duration {
+	delay := base
+	for i := 1; i < attempt; i++ {
+		delay *= 2
+		if delay >= max {
+			return max
+		}
+	}
+	if delay > max {
+		return max
+	}
+	return delay
+}
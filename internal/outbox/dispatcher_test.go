@@ -0,0 +1,129 @@
+package outbox_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/terps489/avito_tech_internship/internal/domain"
+	"github.com/terps489/avito_tech_internship/internal/outbox"
+	"github.com/terps489/avito_tech_internship/internal/repository/memory"
+)
+
+type recordingSink struct {
+	name     string
+	failures int
+	got      []int64
+}
+
+func (s *recordingSink) Name() string { return s.name }
+
+func (s *recordingSink) Deliver(_ context.Context, e domain.OutboxEvent) error {
+	if s.failures > 0 {
+		s.failures--
+		return errors.New("unavailable")
+	}
+	s.got = append(s.got, e.ID)
+	return nil
+}
+
+func newTestDispatcher(t *testing.T, repo *memory.OutboxRepository, sinks ...outbox.Sink) *outbox.Dispatcher {
+	t.Helper()
+	cfg := outbox.DefaultConfig()
+	cfg.MaxAttempts = 3
+	cfg.BaseBackoff = 0
+	cfg.MaxBackoff = 0
+
+	d, err := outbox.NewDispatcher(repo, sinks, cfg)
+	if err != nil {
+		t.Fatalf("NewDispatcher: %v", err)
+	}
+	return d
+}
+
+func dispatch(t *testing.T, d *outbox.Dispatcher) int {
+	t.Helper()
+	n, err := d.DispatchOnce(t.Context())
+	if err != nil {
+		t.Fatalf("DispatchOnce: %v", err)
+	}
+	return n
+}
+
+func TestDispatcherRetriesOnlyFailedSinks(t *testing.T) {
+	repo := memory.NewOutboxRepository(memory.NewStore())
+	e := domain.OutboxEvent{Type: domain.EventPRMerged, Payload: []byte(`{}`)}
+	if err := repo.Append(t.Context(), &e); err != nil {
+		t.Fatal(err)
+	}
+
+	stable := &recordingSink{name: "stable"}
+	flaky := &recordingSink{name: "flaky", failures: 1}
+	d := newTestDispatcher(t, repo, stable, flaky)
+
+	if n := dispatch(t, d); n != 1 {
+		t.Fatalf("first dispatch claimed %d events, want 1", n)
+	}
+	if n := dispatch(t, d); n != 1 {
+		t.Fatalf("retry claimed %d events, want 1", n)
+	}
+	if n := dispatch(t, d); n != 0 {
+		t.Fatalf("dispatch after success claimed %d events, want 0", n)
+	}
+
+	if len(stable.got) != 1 || len(flaky.got) != 1 {
+		t.Fatalf("deliveries: stable=%v flaky=%v, want exactly one each", stable.got, flaky.got)
+	}
+}
+
+func TestDispatcherGivesUpAfterMaxAttempts(t *testing.T) {
+	repo := memory.NewOutboxRepository(memory.NewStore())
+	e := domain.OutboxEvent{Type: domain.EventPRMerged, Payload: []byte(`{}`)}
+	if err := repo.Append(t.Context(), &e); err != nil {
+		t.Fatal(err)
+	}
+
+	broken := &recordingSink{name: "broken", failures: 100}
+	d := newTestDispatcher(t, repo, broken)
+
+	for i := 0; i < 3; i++ {
+		if n := dispatch(t, d); n != 1 {
+			t.Fatalf("attempt %d claimed %d events, want 1", i+1, n)
+		}
+	}
+	if n := dispatch(t, d); n != 0 {
+		t.Fatalf("dead event was claimed again")
+	}
+	if broken.failures != 97 {
+		t.Fatalf("sink called %d times, want 3", 100-broken.failures)
+	}
+}
+
+func TestNewDispatcherRejectsDuplicateSinks(t *testing.T) {
+	repo := memory.NewOutboxRepository(memory.NewStore())
+	_, err := outbox.NewDispatcher(repo, []outbox.Sink{
+		&recordingSink{name: "a"},
+		&recordingSink{name: "a"},
+	}, outbox.DefaultConfig())
+	if err == nil {
+		t.Fatal("NewDispatcher accepted duplicate sink names")
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{1, time.Second},
+		{2, 2 * time.Second},
+		{4, 8 * time.Second},
+		{10, time.Minute},
+	}
+	for _, tt := range tests {
+		if got := outbox.Backoff(tt.attempt, time.Second, time.Minute); got != tt.want {
+			t.Errorf("Backoff(%d) = %v, want %v", tt.attempt, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package outbox
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/terps489/avito_tech_internship/internal/domain"
+)
+
+// InProcessSink hands events to a function in the same process.
+type InProcessSink struct {
+	name string
+	fn   func(ctx context.Context, e domain.OutboxEvent) error
+}
+
+func NewInProcessSink(name string, fn func(ctx context.Context, e domain.OutboxEvent) error) *InProcessSink {
+	return &InProcessSink{name: name, fn: fn}
+}
+
+func (s *InProcessSink) Name() string {
+	return s.name
+}
+
+func (s *InProcessSink) Deliver(ctx context.Context, e domain.OutboxEvent) error {
+	return s.fn(ctx, e)
+}
+
+// Envelope is the JSON document posted by WebhookSink.
+type Envelope struct {
+	ID        int64           `json:"id"`
+	Type      string          `json:"type"`
+	CreatedAt time.Time       `json:"created_at"`
+	RequestID string          `json:"request_id,omitempty"`
+	Payload   json.RawMessage `json:"payload"`
+}
+
+func NewEnvelope(e domain.OutboxEvent) Envelope {
+	return Envelope{
+		ID:        e.ID,
+		Type:      string(e.Type),
+		CreatedAt: e.CreatedAt.UTC(),
+		RequestID: e.RequestID,
+		Payload:   e.Payload,
+	}
+}
+
+// WebhookSink posts every event as an Envelope to a fixed URL.
+// Any response other than 2xx is a failed delivery.
+type WebhookSink struct {
+	name   string
+	url    string
+	client *http.Client
+}
+
+func NewWebhookSink(name, url string, timeout time.Duration) *WebhookSink {
+	return &WebhookSink{
+		name:   name,
+		url:    url,
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+func (s *WebhookSink) Name() string {
+	return s.name
+}
+
+func (s *WebhookSink) Deliver(ctx context.Context, e domain.OutboxEvent) error {
+	body, err := json.Marshal(NewEnvelope(e))
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Event-ID", strconv.FormatInt(e.ID, 10))
+	req.Header.Set("X-Event-Type", string(e.Type))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook responded with %s", resp.Status)
+	}
+
+	return nil
+}
@@ -0,0 +1,182 @@
+// Package pubsub fans review notifications out to live subscribers in the
+// same process. Nothing is persisted: a restart loses the buffered history,
+// and subscribers resuming with an id from a previous run are told to
+// reload their state.
+package pubsub
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/terps489/avito_tech_internship/internal/domain"
+)
+
+// Message is a published notification with its stream id.
+// Ids look like "<epoch>-<seq>" and grow within one hub.
+type Message struct {
+	ID           string
+	Notification domain.ReviewNotification
+}
+
+// Subscription receives messages for one user. C is closed when the
+// subscriber falls too far behind or the subscription is canceled.
+type Subscription struct {
+	C <-chan Message
+	// Replay holds buffered messages published after the requested
+	// Last-Event-ID, oldest first.
+	Replay []Message
+	// Reset is set when the requested id cannot be resumed from, because
+	// it belongs to another run or was evicted from the buffer.
+	Reset bool
+
+	hub    *Hub
+	userID domain.UserID
+	ch     chan Message
+}
+
+type entry struct {
+	seq uint64
+	msg Message
+}
+
+type Hub struct {
+	epoch   int64
+	bufSize int
+
+	mu     sync.Mutex
+	seq    uint64
+	buffer []entry
+	subs   map[domain.UserID]map[*Subscription]struct{}
+}
+
+// NewHub creates a hub that keeps the last bufferSize messages for resume.
+func NewHub(bufferSize int) *Hub {
+	return &Hub{
+		epoch:   time.Now().UnixNano(),
+		bufSize: bufferSize,
+		subs:    make(map[domain.UserID]map[*Subscription]struct{}),
+	}
+}
+
+// Publish implements app.Publisher. It never blocks: a subscriber whose
+// channel is full is dropped and has to reconnect.
+func (h *Hub) Publish(n domain.ReviewNotification) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.seq++
+	msg := Message{ID: h.formatID(h.seq), Notification: n}
+
+	if h.bufSize > 0 {
+		if len(h.buffer) == h.bufSize {
+			copy(h.buffer, h.buffer[1:])
+			h.buffer = h.buffer[:len(h.buffer)-1]
+		}
+		h.buffer = append(h.buffer, entry{seq: h.seq, msg: msg})
+	}
+
+	for sub := range h.subs[n.UserID] {
+		select {
+		case sub.ch <- msg:
+		default:
+			h.removeLocked(sub)
+		}
+	}
+}
+
+// Subscribe registers a subscriber for userID. lastEventID is the
+// Last-Event-ID sent by the client, or "" for a fresh stream.
+func (h *Hub) Subscribe(userID domain.UserID, lastEventID string, chanSize int) *Subscription {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	ch := make(chan Message, chanSize)
+	sub := &Subscription{
+		C:      ch,
+		hub:    h,
+		userID: userID,
+		ch:     ch,
+	}
+
+	if lastEventID != "" {
+		sub.Replay, sub.Reset = h.replayLocked(userID, lastEventID)
+	}
+
+	if h.subs[userID] == nil {
+		h.subs[userID] = make(map[*Subscription]struct{})
+	}
+	h.subs[userID][sub] = struct{}{}
+
+	return sub
+}
+
+// Close unregisters the subscription. It is safe to call more than once.
+func (s *Subscription) Close() {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+	s.hub.removeLocked(s)
+}
+
+func (h *Hub) removeLocked(sub *Subscription) {
+	subs, ok := h.subs[sub.userID]
+	if !ok {
+		return
+	}
+	if _, ok := subs[sub]; !ok {
+		return
+	}
+	delete(subs, sub)
+	if len(subs) == 0 {
+		delete(h.subs, sub.userID)
+	}
+	close(sub.ch)
+}
+
+func (h *Hub) replayLocked(userID domain.UserID, lastEventID string) ([]Message, bool) {
+	epoch, seq, ok := parseID(lastEventID)
+	if !ok || epoch != h.epoch || seq > h.seq {
+		return nil, true
+	}
+
+	// The buffer must still hold the message right after seq, otherwise
+	// something was evicted in between.
+	if seq < h.seq {
+		if len(h.buffer) == 0 {
+			return nil, true
+		}
+		if h.buffer[0].seq > seq+1 {
+			return nil, true
+		}
+	}
+
+	var replay []Message
+	for _, e := range h.buffer {
+		if e.seq > seq && e.msg.Notification.UserID == userID {
+			replay = append(replay, e.msg)
+		}
+	}
+	return replay, false
+}
+
+func (h *Hub) formatID(seq uint64) string {
+	return fmt.Sprintf("%d-%d", h.epoch, seq)
+}
+
+func parseID(id string) (int64, uint64, bool) {
+	epochStr, seqStr, found := strings.Cut(id, "-")
+	if !found {
+		return 0, 0, false
+	}
+	epoch, err := strconv.ParseInt(epochStr, 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	seq, err := strconv.ParseUint(seqStr, 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	return epoch, seq, true
+}
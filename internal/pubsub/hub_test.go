@@ -0,0 +1,96 @@
+package pubsub
+
+import (
+	"testing"
+
+	"github.com/terps489/avito_tech_internship/internal/domain"
+)
+
+func notify(user domain.UserID, pr domain.PullRequestID) domain.ReviewNotification {
+	return domain.ReviewNotification{
+		Type:          domain.ReviewAssigned,
+		UserID:        user,
+		PullRequestID: pr,
+	}
+}
+
+func TestHubDeliversOnlyToUser(t *testing.T) {
+	h := NewHub(16)
+	sub := h.Subscribe("u1", "", 4)
+	defer sub.Close()
+
+	h.Publish(notify("u2", "pr-1"))
+	h.Publish(notify("u1", "pr-2"))
+
+	msg := <-sub.C
+	if msg.Notification.PullRequestID != "pr-2" {
+		t.Fatalf("got %s, want pr-2", msg.Notification.PullRequestID)
+	}
+	select {
+	case msg := <-sub.C:
+		t.Fatalf("unexpected message %+v", msg)
+	default:
+	}
+}
+
+func TestHubReplay(t *testing.T) {
+	h := NewHub(16)
+	first := h.Subscribe("u1", "", 4)
+	h.Publish(notify("u1", "pr-1"))
+	last := (<-first.C).ID
+	first.Close()
+
+	h.Publish(notify("u1", "pr-2"))
+	h.Publish(notify("u2", "pr-3"))
+	h.Publish(notify("u1", "pr-4"))
+
+	sub := h.Subscribe("u1", last, 4)
+	defer sub.Close()
+
+	if sub.Reset {
+		t.Fatal("unexpected reset")
+	}
+	var got []domain.PullRequestID
+	for _, m := range sub.Replay {
+		got = append(got, m.Notification.PullRequestID)
+	}
+	if len(got) != 2 || got[0] != "pr-2" || got[1] != "pr-4" {
+		t.Fatalf("replay = %v, want [pr-2 pr-4]", got)
+	}
+}
+
+func TestHubResetOnEvictedOrForeignID(t *testing.T) {
+	h := NewHub(2)
+	sub := h.Subscribe("u1", "", 8)
+	h.Publish(notify("u1", "pr-1"))
+	first := (<-sub.C).ID
+	sub.Close()
+
+	h.Publish(notify("u1", "pr-2"))
+	h.Publish(notify("u1", "pr-3"))
+	h.Publish(notify("u1", "pr-4"))
+
+	if s := h.Subscribe("u1", first, 1); !s.Reset {
+		t.Fatal("evicted id: want reset")
+	}
+	if s := h.Subscribe("u1", "1-1", 1); !s.Reset {
+		t.Fatal("id from another epoch: want reset")
+	}
+	if s := h.Subscribe("u1", "garbage", 1); !s.Reset {
+		t.Fatal("malformed id: want reset")
+	}
+}
+
+func TestHubDropsSlowSubscriber(t *testing.T) {
+	h := NewHub(0)
+	sub := h.Subscribe("u1", "", 1)
+
+	h.Publish(notify("u1", "pr-1"))
+	h.Publish(notify("u1", "pr-2"))
+
+	<-sub.C
+	if _, ok := <-sub.C; ok {
+		t.Fatal("channel of slow subscriber must be closed")
+	}
+	sub.Close()
+}
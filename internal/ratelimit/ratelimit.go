@@ -0,0 +1,112 @@
+// Package ratelimit implements in-memory token buckets keyed by client.
+package ratelimit
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// sweepInterval is how often buckets that have refilled completely are
+// dropped; a full bucket behaves the same as a missing one.
+const sweepInterval = time.Minute
+
+// Limit allows Rate requests per second on average and bursts of up to
+// Burst requests. A Rate of zero or less means no limit.
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+func (l Limit) Unlimited() bool {
+	return l.Rate <= 0
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+	burst  float64
+	rate   float64
+}
+
+// Limiter keeps one bucket per key. It is safe for concurrent use.
+type Limiter struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+func New() *Limiter {
+	return &Limiter{
+		buckets: make(map[string]*bucket),
+		now:     time.Now,
+	}
+}
+
+// Allow takes a token from the bucket of key, which refills according to
+// lim. If the bucket is empty it returns false and how long to wait for
+// the next token.
+func (l *Limiter) Allow(key string, lim Limit) (bool, time.Duration) {
+	if lim.Unlimited() {
+		return true, 0
+	}
+	burst := float64(max(lim.Burst, 1))
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: burst, last: now}
+		l.buckets[key] = b
+	}
+	b.burst, b.rate = burst, lim.Rate
+	if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.tokens = math.Min(burst, b.tokens+elapsed.Seconds()*lim.Rate)
+		b.last = now
+	}
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+	wait := time.Duration((1 - b.tokens) / lim.Rate * float64(time.Second))
+	return false, wait
+}
+
+// Refund puts back a token that Allow took from the bucket of key, for a
+// request that turned out not to count against the limit.
+func (l *Limiter) Refund(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if b, ok := l.buckets[key]; ok {
+		b.tokens = math.Min(b.burst, b.tokens+1)
+	}
+}
+
+// Len returns the number of buckets, which includes full ones that have
+// not been swept yet.
+func (l *Limiter) Len() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return len(l.buckets)
+}
+
+func (l *Limiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < sweepInterval {
+		return
+	}
+	l.lastSweep = now
+
+	for key, b := range l.buckets {
+		refill := time.Duration(b.burst / b.rate * float64(time.Second))
+		if now.Sub(b.last) >= refill {
+			delete(l.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLimiter(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	l := New()
+	l.now = func() time.Time { return now }
+	lim := Limit{Rate: 2, Burst: 3}
+
+	for i := range 3 {
+		if ok, _ := l.Allow("a", lim); !ok {
+			t.Fatalf("request %d of the burst was refused", i+1)
+		}
+	}
+	ok, wait := l.Allow("a", lim)
+	if ok || wait != 500*time.Millisecond {
+		t.Errorf("over the burst: Allow() = %v, %v; want false, 500ms", ok, wait)
+	}
+	if ok, _ := l.Allow("b", lim); !ok {
+		t.Error("another key shares the bucket")
+	}
+
+	now = now.Add(500 * time.Millisecond)
+	if ok, _ := l.Allow("a", lim); !ok {
+		t.Error("refused after a token refilled")
+	}
+	if ok, _ := l.Allow("a", lim); ok {
+		t.Error("allowed a second request on one refilled token")
+	}
+
+	l.Refund("a")
+	if ok, _ := l.Allow("a", lim); !ok {
+		t.Error("refused after a refund")
+	}
+
+	if ok, _ := l.Allow("a", Limit{}); !ok {
+		t.Error("zero limit refused a request")
+	}
+
+	// Buckets that have refilled completely are dropped.
+	now = now.Add(2 * time.Minute)
+	l.Allow("c", lim)
+	if n := l.Len(); n != 1 {
+		t.Errorf("Len() after a sweep = %d, want 1", n)
+	}
+}
@@ -0,0 +1,106 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"time"
+
+	"github.com/terps489/avito_tech_internship/internal/domain"
+)
+
+type APITokenRepository struct {
+	store *Store
+}
+
+func NewAPITokenRepository(store *Store) *APITokenRepository {
+	return &APITokenRepository{store: store}
+}
+
+func (r *APITokenRepository) Create(ctx context.Context, t *domain.APIToken) error {
+	return r.store.write(ctx, func(d *state) error {
+		for _, existing := range d.apiTokens.m {
+			if existing.Hash == t.Hash {
+				return ErrDuplicateKey
+			}
+		}
+		if t.TeamName != "" {
+			if _, ok := d.teams.m[t.TeamName]; !ok {
+				return ErrForeignKey
+			}
+		}
+		if t.UserID != "" {
+			if _, ok := d.users.m[t.UserID]; !ok {
+				return ErrForeignKey
+			}
+		}
+
+		d.apiTokenSeq++
+		t.ID = d.apiTokenSeq
+		t.CreatedAt = r.store.now()
+		d.apiTokens.set(t.ID, *t)
+		return nil
+	})
+}
+
+func (r *APITokenRepository) GetByID(ctx context.Context, id int64) (*domain.APIToken, error) {
+	var (
+		t  domain.APIToken
+		ok bool
+	)
+	r.store.read(ctx, func(d *state) {
+		t, ok = d.apiTokens.m[id]
+	})
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+
+	return &t, nil
+}
+
+func (r *APITokenRepository) GetByHash(ctx context.Context, hash string) (*domain.APIToken, error) {
+	var (
+		t  domain.APIToken
+		ok bool
+	)
+	r.store.read(ctx, func(d *state) {
+		for _, existing := range d.apiTokens.m {
+			if existing.Hash == hash {
+				t, ok = existing, true
+				return
+			}
+		}
+	})
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+
+	return &t, nil
+}
+
+func (r *APITokenRepository) List(ctx context.Context) ([]domain.APIToken, error) {
+	var tokens []domain.APIToken
+	r.store.read(ctx, func(d *state) {
+		for _, t := range d.apiTokens.m {
+			tokens = append(tokens, t)
+		}
+	})
+
+	sort.Slice(tokens, func(i, j int) bool { return tokens[i].ID < tokens[j].ID })
+	return tokens, nil
+}
+
+func (r *APITokenRepository) Revoke(ctx context.Context, id int64, at time.Time) error {
+	return r.store.write(ctx, func(d *state) error {
+		t, ok := d.apiTokens.m[id]
+		if !ok {
+			return sql.ErrNoRows
+		}
+		if t.RevokedAt == nil {
+			at := at.UTC()
+			t.RevokedAt = &at
+			d.apiTokens.set(id, t)
+		}
+		return nil
+	})
+}
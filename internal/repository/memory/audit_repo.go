@@ -0,0 +1,57 @@
+package memory
+
+import (
+	"context"
+
+	"github.com/terps489/avito_tech_internship/internal/domain"
+)
+
+type AuditRepository struct {
+	store *Store
+}
+
+func NewAuditRepository(store *Store) *AuditRepository {
+	return &AuditRepository{store: store}
+}
+
+func (r *AuditRepository) Append(ctx context.Context, e *domain.AuditEvent) error {
+	return r.store.write(ctx, func(d *state) error {
+		d.auditSeq++
+		e.ID = d.auditSeq
+		e.CreatedAt = r.store.now()
+
+		stored := *e
+		stored.Before = append([]byte(nil), e.Before...)
+		stored.After = append([]byte(nil), e.After...)
+		d.audit = append(d.audit, stored)
+
+		return nil
+	})
+}
+
+func (r *AuditRepository) List(ctx context.Context, filter domain.AuditFilter) ([]domain.AuditEvent, error) {
+	var events []domain.AuditEvent
+	r.store.read(ctx, func(d *state) {
+		for i := len(d.audit) - 1; i >= 0; i-- {
+			e := d.audit[i]
+			if filter.EntityType != "" && e.EntityType != filter.EntityType {
+				continue
+			}
+			if filter.EntityID != "" && e.EntityID != filter.EntityID {
+				continue
+			}
+			if filter.Actor != "" && e.Actor != filter.Actor {
+				continue
+			}
+			if filter.BeforeID > 0 && e.ID >= filter.BeforeID {
+				continue
+			}
+			events = append(events, e)
+			if filter.Limit > 0 && len(events) == filter.Limit {
+				break
+			}
+		}
+	})
+
+	return events, nil
+}
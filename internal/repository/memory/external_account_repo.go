@@ -0,0 +1,86 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+
+	"github.com/terps489/avito_tech_internship/internal/domain"
+)
+
+type externalAccountKey struct {
+	provider domain.ExternalProvider
+	login    string
+}
+
+type ExternalAccountRepository struct {
+	store *Store
+}
+
+func NewExternalAccountRepository(store *Store) *ExternalAccountRepository {
+	return &ExternalAccountRepository{store: store}
+}
+
+func (r *ExternalAccountRepository) Set(ctx context.Context, a *domain.ExternalAccount) error {
+	return r.store.write(ctx, func(d *state) error {
+		if _, ok := d.users.m[a.UserID]; !ok {
+			return ErrForeignKey
+		}
+
+		key := externalAccountKey{provider: a.Provider, login: a.Login}
+		if existing, ok := d.externalAccounts.m[key]; ok {
+			a.CreatedAt = existing.CreatedAt
+		} else {
+			a.CreatedAt = r.store.now()
+		}
+
+		d.externalAccounts.set(key, *a)
+		return nil
+	})
+}
+
+func (r *ExternalAccountRepository) Get(ctx context.Context, provider domain.ExternalProvider, login string) (*domain.ExternalAccount, error) {
+	var (
+		a  domain.ExternalAccount
+		ok bool
+	)
+	r.store.read(ctx, func(d *state) {
+		a, ok = d.externalAccounts.m[externalAccountKey{provider: provider, login: login}]
+	})
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+
+	return &a, nil
+}
+
+func (r *ExternalAccountRepository) List(ctx context.Context, provider domain.ExternalProvider) ([]domain.ExternalAccount, error) {
+	var accounts []domain.ExternalAccount
+	r.store.read(ctx, func(d *state) {
+		for _, a := range d.externalAccounts.m {
+			if provider == "" || a.Provider == provider {
+				accounts = append(accounts, a)
+			}
+		}
+	})
+
+	sort.Slice(accounts, func(i, j int) bool {
+		if accounts[i].Provider != accounts[j].Provider {
+			return accounts[i].Provider < accounts[j].Provider
+		}
+		return accounts[i].Login < accounts[j].Login
+	})
+
+	return accounts, nil
+}
+
+func (r *ExternalAccountRepository) Delete(ctx context.Context, provider domain.ExternalProvider, login string) error {
+	return r.store.write(ctx, func(d *state) error {
+		key := externalAccountKey{provider: provider, login: login}
+		if _, ok := d.externalAccounts.m[key]; !ok {
+			return sql.ErrNoRows
+		}
+		d.externalAccounts.delete(key)
+		return nil
+	})
+}
@@ -0,0 +1,97 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"slices"
+	"time"
+
+	"github.com/terps489/avito_tech_internship/internal/domain"
+)
+
+type idempotencyKeyID struct {
+	scope string
+	key   string
+}
+
+type IdempotencyRepository struct {
+	store *Store
+}
+
+func NewIdempotencyRepository(store *Store) *IdempotencyRepository {
+	return &IdempotencyRepository{store: store}
+}
+
+func (r *IdempotencyRepository) Reserve(ctx context.Context, k *domain.IdempotencyKey) (bool, error) {
+	reserved := false
+	err := r.store.write(ctx, func(d *state) error {
+		id := idempotencyKeyID{k.Scope, k.Key}
+		if existing, ok := d.idempotencyKeys.m[id]; ok && existing.ExpiresAt.After(k.CreatedAt) {
+			return nil
+		}
+
+		d.idempotencyKeys.set(id, domain.IdempotencyKey{
+			Scope:       k.Scope,
+			Key:         k.Key,
+			RequestHash: k.RequestHash,
+			CreatedAt:   k.CreatedAt.UTC(),
+			ExpiresAt:   k.ExpiresAt.UTC(),
+		})
+		reserved = true
+		return nil
+	})
+	return reserved, err
+}
+
+func (r *IdempotencyRepository) Get(ctx context.Context, scope, key string) (*domain.IdempotencyKey, error) {
+	var (
+		k  domain.IdempotencyKey
+		ok bool
+	)
+	r.store.read(ctx, func(d *state) {
+		k, ok = d.idempotencyKeys.m[idempotencyKeyID{scope, key}]
+	})
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+
+	k.Body = slices.Clone(k.Body)
+	return &k, nil
+}
+
+func (r *IdempotencyRepository) Complete(ctx context.Context, scope, key string, status int, body []byte, expiresAt time.Time) error {
+	return r.store.write(ctx, func(d *state) error {
+		id := idempotencyKeyID{scope, key}
+		k, ok := d.idempotencyKeys.m[id]
+		if !ok {
+			return sql.ErrNoRows
+		}
+
+		k.Status = status
+		k.Body = slices.Clone(body)
+		k.ExpiresAt = expiresAt.UTC()
+		d.idempotencyKeys.set(id, k)
+		return nil
+	})
+}
+
+func (r *IdempotencyRepository) Delete(ctx context.Context, scope, key string) error {
+	return r.store.write(ctx, func(d *state) error {
+		d.idempotencyKeys.delete(idempotencyKeyID{scope, key})
+		return nil
+	})
+}
+
+func (r *IdempotencyRepository) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	var n int64
+	err := r.store.write(ctx, func(d *state) error {
+		for id, k := range d.idempotencyKeys.m {
+			if !k.ExpiresAt.After(now) {
+				d.idempotencyKeys.delete(id)
+				n++
+			}
+		}
+		return nil
+	})
+	return n, err
+}
@@ -0,0 +1,212 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"sync"
+	"time"
+
+	"github.com/terps489/avito_tech_internship/internal/domain"
+)
+
+// Errors mirroring the constraint violations PostgreSQL would report.
+var (
+	ErrDuplicateKey = errors.New("memory: duplicate key")
+	ErrForeignKey   = errors.New("memory: foreign key violation")
+)
+
+type state struct {
+	teams    table[domain.TeamName, domain.Team]
+	users    table[domain.UserID, domain.User]
+	prs      table[domain.PullRequestID, domain.PullRequest]
+	audit    []domain.AuditEvent
+	auditSeq int64
+
+	reviewerEvents   []domain.ReviewerEvent
+	reviewerEventSeq int64
+
+	outbox           table[int64, outboxRecord]
+	outboxSeq        int64
+	outboxDeliveries table[outboxDeliveryKey, struct{}]
+
+	webhooks           table[int64, domain.Webhook]
+	webhookSeq         int64
+	webhookDeliveries  table[int64, domain.WebhookDelivery]
+	webhookDeliverySeq int64
+
+	externalAccounts table[externalAccountKey, domain.ExternalAccount]
+
+	apiTokens   table[int64, domain.APIToken]
+	apiTokenSeq int64
+
+	idempotencyKeys table[idempotencyKeyID, domain.IdempotencyKey]
+}
+
+func newState() *state {
+	return &state{
+		teams:            newTable[domain.TeamName, domain.Team](),
+		users:            newTable[domain.UserID, domain.User](),
+		prs:              newTable[domain.PullRequestID, domain.PullRequest](),
+		outbox:           newTable[int64, outboxRecord](),
+		outboxDeliveries: newTable[outboxDeliveryKey, struct{}](),
+
+		webhooks:          newTable[int64, domain.Webhook](),
+		webhookDeliveries: newTable[int64, domain.WebhookDelivery](),
+
+		externalAccounts: newTable[externalAccountKey, domain.ExternalAccount](),
+
+		apiTokens: newTable[int64, domain.APIToken](),
+
+		idempotencyKeys: newTable[idempotencyKeyID, domain.IdempotencyKey](),
+	}
+}
+
+// clone returns a copy that can be modified without affecting s. Tables
+// are shared until their first write and the logs are appended to past
+// the capacity s sees, so cloning costs nothing per stored row.
+func (s *state) clone() *state {
+	c := *s
+	c.audit = s.audit[:len(s.audit):len(s.audit)]
+	c.reviewerEvents = s.reviewerEvents[:len(s.reviewerEvents):len(s.reviewerEvents)]
+	for _, t := range c.tables() {
+		t.share()
+	}
+	return &c
+}
+
+// commit is called on a clone that replaced the state it was made from.
+// Nothing refers to the tables of that state any more, so the tables
+// still shared with it need not be copied on their next write.
+func (s *state) commit() {
+	for _, t := range s.tables() {
+		t.own()
+	}
+}
+
+func (s *state) tables() []sharer {
+	return []sharer{
+		&s.teams, &s.users, &s.prs,
+		&s.outbox, &s.outboxDeliveries,
+		&s.webhooks, &s.webhookDeliveries,
+		&s.externalAccounts, &s.apiTokens, &s.idempotencyKeys,
+	}
+}
+
+type sharer interface {
+	share()
+	own()
+}
+
+// table is a map that a transaction copies on its first write to it, so
+// that a transaction costs as much as the tables it changes rather than
+// the whole state. Read m directly; change it only through set and
+// delete.
+type table[K comparable, V any] struct {
+	m      map[K]V
+	shared bool
+}
+
+func newTable[K comparable, V any]() table[K, V] {
+	return table[K, V]{m: make(map[K]V)}
+}
+
+func (t *table[K, V]) set(k K, v V) {
+	t.copyIfShared()
+	t.m[k] = v
+}
+
+func (t *table[K, V]) delete(k K) {
+	t.copyIfShared()
+	delete(t.m, k)
+}
+
+func (t *table[K, V]) copyIfShared() {
+	if t.shared {
+		t.m = maps.Clone(t.m)
+		t.shared = false
+	}
+}
+
+// share marks m as referenced by another state too.
+func (t *table[K, V]) share() { t.shared = true }
+
+// own marks m as referenced by this state only.
+func (t *table[K, V]) own() { t.shared = false }
+
+// Store holds the shared state of all in-memory repositories.
+// Repositories created from the same Store see each other's data.
+type Store struct {
+	mu   sync.RWMutex
+	data *state
+	now  func() time.Time
+}
+
+func NewStore() *Store {
+	return &Store{
+		data: newState(),
+		now:  func() time.Time { return time.Now().UTC() },
+	}
+}
+
+type txKey struct{}
+
+// WithinTx implements app.TxManager. The store is locked for the whole
+// transaction and fn works on a copy-on-write clone that replaces the
+// state on success.
+// Inside fn the store must only be used with the context passed to fn.
+func (s *Store) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(txKey{}).(*state); ok {
+		return fn(ctx)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data := s.data.clone()
+	if err := fn(context.WithValue(ctx, txKey{}, data)); err != nil {
+		return err
+	}
+
+	data.commit()
+	s.data = data
+	return nil
+}
+
+// read calls fn with the transaction state from ctx or, outside
+// of a transaction, with the committed state under a read lock.
+func (s *Store) read(ctx context.Context, fn func(d *state)) {
+	if d, ok := ctx.Value(txKey{}).(*state); ok {
+		fn(d)
+		return
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	fn(s.data)
+}
+
+// write is like read but takes the write lock. Outside of a transaction
+// fn must validate before changing anything, as there is no rollback.
+func (s *Store) write(ctx context.Context, fn func(d *state) error) error {
+	if d, ok := ctx.Value(txKey{}).(*state); ok {
+		return fn(d)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return fn(s.data)
+}
+
+// clonePullRequest copies the reviewer slice and merge time so callers
+// never share memory with the store.
+func clonePullRequest(pr domain.PullRequest) domain.PullRequest {
+	if pr.ReviewerIDs != nil {
+		pr.ReviewerIDs = append([]domain.UserID(nil), pr.ReviewerIDs...)
+	}
+	if pr.MergedAt != nil {
+		t := *pr.MergedAt
+		pr.MergedAt = &t
+	}
+	return pr
+}
@@ -0,0 +1,75 @@
+package memory_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/terps489/avito_tech_internship/internal/app"
+	"github.com/terps489/avito_tech_internship/internal/domain"
+	"github.com/terps489/avito_tech_internship/internal/repository/memory"
+	"github.com/terps489/avito_tech_internship/internal/repository/repotest"
+)
+
+func TestConformance(t *testing.T) {
+	repotest.Run(t, func(t *testing.T) app.Repositories {
+		store := memory.NewStore()
+		return app.Repositories{
+			Users:            memory.NewUserRepository(store),
+			Teams:            memory.NewTeamRepository(store),
+			PullRequests:     memory.NewPullRequestRepository(store),
+			Audit:            memory.NewAuditRepository(store),
+			ReviewerEvents:   memory.NewReviewerEventRepository(store),
+			Outbox:           memory.NewOutboxRepository(store),
+			Webhooks:         memory.NewWebhookRepository(store),
+			ExternalAccounts: memory.NewExternalAccountRepository(store),
+			APITokens:        memory.NewAPITokenRepository(store),
+			IdempotencyKeys:  memory.NewIdempotencyRepository(store),
+			Tx:               store,
+		}
+	})
+}
+
+// Transactions share tables with the committed state until they write
+// to them; a rollback after any mix of commits and plain writes must
+// leave the committed tables untouched.
+func TestTxCopyOnWrite(t *testing.T) {
+	ctx := t.Context()
+	store := memory.NewStore()
+	teams := memory.NewTeamRepository(store)
+	errAbort := errors.New("abort")
+
+	inTx := func(name domain.TeamName, fail bool) {
+		t.Helper()
+		err := store.WithinTx(ctx, func(ctx context.Context) error {
+			if err := teams.Create(ctx, name); err != nil {
+				return err
+			}
+			if fail {
+				return errAbort
+			}
+			return nil
+		})
+		if fail && !errors.Is(err, errAbort) || !fail && err != nil {
+			t.Fatalf("tx creating %s: err = %v", name, err)
+		}
+	}
+
+	inTx("a", false)
+	inTx("b", true)
+	inTx("c", false)
+	if err := teams.Create(ctx, "d"); err != nil {
+		t.Fatal(err)
+	}
+	inTx("e", true)
+
+	for name, want := range map[domain.TeamName]bool{"a": true, "b": false, "c": true, "d": true, "e": false} {
+		got, err := teams.Exists(ctx, name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("team %s exists = %v, want %v", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/terps489/avito_tech_internship/internal/domain"
+)
+
+const (
+	outboxPending   = "pending"
+	outboxProcessed = "processed"
+	outboxDead      = "dead"
+)
+
+type outboxRecord struct {
+	event         domain.OutboxEvent
+	status        string
+	nextAttemptAt time.Time
+	lastError     string
+}
+
+type outboxDeliveryKey struct {
+	eventID int64
+	sink    string
+}
+
+type OutboxRepository struct {
+	store *Store
+}
+
+func NewOutboxRepository(store *Store) *OutboxRepository {
+	return &OutboxRepository{store: store}
+}
+
+func (r *OutboxRepository) Append(ctx context.Context, e *domain.OutboxEvent) error {
+	return r.store.write(ctx, func(d *state) error {
+		d.outboxSeq++
+		e.ID = d.outboxSeq
+		e.CreatedAt = r.store.now()
+		e.Attempts = 0
+
+		stored := *e
+		stored.Payload = append([]byte(nil), e.Payload...)
+		d.outbox.set(e.ID, outboxRecord{
+			event:         stored,
+			status:        outboxPending,
+			nextAttemptAt: e.CreatedAt,
+		})
+
+		return nil
+	})
+}
+
+func (r *OutboxRepository) ClaimPending(ctx context.Context, limit int, lease time.Duration) ([]domain.OutboxEvent, error) {
+	var events []domain.OutboxEvent
+	err := r.store.write(ctx, func(d *state) error {
+		now := r.store.now()
+
+		ids := make([]int64, 0, len(d.outbox.m))
+		for id, rec := range d.outbox.m {
+			if rec.status == outboxPending && !rec.nextAttemptAt.After(now) {
+				ids = append(ids, id)
+			}
+		}
+		sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+		if len(ids) > limit {
+			ids = ids[:limit]
+		}
+
+		for _, id := range ids {
+			rec := d.outbox.m[id]
+			rec.event.Attempts++
+			rec.nextAttemptAt = now.Add(lease)
+			d.outbox.set(id, rec)
+			events = append(events, rec.event)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
+
+func (r *OutboxRepository) DeliveredSinks(ctx context.Context, eventID int64) ([]string, error) {
+	var sinks []string
+	r.store.read(ctx, func(d *state) {
+		for k := range d.outboxDeliveries.m {
+			if k.eventID == eventID {
+				sinks = append(sinks, k.sink)
+			}
+		}
+	})
+
+	sort.Strings(sinks)
+	return sinks, nil
+}
+
+func (r *OutboxRepository) MarkDelivered(ctx context.Context, eventID int64, sink string) error {
+	return r.store.write(ctx, func(d *state) error {
+		if _, ok := d.outbox.m[eventID]; !ok {
+			return ErrForeignKey
+		}
+		d.outboxDeliveries.set(outboxDeliveryKey{eventID: eventID, sink: sink}, struct{}{})
+		return nil
+	})
+}
+
+func (r *OutboxRepository) MarkProcessed(ctx context.Context, eventID int64) error {
+	return r.store.write(ctx, func(d *state) error {
+		rec, ok := d.outbox.m[eventID]
+		if !ok {
+			return nil
+		}
+		rec.status = outboxProcessed
+		rec.lastError = ""
+		d.outbox.set(eventID, rec)
+		return nil
+	})
+}
+
+func (r *OutboxRepository) MarkFailed(ctx context.Context, eventID int64, retryAt time.Time, lastErr string, dead bool) error {
+	return r.store.write(ctx, func(d *state) error {
+		rec, ok := d.outbox.m[eventID]
+		if !ok {
+			return nil
+		}
+		rec.status = outboxPending
+		if dead {
+			rec.status = outboxDead
+		}
+		rec.nextAttemptAt = retryAt
+		rec.lastError = lastErr
+		d.outbox.set(eventID, rec)
+		return nil
+	})
+}
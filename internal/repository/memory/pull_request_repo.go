@@ -0,0 +1,283 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/terps489/avito_tech_internship/internal/domain"
+)
+
+type PullRequestRepository struct {
+	store *Store
+}
+
+func NewPullRequestRepository(store *Store) *PullRequestRepository {
+	return &PullRequestRepository{store: store}
+}
+
+func (r *PullRequestRepository) Create(ctx context.Context, pr *domain.PullRequest) error {
+	return r.store.write(ctx, func(d *state) error {
+		if _, ok := d.prs.m[pr.ID]; ok {
+			return ErrDuplicateKey
+		}
+		if _, ok := d.users.m[pr.AuthorID]; !ok {
+			return ErrForeignKey
+		}
+		if err := checkReviewers(d, pr.ReviewerIDs); err != nil {
+			return err
+		}
+
+		stored := clonePullRequest(*pr)
+		stored.CreatedAt = r.store.now()
+		stored.MergedAt = nil
+		d.prs.set(pr.ID, stored)
+
+		return nil
+	})
+}
+
+func (r *PullRequestRepository) GetByID(ctx context.Context, id domain.PullRequestID) (*domain.PullRequest, error) {
+	var (
+		stored domain.PullRequest
+		ok     bool
+	)
+	r.store.read(ctx, func(d *state) {
+		stored, ok = d.prs.m[id]
+	})
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+
+	pr := clonePullRequest(stored)
+	return &pr, nil
+}
+
+func (r *PullRequestRepository) Update(ctx context.Context, pr *domain.PullRequest) error {
+	return r.store.write(ctx, func(d *state) error {
+		stored, ok := d.prs.m[pr.ID]
+		if !ok {
+			return sql.ErrNoRows
+		}
+		if _, ok := d.users.m[pr.AuthorID]; !ok {
+			return ErrForeignKey
+		}
+		if err := checkReviewers(d, pr.ReviewerIDs); err != nil {
+			return err
+		}
+
+		if pr.Status == domain.PRStatusMerged {
+			if pr.MergedAt == nil {
+				now := r.store.now()
+				pr.MergedAt = &now
+			}
+			t := *pr.MergedAt
+			stored.MergedAt = &t
+		} else {
+			stored.MergedAt = nil
+		}
+
+		stored.Title = pr.Title
+		stored.AuthorID = pr.AuthorID
+		stored.Status = pr.Status
+		stored.ReviewerIDs = append([]domain.UserID(nil), pr.ReviewerIDs...)
+		d.prs.set(pr.ID, stored)
+
+		return nil
+	})
+}
+
+func (r *PullRequestRepository) Exists(ctx context.Context, id domain.PullRequestID) (bool, error) {
+	var ok bool
+	r.store.read(ctx, func(d *state) {
+		_, ok = d.prs.m[id]
+	})
+	return ok, nil
+}
+
+func (r *PullRequestRepository) List(ctx context.Context, filter domain.PullRequestFilter) ([]domain.PullRequest, error) {
+	var result []domain.PullRequest
+	r.store.read(ctx, func(d *state) {
+		for _, stored := range d.prs.m {
+			if matchPullRequest(d, stored, filter) {
+				result = append(result, clonePullRequest(stored))
+			}
+		}
+	})
+
+	sort.Slice(result, func(i, j int) bool {
+		if filter.Desc {
+			return cursorLess(result[j].Cursor(), result[i].Cursor())
+		}
+		return cursorLess(result[i].Cursor(), result[j].Cursor())
+	})
+
+	if filter.Limit > 0 && len(result) > filter.Limit {
+		result = result[:filter.Limit]
+	}
+
+	return result, nil
+}
+
+func matchPullRequest(d *state, pr domain.PullRequest, filter domain.PullRequestFilter) bool {
+	if filter.AuthorID != "" && pr.AuthorID != filter.AuthorID {
+		return false
+	}
+	if filter.TeamName != "" && d.users.m[pr.AuthorID].TeamName != filter.TeamName {
+		return false
+	}
+	if filter.TitleContains != "" &&
+		!strings.Contains(strings.ToLower(pr.Title), strings.ToLower(filter.TitleContains)) {
+		return false
+	}
+	if filter.ReviewerID != "" && !slices.Contains(pr.ReviewerIDs, filter.ReviewerID) {
+		return false
+	}
+	if filter.Status != "" && pr.Status != filter.Status {
+		return false
+	}
+	if !filter.CreatedFrom.IsZero() && pr.CreatedAt.Before(filter.CreatedFrom) {
+		return false
+	}
+	if !filter.CreatedTo.IsZero() && !pr.CreatedAt.Before(filter.CreatedTo) {
+		return false
+	}
+	if filter.After != nil {
+		if filter.Desc {
+			return cursorLess(pr.Cursor(), *filter.After)
+		}
+		return cursorLess(*filter.After, pr.Cursor())
+	}
+	return true
+}
+
+func cursorLess(a, b domain.PullRequestCursor) bool {
+	if !a.CreatedAt.Equal(b.CreatedAt) {
+		return a.CreatedAt.Before(b.CreatedAt)
+	}
+	return a.ID < b.ID
+}
+
+func (r *PullRequestRepository) GetReviewerAssignmentStats(
+	ctx context.Context,
+	filter domain.AssignmentStatsFilter,
+) ([]domain.ReviewerAssignmentStat, error) {
+	groups := make(map[string]*domain.ReviewerAssignmentStat)
+	r.store.read(ctx, func(d *state) {
+		for _, stored := range d.prs.m {
+			if !filter.From.IsZero() && stored.CreatedAt.Before(filter.From) {
+				continue
+			}
+			if !filter.To.IsZero() && !stored.CreatedAt.Before(filter.To) {
+				continue
+			}
+
+			for _, rid := range stored.ReviewerIDs {
+				team := d.users.m[rid].TeamName
+				if filter.TeamName != "" && team != filter.TeamName {
+					continue
+				}
+
+				var key string
+				switch filter.GroupBy {
+				case domain.GroupByTeam:
+					key = string(team)
+				case domain.GroupByDay:
+					key = stored.CreatedAt.UTC().Format(time.DateOnly)
+				case domain.GroupByWeek:
+					key = weekStart(stored.CreatedAt).Format(time.DateOnly)
+				default:
+					key = string(rid)
+				}
+
+				g, ok := groups[key]
+				if !ok {
+					g = &domain.ReviewerAssignmentStat{Key: key}
+					groups[key] = g
+				}
+				g.Count++
+				switch stored.Status {
+				case domain.PRStatusOpen:
+					g.Open++
+				case domain.PRStatusMerged:
+					g.Merged++
+				}
+			}
+		}
+	})
+
+	stats := make([]domain.ReviewerAssignmentStat, 0, len(groups))
+	for _, g := range groups {
+		stats = append(stats, *g)
+	}
+
+	sort.Slice(stats, func(i, j int) bool {
+		return stats[i].Key < stats[j].Key
+	})
+
+	return stats, nil
+}
+
+func (r *PullRequestRepository) ListMergeTimes(ctx context.Context, filter domain.MergeTimesFilter) ([]domain.MergeTime, error) {
+	var result []domain.MergeTime
+	r.store.read(ctx, func(d *state) {
+		for _, stored := range d.prs.m {
+			if stored.MergedAt == nil {
+				continue
+			}
+			if !filter.From.IsZero() && stored.MergedAt.Before(filter.From) {
+				continue
+			}
+			if !filter.To.IsZero() && !stored.MergedAt.Before(filter.To) {
+				continue
+			}
+			team := d.users.m[stored.AuthorID].TeamName
+			if filter.TeamName != "" && team != filter.TeamName {
+				continue
+			}
+			result = append(result, domain.MergeTime{
+				PullRequestID: stored.ID,
+				AuthorID:      stored.AuthorID,
+				TeamName:      team,
+				CreatedAt:     stored.CreatedAt,
+				MergedAt:      *stored.MergedAt,
+			})
+		}
+	})
+
+	sort.Slice(result, func(i, j int) bool {
+		if !result[i].MergedAt.Equal(result[j].MergedAt) {
+			return result[i].MergedAt.Before(result[j].MergedAt)
+		}
+		return result[i].PullRequestID < result[j].PullRequestID
+	})
+
+	return result, nil
+}
+
+// weekStart returns the Monday of the UTC week containing t.
+func weekStart(t time.Time) time.Time {
+	t = t.UTC()
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	offset := (int(day.Weekday()) + 6) % 7
+	return day.AddDate(0, 0, -offset)
+}
+
+// checkReviewers mirrors the pull_request_reviewers constraints:
+// every reviewer must exist and appear at most once.
+func checkReviewers(d *state, ids []domain.UserID) error {
+	seen := make(map[domain.UserID]struct{}, len(ids))
+	for _, id := range ids {
+		if _, ok := d.users.m[id]; !ok {
+			return ErrForeignKey
+		}
+		if _, dup := seen[id]; dup {
+			return ErrDuplicateKey
+		}
+		seen[id] = struct{}{}
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package memory
+
+import (
+	"context"
+	"sort"
+
+	"github.com/terps489/avito_tech_internship/internal/domain"
+)
+
+type ReviewerEventRepository struct {
+	store *Store
+}
+
+func NewReviewerEventRepository(store *Store) *ReviewerEventRepository {
+	return &ReviewerEventRepository{store: store}
+}
+
+func (r *ReviewerEventRepository) Append(ctx context.Context, e *domain.ReviewerEvent) error {
+	return r.store.write(ctx, func(d *state) error {
+		if _, ok := d.prs.m[e.PullRequestID]; !ok {
+			return ErrForeignKey
+		}
+		for _, id := range []domain.UserID{e.FromUserID, e.ToUserID} {
+			if _, ok := d.users.m[id]; id != "" && !ok {
+				return ErrForeignKey
+			}
+		}
+
+		d.reviewerEventSeq++
+		e.ID = d.reviewerEventSeq
+		e.CreatedAt = r.store.now()
+		d.reviewerEvents = append(d.reviewerEvents, *e)
+
+		return nil
+	})
+}
+
+func (r *ReviewerEventRepository) ListByPullRequest(ctx context.Context, prID domain.PullRequestID) ([]domain.ReviewerEvent, error) {
+	var events []domain.ReviewerEvent
+	r.store.read(ctx, func(d *state) {
+		for _, e := range d.reviewerEvents {
+			if e.PullRequestID == prID {
+				events = append(events, e)
+			}
+		}
+	})
+
+	return events, nil
+}
+
+func (r *ReviewerEventRepository) GetReassignmentStats(ctx context.Context) ([]domain.ReassignmentStat, error) {
+	counts := make(map[domain.UserID]int64)
+	r.store.read(ctx, func(d *state) {
+		for _, e := range d.reviewerEvents {
+			if e.Type == domain.ReviewerEventReassigned {
+				counts[e.FromUserID]++
+			}
+		}
+	})
+
+	stats := make([]domain.ReassignmentStat, 0, len(counts))
+	for id, cnt := range counts {
+		stats = append(stats, domain.ReassignmentStat{UserID: id, Count: cnt})
+	}
+
+	sort.Slice(stats, func(i, j int) bool {
+		return stats[i].UserID < stats[j].UserID
+	})
+
+	return stats, nil
+}
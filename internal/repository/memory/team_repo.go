@@ -0,0 +1,66 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+
+	"github.com/terps489/avito_tech_internship/internal/domain"
+)
+
+type TeamRepository struct {
+	store *Store
+}
+
+func NewTeamRepository(store *Store) *TeamRepository {
+	return &TeamRepository{store: store}
+}
+
+func (r *TeamRepository) GetByName(ctx context.Context, name domain.TeamName) (*domain.Team, error) {
+	var (
+		t  domain.Team
+		ok bool
+	)
+	r.store.read(ctx, func(d *state) {
+		t, ok = d.teams.m[name]
+	})
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return &t, nil
+}
+
+func (r *TeamRepository) Create(ctx context.Context, name domain.TeamName) error {
+	return r.store.write(ctx, func(d *state) error {
+		if _, ok := d.teams.m[name]; ok {
+			return ErrDuplicateKey
+		}
+		d.teams.set(name, domain.Team{Name: name})
+		return nil
+	})
+}
+
+func (r *TeamRepository) Exists(ctx context.Context, name domain.TeamName) (bool, error) {
+	var ok bool
+	r.store.read(ctx, func(d *state) {
+		_, ok = d.teams.m[name]
+	})
+	return ok, nil
+}
+
+func (r *TeamRepository) ListMembers(ctx context.Context, name domain.TeamName) ([]domain.User, error) {
+	var users []domain.User
+	r.store.read(ctx, func(d *state) {
+		for _, u := range d.users.m {
+			if u.TeamName == name {
+				users = append(users, u)
+			}
+		}
+	})
+
+	sort.Slice(users, func(i, j int) bool {
+		return users[i].ID < users[j].ID
+	})
+
+	return users, nil
+}
@@ -0,0 +1,131 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/terps489/avito_tech_internship/internal/domain"
+)
+
+type UserRepository struct {
+	store *Store
+}
+
+func NewUserRepository(store *Store) *UserRepository {
+	return &UserRepository{store: store}
+}
+
+func (r *UserRepository) GetByID(ctx context.Context, id domain.UserID) (*domain.User, error) {
+	var (
+		u  domain.User
+		ok bool
+	)
+	r.store.read(ctx, func(d *state) {
+		u, ok = d.users.m[id]
+	})
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return &u, nil
+}
+
+func (r *UserRepository) ListActiveByTeam(ctx context.Context, teamName domain.TeamName) ([]domain.User, error) {
+	var users []domain.User
+	r.store.read(ctx, func(d *state) {
+		for _, u := range d.users.m {
+			if u.TeamName == teamName && u.IsActive {
+				users = append(users, u)
+			}
+		}
+	})
+
+	sort.Slice(users, func(i, j int) bool {
+		return users[i].ID < users[j].ID
+	})
+
+	return users, nil
+}
+
+func (r *UserRepository) UpsertUsersForTeam(ctx context.Context, teamName domain.TeamName, users []domain.User) error {
+	return r.store.write(ctx, func(d *state) error {
+		if _, ok := d.teams.m[teamName]; !ok && len(users) > 0 {
+			return ErrForeignKey
+		}
+
+		for _, u := range users {
+			u.TeamName = teamName
+			d.users.set(u.ID, u)
+		}
+
+		return nil
+	})
+}
+
+func (r *UserRepository) SetIsActive(ctx context.Context, id domain.UserID, active bool) error {
+	return r.store.write(ctx, func(d *state) error {
+		u, ok := d.users.m[id]
+		if !ok {
+			return sql.ErrNoRows
+		}
+
+		u.IsActive = active
+		d.users.set(id, u)
+
+		return nil
+	})
+}
+
+func (r *UserRepository) List(ctx context.Context, filter domain.UserFilter) ([]domain.User, error) {
+	prefix := strings.ToLower(filter.UsernamePrefix)
+
+	var users []domain.User
+	r.store.read(ctx, func(d *state) {
+		for _, u := range d.users.m {
+			if prefix != "" && !strings.HasPrefix(strings.ToLower(u.Username), prefix) {
+				continue
+			}
+			if filter.TeamName != "" && u.TeamName != filter.TeamName {
+				continue
+			}
+			if filter.IsActive != nil && u.IsActive != *filter.IsActive {
+				continue
+			}
+			if filter.After != nil && !userCursorLess(*filter.After, u.Cursor()) {
+				continue
+			}
+			users = append(users, u)
+		}
+	})
+
+	sort.Slice(users, func(i, j int) bool {
+		return userCursorLess(users[i].Cursor(), users[j].Cursor())
+	})
+
+	if filter.Limit > 0 && len(users) > filter.Limit {
+		users = users[:filter.Limit]
+	}
+
+	return users, nil
+}
+
+func (r *UserRepository) CountOpenReviews(ctx context.Context, id domain.UserID) (int, error) {
+	var n int
+	r.store.read(ctx, func(d *state) {
+		for _, pr := range d.prs.m {
+			if pr.Status == domain.PRStatusOpen && slices.Contains(pr.ReviewerIDs, id) {
+				n++
+			}
+		}
+	})
+	return n, nil
+}
+
+func userCursorLess(a, b domain.UserCursor) bool {
+	if a.Username != b.Username {
+		return a.Username < b.Username
+	}
+	return a.ID < b.ID
+}
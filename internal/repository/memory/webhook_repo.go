@@ -0,0 +1,336 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"time"
+
+	"github.com/terps489/avito_tech_internship/internal/domain"
+)
+
+type WebhookRepository struct {
+	store *Store
+}
+
+func NewWebhookRepository(store *Store) *WebhookRepository {
+	return &WebhookRepository{store: store}
+}
+
+func (r *WebhookRepository) Create(ctx context.Context, w *domain.Webhook) error {
+	return r.store.write(ctx, func(d *state) error {
+		d.webhookSeq++
+		w.ID = d.webhookSeq
+		w.CreatedAt = r.store.now()
+		w.UpdatedAt = w.CreatedAt
+		w.EventTypes = normalizeEventTypes(w.EventTypes)
+
+		d.webhooks.set(w.ID, cloneWebhook(*w))
+		return nil
+	})
+}
+
+func (r *WebhookRepository) GetByID(ctx context.Context, id int64) (*domain.Webhook, error) {
+	var (
+		w  domain.Webhook
+		ok bool
+	)
+	r.store.read(ctx, func(d *state) {
+		w, ok = d.webhooks.m[id]
+	})
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+
+	w = cloneWebhook(w)
+	return &w, nil
+}
+
+func (r *WebhookRepository) List(ctx context.Context) ([]domain.Webhook, error) {
+	var webhooks []domain.Webhook
+	r.store.read(ctx, func(d *state) {
+		for _, w := range d.webhooks.m {
+			webhooks = append(webhooks, cloneWebhook(w))
+		}
+	})
+
+	sort.Slice(webhooks, func(i, j int) bool {
+		return webhooks[i].ID < webhooks[j].ID
+	})
+
+	return webhooks, nil
+}
+
+func (r *WebhookRepository) Update(ctx context.Context, w *domain.Webhook) error {
+	return r.store.write(ctx, func(d *state) error {
+		stored, ok := d.webhooks.m[w.ID]
+		if !ok {
+			return sql.ErrNoRows
+		}
+
+		w.CreatedAt = stored.CreatedAt
+		w.UpdatedAt = r.store.now()
+		w.EventTypes = normalizeEventTypes(w.EventTypes)
+
+		d.webhooks.set(w.ID, cloneWebhook(*w))
+		return nil
+	})
+}
+
+func (r *WebhookRepository) Delete(ctx context.Context, id int64) error {
+	return r.store.write(ctx, func(d *state) error {
+		if _, ok := d.webhooks.m[id]; !ok {
+			return sql.ErrNoRows
+		}
+
+		d.webhooks.delete(id)
+		for deliveryID, delivery := range d.webhookDeliveries.m {
+			if delivery.WebhookID == id {
+				d.webhookDeliveries.delete(deliveryID)
+			}
+		}
+		return nil
+	})
+}
+
+func (r *WebhookRepository) CreateDelivery(ctx context.Context, delivery *domain.WebhookDelivery) error {
+	return r.store.write(ctx, func(d *state) error {
+		if _, ok := d.webhooks.m[delivery.WebhookID]; !ok {
+			return ErrForeignKey
+		}
+		if _, ok := d.outbox.m[delivery.EventID]; !ok {
+			return ErrForeignKey
+		}
+		if delivery.RedeliveryOf == nil && d.hasOriginalDelivery(delivery.WebhookID, delivery.EventID) {
+			return ErrDuplicateKey
+		}
+
+		d.addDelivery(delivery, r.store.now())
+		return nil
+	})
+}
+
+func (r *WebhookRepository) GetDelivery(ctx context.Context, id int64) (*domain.WebhookDelivery, error) {
+	var (
+		delivery domain.WebhookDelivery
+		ok       bool
+	)
+	r.store.read(ctx, func(d *state) {
+		delivery, ok = d.webhookDeliveries.m[id]
+	})
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+
+	delivery = cloneDelivery(delivery)
+	return &delivery, nil
+}
+
+func (r *WebhookRepository) ListDeliveries(ctx context.Context, filter domain.WebhookDeliveryFilter) ([]domain.WebhookDelivery, error) {
+	var deliveries []domain.WebhookDelivery
+	r.store.read(ctx, func(d *state) {
+		for _, delivery := range d.webhookDeliveries.m {
+			if filter.WebhookID > 0 && delivery.WebhookID != filter.WebhookID {
+				continue
+			}
+			if filter.Status != "" && delivery.Status != filter.Status {
+				continue
+			}
+			if filter.BeforeID > 0 && delivery.ID >= filter.BeforeID {
+				continue
+			}
+			deliveries = append(deliveries, cloneDelivery(delivery))
+		}
+	})
+
+	sort.Slice(deliveries, func(i, j int) bool {
+		return deliveries[i].ID > deliveries[j].ID
+	})
+	if filter.Limit > 0 && len(deliveries) > filter.Limit {
+		deliveries = deliveries[:filter.Limit]
+	}
+
+	return deliveries, nil
+}
+
+func (r *WebhookRepository) EnqueueDeliveries(ctx context.Context, e domain.OutboxEvent) error {
+	return r.store.write(ctx, func(d *state) error {
+		if _, ok := d.outbox.m[e.ID]; !ok {
+			return ErrForeignKey
+		}
+
+		ids := make([]int64, 0, len(d.webhooks.m))
+		for id := range d.webhooks.m {
+			ids = append(ids, id)
+		}
+		sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+		now := r.store.now()
+		for _, id := range ids {
+			w := d.webhooks.m[id]
+			if !w.IsActive || !containsEventType(w.EventTypes, e.Type) || d.hasOriginalDelivery(id, e.ID) {
+				continue
+			}
+			d.addDelivery(&domain.WebhookDelivery{WebhookID: id, EventID: e.ID, EventType: e.Type}, now)
+		}
+		return nil
+	})
+}
+
+func (r *WebhookRepository) ClaimDeliveries(ctx context.Context, limit int, lease time.Duration) ([]domain.WebhookJob, error) {
+	var jobs []domain.WebhookJob
+	err := r.store.write(ctx, func(d *state) error {
+		now := r.store.now()
+
+		ids := make([]int64, 0, len(d.webhookDeliveries.m))
+		for id, delivery := range d.webhookDeliveries.m {
+			if delivery.Status != domain.WebhookDeliveryPending || delivery.NextAttemptAt.After(now) {
+				continue
+			}
+			if !d.webhooks.m[delivery.WebhookID].IsActive {
+				continue
+			}
+			ids = append(ids, id)
+		}
+		sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+		if len(ids) > limit {
+			ids = ids[:limit]
+		}
+
+		for _, id := range ids {
+			delivery := d.webhookDeliveries.m[id]
+			delivery.Attempts++
+			delivery.NextAttemptAt = now.Add(lease)
+			d.webhookDeliveries.set(id, delivery)
+
+			w := d.webhooks.m[delivery.WebhookID]
+			event := d.outbox.m[delivery.EventID].event
+			event.Payload = append([]byte(nil), event.Payload...)
+			jobs = append(jobs, domain.WebhookJob{
+				Delivery: cloneDelivery(delivery),
+				URL:      w.URL,
+				Secret:   w.Secret,
+				Event:    event,
+			})
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return jobs, nil
+}
+
+func (r *WebhookRepository) MarkDeliverySucceeded(ctx context.Context, id int64, responseStatus int) error {
+	return r.store.write(ctx, func(d *state) error {
+		delivery, ok := d.webhookDeliveries.m[id]
+		if !ok {
+			return nil
+		}
+		now := r.store.now()
+		delivery.Status = domain.WebhookDeliverySucceeded
+		delivery.ResponseStatus = responseStatus
+		delivery.LastError = ""
+		delivery.DeliveredAt = &now
+		d.webhookDeliveries.set(id, delivery)
+		return nil
+	})
+}
+
+func (r *WebhookRepository) MarkDeliveryFailed(
+	ctx context.Context,
+	id int64,
+	responseStatus int,
+	lastErr string,
+	retryAt time.Time,
+	dead bool,
+) error {
+	return r.store.write(ctx, func(d *state) error {
+		delivery, ok := d.webhookDeliveries.m[id]
+		if !ok {
+			return nil
+		}
+		delivery.Status = domain.WebhookDeliveryPending
+		if dead {
+			delivery.Status = domain.WebhookDeliveryFailed
+		}
+		delivery.ResponseStatus = responseStatus
+		delivery.LastError = lastErr
+		delivery.NextAttemptAt = retryAt
+		d.webhookDeliveries.set(id, delivery)
+		return nil
+	})
+}
+
+func (d *state) hasOriginalDelivery(webhookID, eventID int64) bool {
+	for _, delivery := range d.webhookDeliveries.m {
+		if delivery.WebhookID == webhookID && delivery.EventID == eventID && delivery.RedeliveryOf == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// addDelivery stores a new pending delivery and fills its generated fields.
+func (d *state) addDelivery(delivery *domain.WebhookDelivery, now time.Time) {
+	d.webhookDeliverySeq++
+	delivery.ID = d.webhookDeliverySeq
+	delivery.Status = domain.WebhookDeliveryPending
+	delivery.Attempts = 0
+	delivery.ResponseStatus = 0
+	delivery.LastError = ""
+	delivery.NextAttemptAt = now
+	delivery.CreatedAt = now
+	delivery.DeliveredAt = nil
+
+	d.webhookDeliveries.set(delivery.ID, cloneDelivery(*delivery))
+}
+
+// normalizeEventTypes sorts and deduplicates like the SQL backends,
+// which store event types as rows keyed by (webhook_id, event_type).
+func normalizeEventTypes(types []domain.EventType) []domain.EventType {
+	if len(types) == 0 {
+		return nil
+	}
+	out := append([]domain.EventType(nil), types...)
+	sort.Slice(out, func(i, j int) bool { return out[i] < out[j] })
+
+	n := 1
+	for i := 1; i < len(out); i++ {
+		if out[i] != out[n-1] {
+			out[n] = out[i]
+			n++
+		}
+	}
+	return out[:n]
+}
+
+func containsEventType(types []domain.EventType, t domain.EventType) bool {
+	for _, et := range types {
+		if et == t {
+			return true
+		}
+	}
+	return false
+}
+
+func cloneWebhook(w domain.Webhook) domain.Webhook {
+	if w.EventTypes != nil {
+		w.EventTypes = append([]domain.EventType(nil), w.EventTypes...)
+	}
+	return w
+}
+
+func cloneDelivery(delivery domain.WebhookDelivery) domain.WebhookDelivery {
+	if delivery.RedeliveryOf != nil {
+		id := *delivery.RedeliveryOf
+		delivery.RedeliveryOf = &id
+	}
+	if delivery.DeliveredAt != nil {
+		t := *delivery.DeliveredAt
+		delivery.DeliveredAt = &t
+	}
+	return delivery
+}
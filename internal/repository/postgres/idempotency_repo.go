@@ -0,0 +1,101 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/terps489/avito_tech_internship/internal/domain"
+)
+
+type IdempotencyRepository struct {
+	db *sql.DB
+}
+
+func NewIdempotencyRepository(db *sql.DB) *IdempotencyRepository {
+	return &IdempotencyRepository{db: db}
+}
+
+// Reserve stores k as pending unless its scope and key are taken by a
+// record that has not expired at k.CreatedAt. An expired record is
+// replaced.
+func (r *IdempotencyRepository) Reserve(ctx context.Context, k *domain.IdempotencyKey) (bool, error) {
+	const query = `
+		INSERT INTO idempotency_keys (scope, idempotency_key, request_hash, status, body, created_at, expires_at)
+		VALUES ($1, $2, $3, 0, NULL, $4, $5)
+		ON CONFLICT (scope, idempotency_key) DO UPDATE
+		SET request_hash = EXCLUDED.request_hash,
+		    status = 0,
+		    body = NULL,
+		    created_at = EXCLUDED.created_at,
+		    expires_at = EXCLUDED.expires_at
+		WHERE idempotency_keys.expires_at <= EXCLUDED.created_at
+	`
+
+	res, err := conn(ctx, r.db).ExecContext(ctx, query, k.Scope, k.Key, k.RequestHash, k.CreatedAt, k.ExpiresAt)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n == 1, nil
+}
+
+func (r *IdempotencyRepository) Get(ctx context.Context, scope, key string) (*domain.IdempotencyKey, error) {
+	const query = `
+		SELECT scope, idempotency_key, request_hash, status, body, created_at, expires_at
+		FROM idempotency_keys
+		WHERE scope = $1 AND idempotency_key = $2
+	`
+
+	var k domain.IdempotencyKey
+	err := conn(ctx, r.db).QueryRowContext(ctx, query, scope, key).
+		Scan(&k.Scope, &k.Key, &k.RequestHash, &k.Status, &k.Body, &k.CreatedAt, &k.ExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+	return &k, nil
+}
+
+// Complete stores the response of the request that reserved the key.
+func (r *IdempotencyRepository) Complete(ctx context.Context, scope, key string, status int, body []byte, expiresAt time.Time) error {
+	const query = `
+		UPDATE idempotency_keys
+		SET status = $3, body = $4, expires_at = $5
+		WHERE scope = $1 AND idempotency_key = $2
+	`
+
+	res, err := conn(ctx, r.db).ExecContext(ctx, query, scope, key, status, body, expiresAt)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func (r *IdempotencyRepository) Delete(ctx context.Context, scope, key string) error {
+	const query = `DELETE FROM idempotency_keys WHERE scope = $1 AND idempotency_key = $2`
+
+	_, err := conn(ctx, r.db).ExecContext(ctx, query, scope, key)
+	return err
+}
+
+// DeleteExpired removes records that expired at or before now and
+// returns how many there were.
+func (r *IdempotencyRepository) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	const query = `DELETE FROM idempotency_keys WHERE expires_at <= $1`
+
+	res, err := conn(ctx, r.db).ExecContext(ctx, query, now)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
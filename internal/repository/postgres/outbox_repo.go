@@ -0,0 +1,145 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"time"
+
+	"github.com/terps489/avito_tech_internship/internal/domain"
+)
+
+type OutboxRepository struct {
+	db *sql.DB
+}
+
+func NewOutboxRepository(db *sql.DB) *OutboxRepository {
+	return &OutboxRepository{db: db}
+}
+
+func (r *OutboxRepository) Append(ctx context.Context, e *domain.OutboxEvent) error {
+	const query = `
+		INSERT INTO outbox_events (event_type, payload, request_id)
+		VALUES ($1, $2, $3)
+		RETURNING id, created_at
+	`
+
+	return conn(ctx, r.db).QueryRowContext(ctx, query, e.Type, string(e.Payload), e.RequestID).
+		Scan(&e.ID, &e.CreatedAt)
+}
+
+func (r *OutboxRepository) ClaimPending(ctx context.Context, limit int, lease time.Duration) ([]domain.OutboxEvent, error) {
+	const query = `
+		UPDATE outbox_events
+		SET attempts = attempts + 1,
+		    next_attempt_at = NOW() + make_interval(secs => $2)
+		WHERE id IN (
+			SELECT id
+			FROM outbox_events
+			WHERE status = 'pending' AND next_attempt_at <= NOW()
+			ORDER BY id
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, event_type, payload, request_id, created_at, attempts
+	`
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, limit, lease.Seconds())
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var events []domain.OutboxEvent
+	for rows.Next() {
+		var e domain.OutboxEvent
+		if err := rows.Scan(&e.ID, &e.Type, &e.Payload, &e.RequestID, &e.CreatedAt, &e.Attempts); err != nil {
+			return nil, err
+		}
+		events = append(events, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.Slice(events, func(i, j int) bool {
+		return events[i].ID < events[j].ID
+	})
+
+	return events, nil
+}
+
+func (r *OutboxRepository) DeliveredSinks(ctx context.Context, eventID int64) ([]string, error) {
+	const query = `
+		SELECT sink
+		FROM outbox_deliveries
+		WHERE event_id = $1
+		ORDER BY sink
+	`
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, eventID)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var sinks []string
+	for rows.Next() {
+		var sink string
+		if err := rows.Scan(&sink); err != nil {
+			return nil, err
+		}
+		sinks = append(sinks, sink)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return sinks, nil
+}
+
+func (r *OutboxRepository) MarkDelivered(ctx context.Context, eventID int64, sink string) error {
+	const query = `
+		INSERT INTO outbox_deliveries (event_id, sink)
+		VALUES ($1, $2)
+		ON CONFLICT (event_id, sink) DO NOTHING
+	`
+
+	_, err := conn(ctx, r.db).ExecContext(ctx, query, eventID, sink)
+	return err
+}
+
+func (r *OutboxRepository) MarkProcessed(ctx context.Context, eventID int64) error {
+	const query = `
+		UPDATE outbox_events
+		SET status = 'processed',
+		    processed_at = NOW(),
+		    last_error = NULL
+		WHERE id = $1
+	`
+
+	_, err := conn(ctx, r.db).ExecContext(ctx, query, eventID)
+	return err
+}
+
+func (r *OutboxRepository) MarkFailed(ctx context.Context, eventID int64, retryAt time.Time, lastErr string, dead bool) error {
+	const query = `
+		UPDATE outbox_events
+		SET status = $2,
+		    next_attempt_at = $3,
+		    last_error = $4
+		WHERE id = $1
+	`
+
+	status := "pending"
+	if dead {
+		status = "dead"
+	}
+
+	_, err := conn(ctx, r.db).ExecContext(ctx, query, eventID, status, retryAt.UTC(), lastErr)
+	return err
+}
@@ -1,68 +1,172 @@
 package postgres
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
-	"os"
+	"net"
+	"net/url"
 	"strconv"
 	"time"
 
 	_ "github.com/jackc/pgx/v5/stdlib"
 )
 
+// SchemaVersion is the latest migration in migrations/ this code expects.
+const SchemaVersion = "012_idempotency_keys"
+
 type Config struct {
+	// DSN, if set, is used as is instead of the connection fields and TLS.
+	DSN string
+
 	Host     string
 	Port     int
 	User     string
 	Password string
 	DBName   string
+	TLS      TLS
+
+	Pool  Pool
+	Retry Retry
+}
+
+// TLS maps to the libpq sslmode, sslrootcert, sslcert and sslkey
+// parameters. An empty Mode leaves the driver default.
+type TLS struct {
+	Mode     string
+	RootCert string
+	Cert     string
+	Key      string
+}
+
+// Pool sizes the database/sql connection pool; zero values keep the
+// database/sql defaults.
+type Pool struct {
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+	ConnMaxIdleTime time.Duration
+}
+
+func DefaultPool() Pool {
+	return Pool{
+		MaxOpenConns:    20,
+		MaxIdleConns:    10,
+		ConnMaxLifetime: 30 * time.Minute,
+		ConnMaxIdleTime: 5 * time.Minute,
+	}
+}
+
+// Retry controls how long Connect waits for the database at startup:
+// up to Attempts pings, each bounded by PingTimeout, with a delay that
+// starts at InitialDelay and doubles up to MaxDelay.
+type Retry struct {
+	Attempts     int
+	InitialDelay time.Duration
+	MaxDelay     time.Duration
+	PingTimeout  time.Duration
 }
 
-func NewFromEnv() (*sql.DB, error) {
-	cfg := Config{
-		Host:     getEnv("DB_HOST", "localhost"),
-		User:     getEnv("DB_USER", "postgres"),
-		Password: getEnv("DB_PASSWORD", "postgres"),
-		DBName:   getEnv("DB_NAME", "avito_review"),
+func DefaultRetry() Retry {
+	return Retry{
+		Attempts:     10,
+		InitialDelay: 500 * time.Millisecond,
+		MaxDelay:     10 * time.Second,
+		PingTimeout:  2 * time.Second,
 	}
+}
 
-	portStr := getEnv("DB_PORT", "5432")
-	port, err := strconv.Atoi(portStr)
-	if err != nil {
-		return nil, fmt.Errorf("invalid DB_PORT: %w", err)
+// ConnString returns cfg.DSN, or a postgres:// URL built from the
+// connection fields and TLS.
+func (cfg Config) ConnString() string {
+	if cfg.DSN != "" {
+		return cfg.DSN
+	}
+
+	q := url.Values{}
+	for key, v := range map[string]string{
+		"sslmode":     cfg.TLS.Mode,
+		"sslrootcert": cfg.TLS.RootCert,
+		"sslcert":     cfg.TLS.Cert,
+		"sslkey":      cfg.TLS.Key,
+	} {
+		if v != "" {
+			q.Set(key, v)
+		}
 	}
-	cfg.Port = port
 
-	dsn := fmt.Sprintf(
-		"postgres://%s:%s@%s:%d/%s?sslmode=disable",
-		cfg.User, cfg.Password, cfg.Host, cfg.Port, cfg.DBName,
-	)
+	u := url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(cfg.User, cfg.Password),
+		Host:     net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.Port)),
+		Path:     "/" + cfg.DBName,
+		RawQuery: q.Encode(),
+	}
+	return u.String()
+}
 
-	db, err := sql.Open("pgx", dsn)
+// Connect opens the database described by cfg and waits until it answers
+// a ping, retrying as cfg.Retry says.
+func Connect(ctx context.Context, cfg Config) (*sql.DB, error) {
+	db, err := sql.Open("pgx", cfg.ConnString())
 	if err != nil {
 		return nil, fmt.Errorf("sql open: %w", err)
 	}
 
-	// Wait for the database to be ready
-	const (
-		maxAttempts = 10
-		delay       = time.Second
-	)
+	if cfg.Pool.MaxOpenConns > 0 {
+		db.SetMaxOpenConns(cfg.Pool.MaxOpenConns)
+	}
+	if cfg.Pool.MaxIdleConns > 0 {
+		db.SetMaxIdleConns(cfg.Pool.MaxIdleConns)
+	}
+	if cfg.Pool.ConnMaxLifetime > 0 {
+		db.SetConnMaxLifetime(cfg.Pool.ConnMaxLifetime)
+	}
+	if cfg.Pool.ConnMaxIdleTime > 0 {
+		db.SetConnMaxIdleTime(cfg.Pool.ConnMaxIdleTime)
+	}
 
-	for i := 1; i <= maxAttempts; i++ {
-		if err := db.Ping(); err == nil {
-			return db, nil
-		}
-		time.Sleep(delay)
+	if err := waitReady(ctx, db, cfg.Retry); err != nil {
+		_ = db.Close()
+		return nil, err
 	}
+	return db, nil
+}
+
+func waitReady(ctx context.Context, db *sql.DB, retry Retry) error {
+	delay := retry.InitialDelay
+	for attempt := 1; ; attempt++ {
+		pingCtx, cancel := context.WithTimeout(ctx, retry.PingTimeout)
+		err := db.PingContext(pingCtx)
+		cancel()
+		if err == nil {
+			return nil
+		}
+		if attempt >= retry.Attempts {
+			return fmt.Errorf("sql ping: database is not ready after %d attempts: %w", attempt, err)
+		}
 
-	_ = db.Close()
-	return nil, fmt.Errorf("sql ping: database is not ready after %d attempts", maxAttempts)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+		delay = min(delay*2, retry.MaxDelay)
+	}
 }
 
-func getEnv(key, def string) string {
-	if v := os.Getenv(key); v != "" {
-		return v
+// CheckSchema reports an error unless SchemaVersion has been applied.
+// Later migrations are fine: during a rolling deploy old instances keep
+// running on the schema of the new ones.
+func CheckSchema(ctx context.Context, db *sql.DB) error {
+	const query = `SELECT EXISTS (SELECT 1 FROM schema_migrations WHERE version = $1)`
+
+	var applied bool
+	if err := db.QueryRowContext(ctx, query, SchemaVersion).Scan(&applied); err != nil {
+		return fmt.Errorf("read schema version: %w", err)
+	}
+	if !applied {
+		return fmt.Errorf("migration %s is not applied", SchemaVersion)
 	}
-	return def
+	return nil
 }
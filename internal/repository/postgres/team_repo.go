@@ -1,6 +1,7 @@
 package postgres
 
 import (
+	"context"
 	"database/sql"
 
 	"github.com/terps489/avito_tech_internship/internal/domain"
@@ -14,7 +15,7 @@ func NewTeamRepository(db *sql.DB) *TeamRepository {
 	return &TeamRepository{db: db}
 }
 
-func (r *TeamRepository) GetByName(name domain.TeamName) (*domain.Team, error) {
+func (r *TeamRepository) GetByName(ctx context.Context, name domain.TeamName) (*domain.Team, error) {
 	const query = `
 		SELECT team_name
 		FROM teams
@@ -22,30 +23,30 @@ func (r *TeamRepository) GetByName(name domain.TeamName) (*domain.Team, error) {
 	`
 
 	var t domain.Team
-	err := r.db.QueryRow(query, name).Scan(&t.Name)
+	err := conn(ctx, r.db).QueryRowContext(ctx, query, name).Scan(&t.Name)
 	if err != nil {
 		return nil, err
 	}
 	return &t, nil
 }
 
-func (r *TeamRepository) Create(name domain.TeamName) error {
+func (r *TeamRepository) Create(ctx context.Context, name domain.TeamName) error {
 	const query = `
 		INSERT INTO teams (team_name)
 		VALUES ($1)
 	`
-	_, err := r.db.Exec(query, name)
+	_, err := conn(ctx, r.db).ExecContext(ctx, query, name)
 	return err
 }
 
-func (r *TeamRepository) Exists(name domain.TeamName) (bool, error) {
+func (r *TeamRepository) Exists(ctx context.Context, name domain.TeamName) (bool, error) {
 	const query = `
 		SELECT 1
 		FROM teams
 		WHERE team_name = $1
 	`
 	var dummy int
-	err := r.db.QueryRow(query, name).Scan(&dummy)
+	err := conn(ctx, r.db).QueryRowContext(ctx, query, name).Scan(&dummy)
 	if err == sql.ErrNoRows {
 		return false, nil
 	}
@@ -55,7 +56,7 @@ func (r *TeamRepository) Exists(name domain.TeamName) (bool, error) {
 	return true, nil
 }
 
-func (r *TeamRepository) ListMembers(name domain.TeamName) ([]domain.User, error) {
+func (r *TeamRepository) ListMembers(ctx context.Context, name domain.TeamName) ([]domain.User, error) {
 	const query = `
 		SELECT user_id, username, is_active, team_name
 		FROM users
@@ -63,7 +64,7 @@ func (r *TeamRepository) ListMembers(name domain.TeamName) ([]domain.User, error
 		ORDER BY user_id
 	`
 
-	rows, err := r.db.Query(query, name)
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, name)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,66 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+
+	"github.com/terps489/avito_tech_internship/internal/tracing"
+)
+
+// querier is implemented by both *sql.DB and *sql.Tx.
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+type txKey struct{}
+
+// TxManager implements app.TxManager. The transaction travels in the
+// context, so repositories built on the same *sql.DB join it.
+type TxManager struct {
+	db *sql.DB
+}
+
+func NewTxManager(db *sql.DB) *TxManager {
+	return &TxManager{db: db}
+}
+
+func (m *TxManager) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(txKey{}).(*sql.Tx); ok {
+		return fn(ctx)
+	}
+
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	if err := fn(context.WithValue(ctx, txKey{}, tx)); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// conn returns the transaction from ctx, or db when there is none,
+// with a span per query.
+func conn(ctx context.Context, db *sql.DB) querier {
+	if tx, ok := ctx.Value(txKey{}).(*sql.Tx); ok {
+		return tracing.SQL(tx, semconv.DBSystemPostgreSQL)
+	}
+	return tracing.SQL(db, semconv.DBSystemPostgreSQL)
+}
+
+// inTx runs fn in the transaction from ctx, or in a new one
+// for multi-statement writes made outside of a transaction.
+func inTx(ctx context.Context, db *sql.DB, fn func(q querier) error) error {
+	return NewTxManager(db).WithinTx(ctx, func(ctx context.Context) error {
+		return fn(conn(ctx, db))
+	})
+}
@@ -1,7 +1,10 @@
 package postgres
 
 import (
+	"context"
 	"database/sql"
+	"fmt"
+	"strings"
 
 	"github.com/terps489/avito_tech_internship/internal/domain"
 )
@@ -14,7 +17,7 @@ func NewUserRepository(db *sql.DB) *UserRepository {
 	return &UserRepository{db: db}
 }
 
-func (r *UserRepository) GetByID(id domain.UserID) (*domain.User, error) {
+func (r *UserRepository) GetByID(ctx context.Context, id domain.UserID) (*domain.User, error) {
 	const query = `
 		SELECT user_id, username, is_active, team_name
 		FROM users
@@ -22,21 +25,21 @@ func (r *UserRepository) GetByID(id domain.UserID) (*domain.User, error) {
 	`
 
 	var u domain.User
-	err := r.db.QueryRow(query, id).Scan(&u.ID, &u.Username, &u.IsActive, &u.TeamName)
+	err := conn(ctx, r.db).QueryRowContext(ctx, query, id).Scan(&u.ID, &u.Username, &u.IsActive, &u.TeamName)
 	if err != nil {
 		return nil, err
 	}
 	return &u, nil
 }
 
-func (r *UserRepository) ListActiveByTeam(teamName domain.TeamName) ([]domain.User, error) {
+func (r *UserRepository) ListActiveByTeam(ctx context.Context, teamName domain.TeamName) ([]domain.User, error) {
 	const query = `
 		SELECT user_id, username, is_active, team_name
 		FROM users
 		WHERE team_name = $1 AND is_active = TRUE
 	`
 
-	rows, err := r.db.Query(query, teamName)
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, teamName)
 	if err != nil {
 		return nil, err
 	}
@@ -59,41 +62,35 @@ func (r *UserRepository) ListActiveByTeam(teamName domain.TeamName) ([]domain.Us
 	return users, nil
 }
 
-func (r *UserRepository) UpsertUsersForTeam(teamName domain.TeamName, users []domain.User) error {
-	tx, err := r.db.Begin()
-	if err != nil {
-		return err
-	}
-	defer func() {
-		_ = tx.Rollback()
-	}()
-
-	const query = `
-		INSERT INTO users (user_id, username, is_active, team_name)
-		VALUES ($1, $2, $3, $4)
-		ON CONFLICT (user_id) DO UPDATE
-		SET username = EXCLUDED.username,
-		    is_active = EXCLUDED.is_active,
-		    team_name = EXCLUDED.team_name
-	`
-
-	for _, u := range users {
-		if _, err := tx.Exec(query, u.ID, u.Username, u.IsActive, teamName); err != nil {
-			return err
+func (r *UserRepository) UpsertUsersForTeam(ctx context.Context, teamName domain.TeamName, users []domain.User) error {
+	return inTx(ctx, r.db, func(tx querier) error {
+		const query = `
+			INSERT INTO users (user_id, username, is_active, team_name)
+			VALUES ($1, $2, $3, $4)
+			ON CONFLICT (user_id) DO UPDATE
+			SET username = EXCLUDED.username,
+			    is_active = EXCLUDED.is_active,
+			    team_name = EXCLUDED.team_name
+		`
+
+		for _, u := range users {
+			if _, err := tx.ExecContext(ctx, query, u.ID, u.Username, u.IsActive, teamName); err != nil {
+				return err
+			}
 		}
-	}
 
-	return tx.Commit()
+		return nil
+	})
 }
 
-func (r *UserRepository) SetIsActive(id domain.UserID, active bool) error {
+func (r *UserRepository) SetIsActive(ctx context.Context, id domain.UserID, active bool) error {
 	const query = `
 		UPDATE users
 		SET is_active = $2
 		WHERE user_id = $1
 	`
 
-	res, err := r.db.Exec(query, id, active)
+	res, err := conn(ctx, r.db).ExecContext(ctx, query, id, active)
 	if err != nil {
 		return err
 	}
@@ -108,3 +105,79 @@ func (r *UserRepository) SetIsActive(id domain.UserID, active bool) error {
 
 	return nil
 }
+
+func (r *UserRepository) List(ctx context.Context, filter domain.UserFilter) ([]domain.User, error) {
+	var (
+		conds []string
+		args  []any
+	)
+	addCond := func(cond string, arg any) {
+		args = append(args, arg)
+		conds = append(conds, fmt.Sprintf(cond, len(args)))
+	}
+
+	if filter.UsernamePrefix != "" {
+		addCond(`lower(username) LIKE $%d ESCAPE '\'`, likeEscaper.Replace(strings.ToLower(filter.UsernamePrefix))+"%")
+	}
+	if filter.TeamName != "" {
+		addCond("team_name = $%d", filter.TeamName)
+	}
+	if filter.IsActive != nil {
+		addCond("is_active = $%d", *filter.IsActive)
+	}
+	if filter.After != nil {
+		args = append(args, filter.After.Username, filter.After.ID)
+		conds = append(conds, fmt.Sprintf(`(username COLLATE "C", user_id COLLATE "C") > ($%d, $%d)`, len(args)-1, len(args)))
+	}
+
+	query := `
+		SELECT user_id, username, is_active, team_name
+		FROM users
+	`
+	if len(conds) > 0 {
+		query += " WHERE " + strings.Join(conds, " AND ")
+	}
+	// Byte order keeps pagination identical across backends.
+	query += ` ORDER BY username COLLATE "C", user_id COLLATE "C"`
+	if filter.Limit > 0 {
+		args = append(args, filter.Limit)
+		query += fmt.Sprintf(" LIMIT $%d", len(args))
+	}
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var users []domain.User
+	for rows.Next() {
+		var u domain.User
+		if err := rows.Scan(&u.ID, &u.Username, &u.IsActive, &u.TeamName); err != nil {
+			return nil, err
+		}
+		users = append(users, u)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return users, nil
+}
+
+func (r *UserRepository) CountOpenReviews(ctx context.Context, id domain.UserID) (int, error) {
+	const query = `
+		SELECT COUNT(*)
+		FROM pull_request_reviewers r
+		JOIN pull_requests pr ON pr.pull_request_id = r.pr_id
+		WHERE r.reviewer_id = $1 AND pr.status = 'OPEN'
+	`
+
+	var n int
+	if err := conn(ctx, r.db).QueryRowContext(ctx, query, id).Scan(&n); err != nil {
+		return 0, err
+	}
+	return n, nil
+}
@@ -0,0 +1,61 @@
+package repotest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/terps489/avito_tech_internship/internal/app"
+	"github.com/terps489/avito_tech_internship/internal/domain"
+)
+
+func testAPITokens(t *testing.T, r app.Repositories) {
+	ctx := t.Context()
+	seedTeam(t, r, "backend", user("u1", true))
+
+	admin := &domain.APIToken{Name: "ops", Hash: "hash-admin", Role: domain.RoleAdmin}
+	mustNoErr(t, r.APITokens.Create(ctx, admin))
+	if admin.ID == 0 || admin.CreatedAt.IsZero() {
+		t.Fatalf("Create did not fill ID and CreatedAt: %+v", admin)
+	}
+	lead := &domain.APIToken{Name: "lead", Hash: "hash-lead", Role: domain.RoleTeamLead, TeamName: "backend", UserID: "u1"}
+	mustNoErr(t, r.APITokens.Create(ctx, lead))
+
+	if err := r.APITokens.Create(ctx, &domain.APIToken{Name: "dup", Hash: "hash-admin", Role: domain.RoleAdmin}); err == nil {
+		t.Fatal("Create with a duplicate hash succeeded")
+	}
+
+	got, err := r.APITokens.GetByHash(ctx, "hash-lead")
+	mustNoErr(t, err)
+	if got.ID != lead.ID || got.Role != domain.RoleTeamLead || got.TeamName != "backend" || got.UserID != "u1" || got.RevokedAt != nil {
+		t.Fatalf("GetByHash = %+v, want %+v", got, lead)
+	}
+	got, err = r.APITokens.GetByHash(ctx, "hash-admin")
+	mustNoErr(t, err)
+	if got.TeamName != "" || got.UserID != "" {
+		t.Fatalf("GetByHash(admin) = %+v, want no team and user", got)
+	}
+	_, err = r.APITokens.GetByHash(ctx, "missing")
+	mustNotFound(t, "GetByHash", err)
+
+	got, err = r.APITokens.GetByID(ctx, lead.ID)
+	mustNoErr(t, err)
+	if got.Hash != "hash-lead" {
+		t.Fatalf("GetByID = %+v, want the lead token", got)
+	}
+	_, err = r.APITokens.GetByID(ctx, 9999)
+	mustNotFound(t, "GetByID", err)
+
+	revokedAt := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	mustNoErr(t, r.APITokens.Revoke(ctx, admin.ID, revokedAt))
+	mustNoErr(t, r.APITokens.Revoke(ctx, admin.ID, revokedAt.Add(time.Hour)))
+	mustNotFound(t, "Revoke", r.APITokens.Revoke(ctx, 9999, revokedAt))
+
+	all, err := r.APITokens.List(ctx)
+	mustNoErr(t, err)
+	if len(all) != 2 || all[0].ID != admin.ID || all[1].ID != lead.ID {
+		t.Fatalf("List = %+v, want admin then lead", all)
+	}
+	if all[0].RevokedAt == nil || !all[0].RevokedAt.Equal(revokedAt) {
+		t.Fatalf("RevokedAt = %v, want the first revocation time %v", all[0].RevokedAt, revokedAt)
+	}
+}
@@ -0,0 +1,114 @@
+package repotest
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/terps489/avito_tech_internship/internal/app"
+	"github.com/terps489/avito_tech_internship/internal/domain"
+)
+
+func testAuditAppendAndList(t *testing.T, r app.Repositories) {
+	ctx := t.Context()
+
+	created := &domain.AuditEvent{
+		Actor:      "alice",
+		Action:     domain.AuditActionTeamAdd,
+		EntityType: domain.AuditEntityTeam,
+		EntityID:   "backend",
+		After:      []byte(`{"team_name":"backend"}`),
+		RequestID:  "req-1",
+	}
+	mustNoErr(t, r.Audit.Append(ctx, created))
+	if created.ID == 0 || created.CreatedAt.IsZero() {
+		t.Fatalf("Append did not fill ID and CreatedAt: %+v", *created)
+	}
+
+	mustNoErr(t, r.Audit.Append(ctx, &domain.AuditEvent{
+		Actor:      "bob",
+		Action:     domain.AuditActionUserSetActive,
+		EntityType: domain.AuditEntityUser,
+		EntityID:   "u1",
+		Before:     []byte(`{"is_active":true}`),
+		After:      []byte(`{"is_active":false}`),
+	}))
+
+	all, err := r.Audit.List(ctx, domain.AuditFilter{})
+	mustNoErr(t, err)
+	if len(all) != 2 || all[0].Actor != "bob" || all[1].Actor != "alice" {
+		t.Fatalf("List = %+v, want bob then alice (newest first)", all)
+	}
+
+	got := all[1]
+	if got.ID != created.ID || got.Action != created.Action || got.EntityType != created.EntityType ||
+		got.EntityID != created.EntityID || got.RequestID != "req-1" {
+		t.Fatalf("List[1] = %+v, want %+v", got, *created)
+	}
+	if got.Before != nil {
+		t.Fatalf("Before = %s, want nil", got.Before)
+	}
+	if !jsonEqual(t, got.After, created.After) {
+		t.Fatalf("After = %s, want %s", got.After, created.After)
+	}
+
+	byActor, err := r.Audit.List(ctx, domain.AuditFilter{Actor: "alice"})
+	mustNoErr(t, err)
+	if len(byActor) != 1 || byActor[0].ID != created.ID {
+		t.Fatalf("List by actor = %+v, want only alice's event", byActor)
+	}
+
+	byEntity, err := r.Audit.List(ctx, domain.AuditFilter{EntityType: domain.AuditEntityUser, EntityID: "u1"})
+	mustNoErr(t, err)
+	if len(byEntity) != 1 || byEntity[0].Actor != "bob" {
+		t.Fatalf("List by entity = %+v, want only bob's event", byEntity)
+	}
+}
+
+func testAuditPagination(t *testing.T, r app.Repositories) {
+	ctx := t.Context()
+
+	for i := 0; i < 5; i++ {
+		mustNoErr(t, r.Audit.Append(ctx, &domain.AuditEvent{
+			Actor:      "alice",
+			Action:     domain.AuditActionPRCreate,
+			EntityType: domain.AuditEntityPullRequest,
+			EntityID:   "pr",
+		}))
+	}
+
+	var seen []int64
+	filter := domain.AuditFilter{Limit: 2}
+	for {
+		page, err := r.Audit.List(ctx, filter)
+		mustNoErr(t, err)
+		if len(page) > 2 {
+			t.Fatalf("page size = %d, want at most 2", len(page))
+		}
+		if len(page) == 0 {
+			break
+		}
+		for _, e := range page {
+			seen = append(seen, e.ID)
+		}
+		filter.BeforeID = page[len(page)-1].ID
+	}
+
+	if len(seen) != 5 {
+		t.Fatalf("paginated over %d events, want 5", len(seen))
+	}
+	for i := 1; i < len(seen); i++ {
+		if seen[i] >= seen[i-1] {
+			t.Fatalf("ids are not strictly descending: %v", seen)
+		}
+	}
+}
+
+func jsonEqual(t *testing.T, a, b []byte) bool {
+	t.Helper()
+	var va, vb any
+	mustNoErr(t, json.Unmarshal(a, &va))
+	mustNoErr(t, json.Unmarshal(b, &vb))
+	ja, _ := json.Marshal(va)
+	jb, _ := json.Marshal(vb)
+	return string(ja) == string(jb)
+}
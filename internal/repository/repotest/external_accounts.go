@@ -0,0 +1,58 @@
+package repotest
+
+import (
+	"testing"
+
+	"github.com/terps489/avito_tech_internship/internal/app"
+	"github.com/terps489/avito_tech_internship/internal/domain"
+)
+
+func testExternalAccounts(t *testing.T, r app.Repositories) {
+	ctx := t.Context()
+	seedTeam(t, r, "backend", user("u1", true), user("u2", true))
+
+	gh := &domain.ExternalAccount{Provider: domain.ProviderGitHub, Login: "octocat", UserID: "u1"}
+	mustNoErr(t, r.ExternalAccounts.Set(ctx, gh))
+	if gh.CreatedAt.IsZero() {
+		t.Fatalf("Set did not fill CreatedAt: %+v", gh)
+	}
+	mustNoErr(t, r.ExternalAccounts.Set(ctx, &domain.ExternalAccount{
+		Provider: domain.ProviderGitLab, Login: "octocat", UserID: "u2",
+	}))
+
+	got, err := r.ExternalAccounts.Get(ctx, domain.ProviderGitHub, "octocat")
+	mustNoErr(t, err)
+	if got.UserID != "u1" {
+		t.Fatalf("Get = %+v, want u1", got)
+	}
+
+	// Set replaces the mapping of an existing login.
+	mustNoErr(t, r.ExternalAccounts.Set(ctx, &domain.ExternalAccount{
+		Provider: domain.ProviderGitHub, Login: "octocat", UserID: "u2",
+	}))
+	got, err = r.ExternalAccounts.Get(ctx, domain.ProviderGitHub, "octocat")
+	mustNoErr(t, err)
+	if got.UserID != "u2" {
+		t.Fatalf("Get after remap = %+v, want u2", got)
+	}
+
+	_, err = r.ExternalAccounts.Get(ctx, domain.ProviderGitHub, "nobody")
+	mustNotFound(t, "Get", err)
+
+	all, err := r.ExternalAccounts.List(ctx, "")
+	mustNoErr(t, err)
+	if len(all) != 2 || all[0].Provider != domain.ProviderGitHub || all[1].Provider != domain.ProviderGitLab {
+		t.Fatalf("List = %+v, want github then gitlab", all)
+	}
+
+	gitlab, err := r.ExternalAccounts.List(ctx, domain.ProviderGitLab)
+	mustNoErr(t, err)
+	if len(gitlab) != 1 || gitlab[0].UserID != "u2" {
+		t.Fatalf("List(gitlab) = %+v", gitlab)
+	}
+
+	mustNoErr(t, r.ExternalAccounts.Delete(ctx, domain.ProviderGitHub, "octocat"))
+	mustNotFound(t, "Delete twice", r.ExternalAccounts.Delete(ctx, domain.ProviderGitHub, "octocat"))
+	_, err = r.ExternalAccounts.Get(ctx, domain.ProviderGitHub, "octocat")
+	mustNotFound(t, "Get after Delete", err)
+}
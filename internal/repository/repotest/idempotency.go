@@ -0,0 +1,72 @@
+package repotest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/terps489/avito_tech_internship/internal/app"
+	"github.com/terps489/avito_tech_internship/internal/domain"
+)
+
+func testIdempotencyKeys(t *testing.T, r app.Repositories) {
+	ctx := t.Context()
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	reserve := func(scope, key, hash string, at time.Time) bool {
+		t.Helper()
+		ok, err := r.IdempotencyKeys.Reserve(ctx, &domain.IdempotencyKey{
+			Scope: scope, Key: key, RequestHash: hash, CreatedAt: at, ExpiresAt: at.Add(time.Minute),
+		})
+		mustNoErr(t, err)
+		return ok
+	}
+
+	if !reserve("token:1", "k1", "h1", now) {
+		t.Fatal("Reserve of a new key = false")
+	}
+	if reserve("token:1", "k1", "h2", now.Add(time.Second)) {
+		t.Fatal("Reserve of a held key = true")
+	}
+	if !reserve("token:2", "k1", "h1", now) {
+		t.Fatal("Reserve of the same key in another scope = false")
+	}
+
+	got, err := r.IdempotencyKeys.Get(ctx, "token:1", "k1")
+	mustNoErr(t, err)
+	if !got.Pending() || got.RequestHash != "h1" || len(got.Body) != 0 {
+		t.Fatalf("Get before Complete = %+v, want a pending h1", got)
+	}
+	_, err = r.IdempotencyKeys.Get(ctx, "token:1", "missing")
+	mustNotFound(t, "Get", err)
+
+	body := []byte(`{"pr":{"pull_request_id":"pr-1"}}`)
+	mustNoErr(t, r.IdempotencyKeys.Complete(ctx, "token:1", "k1", 201, body, now.Add(24*time.Hour)))
+	got, err = r.IdempotencyKeys.Get(ctx, "token:1", "k1")
+	mustNoErr(t, err)
+	if got.Status != 201 || string(got.Body) != string(body) || !got.ExpiresAt.Equal(now.Add(24*time.Hour)) {
+		t.Fatalf("Get after Complete = %+v", got)
+	}
+	mustNotFound(t, "Complete", r.IdempotencyKeys.Complete(ctx, "token:1", "missing", 200, nil, now))
+
+	// The pending key of token:2 expired after a minute and may be taken over.
+	if !reserve("token:2", "k1", "h3", now.Add(2*time.Minute)) {
+		t.Fatal("Reserve over an expired key = false")
+	}
+	got, err = r.IdempotencyKeys.Get(ctx, "token:2", "k1")
+	mustNoErr(t, err)
+	if got.RequestHash != "h3" || !got.Pending() {
+		t.Fatalf("Get after a takeover = %+v, want a pending h3", got)
+	}
+
+	n, err := r.IdempotencyKeys.DeleteExpired(ctx, now.Add(time.Hour))
+	mustNoErr(t, err)
+	if n != 1 {
+		t.Fatalf("DeleteExpired = %d, want 1", n)
+	}
+	_, err = r.IdempotencyKeys.Get(ctx, "token:2", "k1")
+	mustNotFound(t, "Get after DeleteExpired", err)
+
+	mustNoErr(t, r.IdempotencyKeys.Delete(ctx, "token:1", "k1"))
+	_, err = r.IdempotencyKeys.Get(ctx, "token:1", "k1")
+	mustNotFound(t, "Get after Delete", err)
+}
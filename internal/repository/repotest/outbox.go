@@ -0,0 +1,128 @@
+package repotest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/terps489/avito_tech_internship/internal/app"
+	"github.com/terps489/avito_tech_internship/internal/domain"
+	"github.com/terps489/avito_tech_internship/internal/outbox"
+)
+
+func outboxStore(t *testing.T, r app.Repositories) outbox.Store {
+	t.Helper()
+	store, ok := r.Outbox.(outbox.Store)
+	if !ok {
+		t.Fatalf("%T does not implement outbox.Store", r.Outbox)
+	}
+	return store
+}
+
+func appendOutbox(t *testing.T, r app.Repositories, typ domain.EventType) domain.OutboxEvent {
+	t.Helper()
+	e := domain.OutboxEvent{Type: typ, Payload: []byte(`{"pull_request_id":"pr-1"}`), RequestID: "req-1"}
+	mustNoErr(t, r.Outbox.Append(t.Context(), &e))
+	if e.ID == 0 || e.CreatedAt.IsZero() {
+		t.Fatalf("Append did not fill ID and CreatedAt: %+v", e)
+	}
+	return e
+}
+
+func claimIDs(t *testing.T, store outbox.Store, limit int, lease time.Duration) []int64 {
+	t.Helper()
+	events, err := store.ClaimPending(t.Context(), limit, lease)
+	mustNoErr(t, err)
+	ids := make([]int64, 0, len(events))
+	for _, e := range events {
+		ids = append(ids, e.ID)
+	}
+	return ids
+}
+
+func testOutboxClaim(t *testing.T, r app.Repositories) {
+	ctx := t.Context()
+	store := outboxStore(t, r)
+
+	first := appendOutbox(t, r, domain.EventPRCreated)
+	second := appendOutbox(t, r, domain.EventPRMerged)
+	third := appendOutbox(t, r, domain.EventPRMerged)
+
+	events, err := store.ClaimPending(ctx, 2, time.Minute)
+	mustNoErr(t, err)
+	if len(events) != 2 || events[0].ID != first.ID || events[1].ID != second.ID {
+		t.Fatalf("ClaimPending = %+v, want the two oldest events", events)
+	}
+
+	got := events[0]
+	if got.Type != domain.EventPRCreated || got.RequestID != "req-1" || got.Attempts != 1 {
+		t.Fatalf("claimed event = %+v, want pr.created attempt 1", got)
+	}
+	if !jsonEqual(t, got.Payload, first.Payload) {
+		t.Fatalf("payload = %s, want %s", got.Payload, first.Payload)
+	}
+
+	// Leased events are hidden until the lease expires.
+	if ids := claimIDs(t, store, 10, time.Minute); !equalSlices(ids, []int64{third.ID}) {
+		t.Fatalf("second claim = %v, want [%d]", ids, third.ID)
+	}
+	if ids := claimIDs(t, store, 10, time.Minute); len(ids) != 0 {
+		t.Fatalf("claim with everything leased = %v, want none", ids)
+	}
+}
+
+func testOutboxDeliveryBookkeeping(t *testing.T, r app.Repositories) {
+	ctx := t.Context()
+	store := outboxStore(t, r)
+	e := appendOutbox(t, r, domain.EventPRCreated)
+
+	// A zero lease makes the event due again right away.
+	events, err := store.ClaimPending(ctx, 10, 0)
+	mustNoErr(t, err)
+	if len(events) != 1 {
+		t.Fatalf("ClaimPending = %+v, want 1 event", events)
+	}
+
+	mustNoErr(t, store.MarkDelivered(ctx, e.ID, "webhook"))
+	mustNoErr(t, store.MarkDelivered(ctx, e.ID, "webhook"))
+	mustNoErr(t, store.MarkDelivered(ctx, e.ID, "audit-bus"))
+
+	sinks, err := store.DeliveredSinks(ctx, e.ID)
+	mustNoErr(t, err)
+	if !equalSlices(sinks, []string{"audit-bus", "webhook"}) {
+		t.Fatalf("DeliveredSinks = %v, want [audit-bus webhook]", sinks)
+	}
+
+	events, err = store.ClaimPending(ctx, 10, 0)
+	mustNoErr(t, err)
+	if len(events) != 1 || events[0].Attempts != 2 {
+		t.Fatalf("reclaim = %+v, want attempt 2", events)
+	}
+
+	mustNoErr(t, store.MarkProcessed(ctx, e.ID))
+	if ids := claimIDs(t, store, 10, 0); len(ids) != 0 {
+		t.Fatalf("claim after MarkProcessed = %v, want none", ids)
+	}
+}
+
+func testOutboxRetry(t *testing.T, r app.Repositories) {
+	ctx := t.Context()
+	store := outboxStore(t, r)
+	retried := appendOutbox(t, r, domain.EventPRCreated)
+	postponed := appendOutbox(t, r, domain.EventPRCreated)
+	dead := appendOutbox(t, r, domain.EventPRCreated)
+
+	if ids := claimIDs(t, store, 10, time.Minute); len(ids) != 3 {
+		t.Fatalf("ClaimPending = %v, want 3 events", ids)
+	}
+
+	past := time.Now().Add(-time.Second)
+	mustNoErr(t, store.MarkFailed(ctx, retried.ID, past, "boom", false))
+	mustNoErr(t, store.MarkFailed(ctx, postponed.ID, time.Now().Add(time.Hour), "boom", false))
+	mustNoErr(t, store.MarkFailed(ctx, dead.ID, past, "boom", true))
+
+	events, err := store.ClaimPending(ctx, 10, time.Minute)
+	mustNoErr(t, err)
+	if len(events) != 1 || events[0].ID != retried.ID || events[0].Attempts != 2 {
+		t.Fatalf("claim after MarkFailed = %+v, want only event %d at attempt 2", events, retried.ID)
+	}
+}
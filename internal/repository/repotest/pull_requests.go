@@ -0,0 +1,428 @@
+package repotest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/terps489/avito_tech_internship/internal/app"
+	"github.com/terps489/avito_tech_internship/internal/domain"
+)
+
+func testPullRequestsCreateAndGet(t *testing.T, r app.Repositories) {
+	ctx := t.Context()
+
+	seedTeam(t, r, "backend", user("u1", true), user("u2", true), user("u3", true))
+
+	pr := &domain.PullRequest{
+		ID:          "pr-1",
+		Title:       "Add search",
+		AuthorID:    "u1",
+		Status:      domain.PRStatusOpen,
+		ReviewerIDs: []domain.UserID{"u2", "u3"},
+	}
+	mustNoErr(t, r.PullRequests.Create(ctx, pr))
+
+	if err := r.PullRequests.Create(ctx, pr); err == nil {
+		t.Fatal("Create of duplicate pull request succeeded")
+	}
+
+	exists, err := r.PullRequests.Exists(ctx, "pr-1")
+	mustNoErr(t, err)
+	if !exists {
+		t.Fatal("Exists after Create = false")
+	}
+
+	got, err := r.PullRequests.GetByID(ctx, "pr-1")
+	mustNoErr(t, err)
+	if got.ID != pr.ID || got.Title != pr.Title || got.AuthorID != pr.AuthorID || got.Status != domain.PRStatusOpen {
+		t.Fatalf("GetByID = %+v, want %+v", *got, *pr)
+	}
+	if !sameSet(got.ReviewerIDs, pr.ReviewerIDs) {
+		t.Fatalf("GetByID reviewers = %v, want %v", got.ReviewerIDs, pr.ReviewerIDs)
+	}
+	if got.CreatedAt.IsZero() {
+		t.Fatal("GetByID CreatedAt is zero")
+	}
+	if got.MergedAt != nil {
+		t.Fatalf("GetByID MergedAt = %v for open PR, want nil", got.MergedAt)
+	}
+}
+
+func testPullRequestsNotFound(t *testing.T, r app.Repositories) {
+	ctx := t.Context()
+
+	_, err := r.PullRequests.GetByID(ctx, "missing")
+	mustNotFound(t, "GetByID", err)
+
+	exists, err := r.PullRequests.Exists(ctx, "missing")
+	mustNoErr(t, err)
+	if exists {
+		t.Fatal("Exists of missing PR = true")
+	}
+}
+
+func testPullRequestsUpdateReplacesReviewers(t *testing.T, r app.Repositories) {
+	ctx := t.Context()
+
+	seedTeam(t, r, "backend", user("u1", true), user("u2", true), user("u3", true), user("u4", true))
+	seedPR(t, r, "pr-1", "u1", "u2", "u3")
+
+	pr, err := r.PullRequests.GetByID(ctx, "pr-1")
+	mustNoErr(t, err)
+
+	pr.Title = "renamed"
+	pr.ReviewerIDs = []domain.UserID{"u4", "u3"}
+	mustNoErr(t, r.PullRequests.Update(ctx, pr))
+
+	got, err := r.PullRequests.GetByID(ctx, "pr-1")
+	mustNoErr(t, err)
+	if got.Title != "renamed" {
+		t.Fatalf("title after Update = %q, want renamed", got.Title)
+	}
+	if !sameSet(got.ReviewerIDs, []domain.UserID{"u3", "u4"}) {
+		t.Fatalf("reviewers after Update = %v, want [u3 u4]", got.ReviewerIDs)
+	}
+
+	old, err := r.PullRequests.List(ctx, domain.PullRequestFilter{ReviewerID: "u2"})
+	mustNoErr(t, err)
+	if len(old) != 0 {
+		t.Fatalf("List of replaced reviewer = %v, want empty", prIDs(old))
+	}
+
+	got.ReviewerIDs = nil
+	mustNoErr(t, r.PullRequests.Update(ctx, got))
+
+	got, err = r.PullRequests.GetByID(ctx, "pr-1")
+	mustNoErr(t, err)
+	if len(got.ReviewerIDs) != 0 {
+		t.Fatalf("reviewers after clearing = %v, want empty", got.ReviewerIDs)
+	}
+}
+
+func testPullRequestsMergedAt(t *testing.T, r app.Repositories) {
+	ctx := t.Context()
+
+	seedTeam(t, r, "backend", user("u1", true), user("u2", true))
+	seedPR(t, r, "pr-1", "u1", "u2")
+	seedPR(t, r, "pr-2", "u1", "u2")
+
+	// Merging without MergedAt stamps the current time and reports it back.
+	pr, err := r.PullRequests.GetByID(ctx, "pr-1")
+	mustNoErr(t, err)
+	before := time.Now().Add(-time.Minute)
+	pr.Status = domain.PRStatusMerged
+	mustNoErr(t, r.PullRequests.Update(ctx, pr))
+	if pr.MergedAt == nil {
+		t.Fatal("Update did not set MergedAt on merged PR")
+	}
+
+	got, err := r.PullRequests.GetByID(ctx, "pr-1")
+	mustNoErr(t, err)
+	if got.Status != domain.PRStatusMerged || got.MergedAt == nil {
+		t.Fatalf("GetByID after merge = %+v, want MERGED with merged_at", *got)
+	}
+	if got.MergedAt.Before(before) {
+		t.Fatalf("merged_at = %v, want about now", got.MergedAt)
+	}
+	if got.MergedAt.Sub(*pr.MergedAt).Abs() > time.Millisecond {
+		t.Fatalf("stored merged_at = %v, reported %v", got.MergedAt, pr.MergedAt)
+	}
+
+	// An explicit MergedAt is kept as is.
+	explicit := time.Date(2025, 10, 24, 12, 34, 56, 0, time.UTC)
+	pr2, err := r.PullRequests.GetByID(ctx, "pr-2")
+	mustNoErr(t, err)
+	pr2.Status = domain.PRStatusMerged
+	pr2.MergedAt = &explicit
+	mustNoErr(t, r.PullRequests.Update(ctx, pr2))
+
+	got, err = r.PullRequests.GetByID(ctx, "pr-2")
+	mustNoErr(t, err)
+	if got.MergedAt == nil || !got.MergedAt.Equal(explicit) {
+		t.Fatalf("merged_at = %v, want %v", got.MergedAt, explicit)
+	}
+
+	// An open PR never has merged_at.
+	got.Status = domain.PRStatusOpen
+	mustNoErr(t, r.PullRequests.Update(ctx, got))
+
+	got, err = r.PullRequests.GetByID(ctx, "pr-2")
+	mustNoErr(t, err)
+	if got.MergedAt != nil {
+		t.Fatalf("merged_at of reopened PR = %v, want nil", got.MergedAt)
+	}
+}
+
+func testPullRequestsListByReviewer(t *testing.T, r app.Repositories) {
+	ctx := t.Context()
+
+	seedTeam(t, r, "backend", user("u1", true), user("u2", true), user("u3", true))
+	seedPR(t, r, "pr-1", "u1", "u2", "u3")
+	seedPR(t, r, "pr-2", "u1", "u3")
+	seedPR(t, r, "pr-3", "u1", "u2")
+
+	merged, err := r.PullRequests.GetByID(ctx, "pr-3")
+	mustNoErr(t, err)
+	merged.Status = domain.PRStatusMerged
+	mustNoErr(t, r.PullRequests.Update(ctx, merged))
+
+	list, err := r.PullRequests.List(ctx, domain.PullRequestFilter{ReviewerID: "u2"})
+	mustNoErr(t, err)
+
+	want := []domain.PullRequestID{"pr-1", "pr-3"}
+	if got := prIDs(list); !equalSlices(got, want) {
+		t.Fatalf("List = %v, want %v", got, want)
+	}
+	if list[0].Title != "title-pr-1" || list[0].AuthorID != "u1" || list[0].Status != domain.PRStatusOpen {
+		t.Fatalf("List[0] = %+v", list[0])
+	}
+	if !sameSet(list[0].ReviewerIDs, []domain.UserID{"u2", "u3"}) {
+		t.Fatalf("List[0] reviewers = %v, want [u2 u3]", list[0].ReviewerIDs)
+	}
+	if list[0].CreatedAt.IsZero() || list[0].MergedAt != nil {
+		t.Fatalf("List[0] timestamps = %v, %v", list[0].CreatedAt, list[0].MergedAt)
+	}
+	if list[1].Status != domain.PRStatusMerged || list[1].MergedAt == nil {
+		t.Fatalf("List[1] = %+v, want MERGED with merged_at", list[1])
+	}
+
+	open, err := r.PullRequests.List(ctx, domain.PullRequestFilter{ReviewerID: "u2", Status: domain.PRStatusOpen})
+	mustNoErr(t, err)
+	if got := prIDs(open); !equalSlices(got, []domain.PullRequestID{"pr-1"}) {
+		t.Fatalf("List of open = %v, want [pr-1]", got)
+	}
+
+	none, err := r.PullRequests.List(ctx, domain.PullRequestFilter{ReviewerID: "u1"})
+	mustNoErr(t, err)
+	if len(none) != 0 {
+		t.Fatalf("List of author = %v, want empty", prIDs(none))
+	}
+}
+
+func testPullRequestsListPagination(t *testing.T, r app.Repositories) {
+	ctx := t.Context()
+
+	seedTeam(t, r, "backend", user("u1", true), user("u2", true))
+	for _, id := range []domain.PullRequestID{"pr-1", "pr-2", "pr-3", "pr-4", "pr-5"} {
+		seedPR(t, r, id, "u1", "u2")
+	}
+
+	for _, desc := range []bool{false, true} {
+		want := []domain.PullRequestID{"pr-1", "pr-2", "pr-3", "pr-4", "pr-5"}
+		if desc {
+			want = []domain.PullRequestID{"pr-5", "pr-4", "pr-3", "pr-2", "pr-1"}
+		}
+
+		var (
+			got   []domain.PullRequestID
+			after *domain.PullRequestCursor
+		)
+		for {
+			page, err := r.PullRequests.List(ctx, domain.PullRequestFilter{
+				ReviewerID: "u2",
+				After:      after,
+				Desc:       desc,
+				Limit:      2,
+			})
+			mustNoErr(t, err)
+			if len(page) > 2 {
+				t.Fatalf("page size = %d, want at most 2", len(page))
+			}
+			got = append(got, prIDs(page)...)
+			if len(page) < 2 {
+				break
+			}
+			cursor := page[len(page)-1].Cursor()
+			after = &cursor
+		}
+
+		if !equalSlices(got, want) {
+			t.Fatalf("pages (desc=%v) = %v, want %v", desc, got, want)
+		}
+	}
+}
+
+func testPullRequestsListCreatedRange(t *testing.T, r app.Repositories) {
+	ctx := t.Context()
+
+	seedTeam(t, r, "backend", user("u1", true), user("u2", true))
+	seedPR(t, r, "pr-1", "u1", "u2")
+
+	first, err := r.PullRequests.GetByID(ctx, "pr-1")
+	mustNoErr(t, err)
+
+	// Timestamps come from the storage clock, so step past pr-1 by waiting.
+	time.Sleep(5 * time.Millisecond)
+	seedPR(t, r, "pr-2", "u1", "u2")
+
+	from := first.CreatedAt.Add(time.Millisecond)
+	later, err := r.PullRequests.List(ctx, domain.PullRequestFilter{CreatedFrom: from})
+	mustNoErr(t, err)
+	if got := prIDs(later); !equalSlices(got, []domain.PullRequestID{"pr-2"}) {
+		t.Fatalf("List from %v = %v, want [pr-2]", from, got)
+	}
+
+	earlier, err := r.PullRequests.List(ctx, domain.PullRequestFilter{CreatedTo: from})
+	mustNoErr(t, err)
+	if got := prIDs(earlier); !equalSlices(got, []domain.PullRequestID{"pr-1"}) {
+		t.Fatalf("List to %v = %v, want [pr-1]", from, got)
+	}
+
+	exact, err := r.PullRequests.List(ctx, domain.PullRequestFilter{CreatedFrom: first.CreatedAt, CreatedTo: from})
+	mustNoErr(t, err)
+	if got := prIDs(exact); !equalSlices(got, []domain.PullRequestID{"pr-1"}) {
+		t.Fatalf("List in [%v, %v) = %v, want [pr-1]", first.CreatedAt, from, got)
+	}
+}
+
+func testPullRequestsListSearch(t *testing.T, r app.Repositories) {
+	ctx := t.Context()
+
+	seedTeam(t, r, "backend", user("u1", true), user("u2", true), user("u3", true))
+	seedTeam(t, r, "frontend", user("f1", true), user("f2", true))
+
+	create := func(id domain.PullRequestID, title string, author domain.UserID, reviewers ...domain.UserID) {
+		t.Helper()
+		mustNoErr(t, r.PullRequests.Create(ctx, &domain.PullRequest{
+			ID:          id,
+			Title:       title,
+			AuthorID:    author,
+			Status:      domain.PRStatusOpen,
+			ReviewerIDs: reviewers,
+		}))
+	}
+	create("pr-1", "Add search", "u1", "u2")
+	create("pr-2", "Fix SEARCH index", "u2", "u3")
+	create("pr-3", "Speed up by 100%", "f1", "f2")
+	create("pr-4", "Speed up by 1000", "f1", "f2")
+
+	tests := []struct {
+		name   string
+		filter domain.PullRequestFilter
+		want   []domain.PullRequestID
+	}{
+		{"author", domain.PullRequestFilter{AuthorID: "u1"}, []domain.PullRequestID{"pr-1"}},
+		{"team", domain.PullRequestFilter{TeamName: "backend"}, []domain.PullRequestID{"pr-1", "pr-2"}},
+		{"title ignores case", domain.PullRequestFilter{TitleContains: "search"}, []domain.PullRequestID{"pr-1", "pr-2"}},
+		{"title wildcard is literal", domain.PullRequestFilter{TitleContains: "100%"}, []domain.PullRequestID{"pr-3"}},
+		{"combined", domain.PullRequestFilter{TeamName: "frontend", ReviewerID: "f2", TitleContains: "speed"}, []domain.PullRequestID{"pr-3", "pr-4"}},
+		{"no match", domain.PullRequestFilter{TeamName: "frontend", AuthorID: "u1"}, nil},
+	}
+	for _, tt := range tests {
+		list, err := r.PullRequests.List(ctx, tt.filter)
+		mustNoErr(t, err)
+		if got := prIDs(list); !equalSlices(got, tt.want) {
+			t.Fatalf("%s: List = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func testPullRequestsAssignmentStats(t *testing.T, r app.Repositories) {
+	ctx := t.Context()
+
+	stats, err := r.PullRequests.GetReviewerAssignmentStats(ctx, domain.AssignmentStatsFilter{})
+	mustNoErr(t, err)
+	if len(stats) != 0 {
+		t.Fatalf("stats on empty storage = %+v, want empty", stats)
+	}
+
+	seedTeam(t, r, "backend", user("u1", true), user("u2", true), user("u3", true))
+	seedTeam(t, r, "frontend", user("f1", true))
+	seedPR(t, r, "pr-1", "u1", "u3", "u2")
+	seedPR(t, r, "pr-2", "u1", "u3")
+	seedPR(t, r, "pr-3", "u2", "u3", "u1", "f1")
+
+	merged, err := r.PullRequests.GetByID(ctx, "pr-1")
+	mustNoErr(t, err)
+	merged.Status = domain.PRStatusMerged
+	mustNoErr(t, r.PullRequests.Update(ctx, merged))
+
+	check := func(filter domain.AssignmentStatsFilter, want []domain.ReviewerAssignmentStat) {
+		t.Helper()
+		stats, err := r.PullRequests.GetReviewerAssignmentStats(ctx, filter)
+		mustNoErr(t, err)
+		if !equalSlices(stats, want) {
+			t.Fatalf("GetReviewerAssignmentStats(%+v) = %+v, want %+v", filter, stats, want)
+		}
+	}
+
+	check(domain.AssignmentStatsFilter{}, []domain.ReviewerAssignmentStat{
+		{Key: "f1", Count: 1, Open: 1},
+		{Key: "u1", Count: 1, Open: 1},
+		{Key: "u2", Count: 1, Merged: 1},
+		{Key: "u3", Count: 3, Open: 2, Merged: 1},
+	})
+	check(domain.AssignmentStatsFilter{GroupBy: domain.GroupByTeam}, []domain.ReviewerAssignmentStat{
+		{Key: "backend", Count: 5, Open: 3, Merged: 2},
+		{Key: "frontend", Count: 1, Open: 1},
+	})
+	check(domain.AssignmentStatsFilter{TeamName: "frontend"}, []domain.ReviewerAssignmentStat{
+		{Key: "f1", Count: 1, Open: 1},
+	})
+
+	// All pull requests were created just now, so they share a day and a week.
+	created := merged.CreatedAt.UTC()
+	day := created.Format(time.DateOnly)
+	monday := created.AddDate(0, 0, -((int(created.Weekday()) + 6) % 7)).Format(time.DateOnly)
+	check(domain.AssignmentStatsFilter{GroupBy: domain.GroupByDay}, []domain.ReviewerAssignmentStat{
+		{Key: day, Count: 6, Open: 4, Merged: 2},
+	})
+	check(domain.AssignmentStatsFilter{GroupBy: domain.GroupByWeek, TeamName: "backend"}, []domain.ReviewerAssignmentStat{
+		{Key: monday, Count: 5, Open: 3, Merged: 2},
+	})
+
+	check(domain.AssignmentStatsFilter{From: created.Add(time.Hour)}, nil)
+	check(domain.AssignmentStatsFilter{To: created.Add(-time.Hour)}, nil)
+}
+
+func testPullRequestsMergeTimes(t *testing.T, r app.Repositories) {
+	ctx := t.Context()
+
+	seedTeam(t, r, "backend", user("u1", true), user("u2", true))
+	seedTeam(t, r, "frontend", user("f1", true))
+	seedPR(t, r, "pr-1", "u1", "u2")
+	seedPR(t, r, "pr-2", "f1")
+	seedPR(t, r, "pr-3", "u2", "u1")
+
+	for _, id := range []domain.PullRequestID{"pr-1", "pr-2"} {
+		pr, err := r.PullRequests.GetByID(ctx, id)
+		mustNoErr(t, err)
+		pr.Status = domain.PRStatusMerged
+		mustNoErr(t, r.PullRequests.Update(ctx, pr))
+	}
+
+	all, err := r.PullRequests.ListMergeTimes(ctx, domain.MergeTimesFilter{})
+	mustNoErr(t, err)
+	if len(all) != 2 {
+		t.Fatalf("ListMergeTimes = %+v, want pr-1 and pr-2", all)
+	}
+
+	first := all[0]
+	if first.PullRequestID != "pr-1" || first.AuthorID != "u1" || first.TeamName != "backend" {
+		t.Fatalf("ListMergeTimes[0] = %+v", first)
+	}
+	if first.CreatedAt.IsZero() || first.MergedAt.Before(first.CreatedAt) {
+		t.Fatalf("ListMergeTimes[0] times = %v .. %v", first.CreatedAt, first.MergedAt)
+	}
+
+	frontend, err := r.PullRequests.ListMergeTimes(ctx, domain.MergeTimesFilter{TeamName: "frontend"})
+	mustNoErr(t, err)
+	if len(frontend) != 1 || frontend[0].PullRequestID != "pr-2" {
+		t.Fatalf("ListMergeTimes of frontend = %+v, want pr-2", frontend)
+	}
+
+	later, err := r.PullRequests.ListMergeTimes(ctx, domain.MergeTimesFilter{From: first.MergedAt.Add(time.Hour)})
+	mustNoErr(t, err)
+	if len(later) != 0 {
+		t.Fatalf("ListMergeTimes after the merges = %+v, want empty", later)
+	}
+
+	window, err := r.PullRequests.ListMergeTimes(ctx, domain.MergeTimesFilter{
+		From: first.MergedAt,
+		To:   first.MergedAt.Add(time.Hour),
+	})
+	mustNoErr(t, err)
+	if len(window) != 2 {
+		t.Fatalf("ListMergeTimes in window = %+v, want 2", window)
+	}
+}
@@ -0,0 +1,149 @@
+// Package repotest is a conformance suite for the repository interfaces
+// declared in package app. Every storage backend runs the same suite, so
+// app.Service can rely on identical behavior whatever the storage is.
+package repotest
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/terps489/avito_tech_internship/internal/app"
+	"github.com/terps489/avito_tech_internship/internal/domain"
+)
+
+// Run executes the suite. newRepos is called once per subtest and must
+// return repositories backed by empty storage.
+func Run(t *testing.T, newRepos func(t *testing.T) app.Repositories) {
+	tests := []struct {
+		name string
+		fn   func(t *testing.T, r app.Repositories)
+	}{
+		{"Teams/CreateAndGet", testTeamsCreateAndGet},
+		{"Teams/NotFound", testTeamsNotFound},
+		{"Teams/ListMembersOrdering", testTeamsListMembersOrdering},
+		{"Users/GetAndList", testUsersGetAndList},
+		{"Users/NotFound", testUsersNotFound},
+		{"Users/UpsertUpdatesFields", testUsersUpsertUpdatesFields},
+		{"Users/UpsertMovesBetweenTeams", testUsersUpsertMovesBetweenTeams},
+		{"Users/List", testUsersList},
+		{"Users/CountOpenReviews", testUsersCountOpenReviews},
+		{"PullRequests/CreateAndGet", testPullRequestsCreateAndGet},
+		{"PullRequests/NotFound", testPullRequestsNotFound},
+		{"PullRequests/UpdateReplacesReviewers", testPullRequestsUpdateReplacesReviewers},
+		{"PullRequests/MergedAt", testPullRequestsMergedAt},
+		{"PullRequests/ListByReviewer", testPullRequestsListByReviewer},
+		{"PullRequests/ListPagination", testPullRequestsListPagination},
+		{"PullRequests/ListCreatedRange", testPullRequestsListCreatedRange},
+		{"PullRequests/ListSearch", testPullRequestsListSearch},
+		{"PullRequests/AssignmentStats", testPullRequestsAssignmentStats},
+		{"PullRequests/MergeTimes", testPullRequestsMergeTimes},
+		{"ReviewerEvents/History", testReviewerEventsHistory},
+		{"ReviewerEvents/ReassignmentStats", testReviewerEventsReassignmentStats},
+		{"Outbox/Claim", testOutboxClaim},
+		{"Outbox/DeliveryBookkeeping", testOutboxDeliveryBookkeeping},
+		{"Outbox/Retry", testOutboxRetry},
+		{"Webhooks/CRUD", testWebhooksCRUD},
+		{"Webhooks/Enqueue", testWebhooksEnqueue},
+		{"Webhooks/ClaimAndMark", testWebhooksClaimAndMark},
+		{"Webhooks/GiveUpAndInactive", testWebhooksGiveUpAndInactive},
+		{"Webhooks/Redelivery", testWebhooksRedelivery},
+		{"ExternalAccounts", testExternalAccounts},
+		{"APITokens", testAPITokens},
+		{"IdempotencyKeys", testIdempotencyKeys},
+		{"Audit/AppendAndList", testAuditAppendAndList},
+		{"Audit/Pagination", testAuditPagination},
+		{"Tx/Commit", testTxCommit},
+		{"Tx/Rollback", testTxRollback},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.fn(t, newRepos(t))
+		})
+	}
+}
+
+// ---------- Helpers ----------
+
+func user(id domain.UserID, active bool) domain.User {
+	return domain.User{ID: id, Username: "name-" + string(id), IsActive: active}
+}
+
+func seedTeam(t *testing.T, r app.Repositories, name domain.TeamName, members ...domain.User) {
+	t.Helper()
+	ctx := t.Context()
+	mustNoErr(t, r.Teams.Create(ctx, name))
+	mustNoErr(t, r.Users.UpsertUsersForTeam(ctx, name, members))
+}
+
+func seedPR(t *testing.T, r app.Repositories, id domain.PullRequestID, author domain.UserID, reviewers ...domain.UserID) {
+	t.Helper()
+	ctx := t.Context()
+	mustNoErr(t, r.PullRequests.Create(ctx, &domain.PullRequest{
+		ID:          id,
+		Title:       "title-" + string(id),
+		AuthorID:    author,
+		Status:      domain.PRStatusOpen,
+		ReviewerIDs: reviewers,
+	}))
+}
+
+func userIDs(users []domain.User) []domain.UserID {
+	ids := make([]domain.UserID, 0, len(users))
+	for _, u := range users {
+		ids = append(ids, u.ID)
+	}
+	return ids
+}
+
+func prIDs(prs []domain.PullRequest) []domain.PullRequestID {
+	ids := make([]domain.PullRequestID, 0, len(prs))
+	for _, pr := range prs {
+		ids = append(ids, pr.ID)
+	}
+	return ids
+}
+
+func sameSet(a, b []domain.UserID) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	seen := make(map[domain.UserID]int, len(a))
+	for _, id := range a {
+		seen[id]++
+	}
+	for _, id := range b {
+		if seen[id] == 0 {
+			return false
+		}
+		seen[id]--
+	}
+	return true
+}
+
+func equalSlices[T comparable](a, b []T) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func mustNoErr(t *testing.T, err error) {
+	t.Helper()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func mustNotFound(t *testing.T, op string, err error) {
+	t.Helper()
+	if !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("%s error = %v, want sql.ErrNoRows", op, err)
+	}
+}
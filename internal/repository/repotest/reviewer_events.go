@@ -0,0 +1,84 @@
+package repotest
+
+import (
+	"testing"
+
+	"github.com/terps489/avito_tech_internship/internal/app"
+	"github.com/terps489/avito_tech_internship/internal/domain"
+)
+
+func testReviewerEventsHistory(t *testing.T, r app.Repositories) {
+	ctx := t.Context()
+	seedTeam(t, r, "backend", user("u1", true), user("u2", true), user("u3", true))
+	seedPR(t, r, "pr-1", "u1", "u2")
+	seedPR(t, r, "pr-2", "u1", "u3")
+
+	events := []domain.ReviewerEvent{
+		{PullRequestID: "pr-1", Type: domain.ReviewerEventAssigned, ToUserID: "u2", Reason: "auto_assign", Actor: "alice"},
+		{PullRequestID: "pr-2", Type: domain.ReviewerEventAssigned, ToUserID: "u3", Reason: "auto_assign", Actor: "alice"},
+		{PullRequestID: "pr-1", Type: domain.ReviewerEventReassigned, FromUserID: "u2", ToUserID: "u3", Reason: "vacation", Actor: "bob"},
+		{PullRequestID: "pr-1", Type: domain.ReviewerEventRemoved, FromUserID: "u3", Reason: "left team", Actor: "bob"},
+	}
+	for i := range events {
+		mustNoErr(t, r.ReviewerEvents.Append(ctx, &events[i]))
+		if events[i].ID == 0 || events[i].CreatedAt.IsZero() {
+			t.Fatalf("Append did not fill ID and CreatedAt: %+v", events[i])
+		}
+	}
+
+	history, err := r.ReviewerEvents.ListByPullRequest(ctx, "pr-1")
+	mustNoErr(t, err)
+	if len(history) != 3 {
+		t.Fatalf("ListByPullRequest = %+v, want 3 events", history)
+	}
+
+	for i, want := range []domain.ReviewerEvent{events[0], events[2], events[3]} {
+		got := history[i]
+		if got.ID != want.ID || got.Type != want.Type || got.FromUserID != want.FromUserID ||
+			got.ToUserID != want.ToUserID || got.Reason != want.Reason || got.Actor != want.Actor {
+			t.Fatalf("history[%d] = %+v, want %+v", i, got, want)
+		}
+	}
+
+	none, err := r.ReviewerEvents.ListByPullRequest(ctx, "missing")
+	mustNoErr(t, err)
+	if len(none) != 0 {
+		t.Fatalf("ListByPullRequest of missing PR = %+v, want empty", none)
+	}
+}
+
+func testReviewerEventsReassignmentStats(t *testing.T, r app.Repositories) {
+	ctx := t.Context()
+	seedTeam(t, r, "backend", user("u1", true), user("u2", true), user("u3", true))
+	seedPR(t, r, "pr-1", "u1", "u2")
+
+	reassign := func(from, to domain.UserID) {
+		mustNoErr(t, r.ReviewerEvents.Append(ctx, &domain.ReviewerEvent{
+			PullRequestID: "pr-1",
+			Type:          domain.ReviewerEventReassigned,
+			FromUserID:    from,
+			ToUserID:      to,
+			Reason:        "manual_reassign",
+			Actor:         "alice",
+		}))
+	}
+	reassign("u2", "u3")
+	reassign("u3", "u2")
+	reassign("u2", "u3")
+
+	// Assignments and removals are not reassignments.
+	mustNoErr(t, r.ReviewerEvents.Append(ctx, &domain.ReviewerEvent{
+		PullRequestID: "pr-1", Type: domain.ReviewerEventRemoved, FromUserID: "u1", Reason: "x", Actor: "alice",
+	}))
+
+	stats, err := r.ReviewerEvents.GetReassignmentStats(ctx)
+	mustNoErr(t, err)
+
+	want := []domain.ReassignmentStat{
+		{UserID: "u2", Count: 2},
+		{UserID: "u3", Count: 1},
+	}
+	if !equalSlices(stats, want) {
+		t.Fatalf("GetReassignmentStats = %+v, want %+v", stats, want)
+	}
+}
@@ -0,0 +1,72 @@
+package repotest
+
+import (
+	"testing"
+
+	"github.com/terps489/avito_tech_internship/internal/app"
+	"github.com/terps489/avito_tech_internship/internal/domain"
+)
+
+func testTeamsCreateAndGet(t *testing.T, r app.Repositories) {
+	ctx := t.Context()
+
+	exists, err := r.Teams.Exists(ctx, "backend")
+	mustNoErr(t, err)
+	if exists {
+		t.Fatal("Exists on empty storage = true")
+	}
+
+	mustNoErr(t, r.Teams.Create(ctx, "backend"))
+
+	if err := r.Teams.Create(ctx, "backend"); err == nil {
+		t.Fatal("Create of duplicate team succeeded")
+	}
+
+	exists, err = r.Teams.Exists(ctx, "backend")
+	mustNoErr(t, err)
+	if !exists {
+		t.Fatal("Exists after Create = false")
+	}
+
+	team, err := r.Teams.GetByName(ctx, "backend")
+	mustNoErr(t, err)
+	if team.Name != "backend" {
+		t.Fatalf("GetByName name = %q, want backend", team.Name)
+	}
+}
+
+func testTeamsNotFound(t *testing.T, r app.Repositories) {
+	ctx := t.Context()
+
+	_, err := r.Teams.GetByName(ctx, "missing")
+	mustNotFound(t, "GetByName", err)
+
+	members, err := r.Teams.ListMembers(ctx, "missing")
+	mustNoErr(t, err)
+	if len(members) != 0 {
+		t.Fatalf("ListMembers of missing team = %v, want empty", members)
+	}
+}
+
+func testTeamsListMembersOrdering(t *testing.T, r app.Repositories) {
+	ctx := t.Context()
+
+	seedTeam(t, r, "backend", user("u3", true), user("u1", false), user("u2", true))
+	seedTeam(t, r, "frontend", user("u0", true))
+
+	members, err := r.Teams.ListMembers(ctx, "backend")
+	mustNoErr(t, err)
+
+	want := []domain.UserID{"u1", "u2", "u3"}
+	if got := userIDs(members); !equalSlices(got, want) {
+		t.Fatalf("ListMembers = %v, want %v (ordered by user_id, inactive included)", got, want)
+	}
+	if members[0].IsActive {
+		t.Fatal("ListMembers lost is_active = false of u1")
+	}
+	for _, m := range members {
+		if m.TeamName != "backend" {
+			t.Fatalf("ListMembers member %s team = %q, want backend", m.ID, m.TeamName)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package repotest
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/terps489/avito_tech_internship/internal/app"
+	"github.com/terps489/avito_tech_internship/internal/domain"
+)
+
+func testTxCommit(t *testing.T, r app.Repositories) {
+	ctx := t.Context()
+
+	err := r.Tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := r.Teams.Create(ctx, "backend"); err != nil {
+			return err
+		}
+		if err := r.Users.UpsertUsersForTeam(ctx, "backend", []domain.User{user("u1", true)}); err != nil {
+			return err
+		}
+
+		// Changes are visible inside the transaction.
+		_, err := r.Users.GetByID(ctx, "u1")
+		return err
+	})
+	mustNoErr(t, err)
+
+	_, err = r.Users.GetByID(ctx, "u1")
+	mustNoErr(t, err)
+}
+
+func testTxRollback(t *testing.T, r app.Repositories) {
+	ctx := t.Context()
+	seedTeam(t, r, "backend", user("u1", true))
+
+	errAbort := errors.New("abort")
+	err := r.Tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := r.Teams.Create(ctx, "frontend"); err != nil {
+			return err
+		}
+		if err := r.Users.SetIsActive(ctx, "u1", false); err != nil {
+			return err
+		}
+		if err := r.Audit.Append(ctx, &domain.AuditEvent{
+			Actor:      "alice",
+			Action:     domain.AuditActionUserSetActive,
+			EntityType: domain.AuditEntityUser,
+			EntityID:   "u1",
+		}); err != nil {
+			return err
+		}
+		if err := r.Outbox.Append(ctx, &domain.OutboxEvent{
+			Type:    domain.EventUserDeactivated,
+			Payload: []byte(`{"user_id":"u1"}`),
+		}); err != nil {
+			return err
+		}
+		return errAbort
+	})
+	if !errors.Is(err, errAbort) {
+		t.Fatalf("WithinTx error = %v, want the error returned by fn", err)
+	}
+
+	exists, err := r.Teams.Exists(ctx, "frontend")
+	mustNoErr(t, err)
+	if exists {
+		t.Fatal("team created in rolled back transaction exists")
+	}
+
+	u, err := r.Users.GetByID(ctx, "u1")
+	mustNoErr(t, err)
+	if !u.IsActive {
+		t.Fatal("SetIsActive from rolled back transaction was applied")
+	}
+
+	events, err := r.Audit.List(ctx, domain.AuditFilter{})
+	mustNoErr(t, err)
+	if len(events) != 0 {
+		t.Fatalf("audit events after rollback = %+v, want none", events)
+	}
+
+	claimed, err := outboxStore(t, r).ClaimPending(ctx, 10, 0)
+	mustNoErr(t, err)
+	if len(claimed) != 0 {
+		t.Fatalf("outbox events after rollback = %+v, want none", claimed)
+	}
+}
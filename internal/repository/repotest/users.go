@@ -0,0 +1,162 @@
+package repotest
+
+import (
+	"testing"
+
+	"github.com/terps489/avito_tech_internship/internal/app"
+	"github.com/terps489/avito_tech_internship/internal/domain"
+)
+
+func testUsersGetAndList(t *testing.T, r app.Repositories) {
+	ctx := t.Context()
+
+	seedTeam(t, r, "backend", user("u1", true), user("u2", false))
+	seedTeam(t, r, "frontend", user("u3", true))
+
+	u, err := r.Users.GetByID(ctx, "u1")
+	mustNoErr(t, err)
+	want := domain.User{ID: "u1", Username: "name-u1", IsActive: true, TeamName: "backend"}
+	if *u != want {
+		t.Fatalf("GetByID = %+v, want %+v", *u, want)
+	}
+
+	active, err := r.Users.ListActiveByTeam(ctx, "backend")
+	mustNoErr(t, err)
+	if ids := userIDs(active); !sameSet(ids, []domain.UserID{"u1"}) {
+		t.Fatalf("ListActiveByTeam = %v, want [u1]", ids)
+	}
+
+	mustNoErr(t, r.Users.SetIsActive(ctx, "u2", true))
+
+	active, err = r.Users.ListActiveByTeam(ctx, "backend")
+	mustNoErr(t, err)
+	if ids := userIDs(active); !sameSet(ids, []domain.UserID{"u1", "u2"}) {
+		t.Fatalf("ListActiveByTeam after SetIsActive = %v, want [u1 u2]", ids)
+	}
+}
+
+func testUsersNotFound(t *testing.T, r app.Repositories) {
+	ctx := t.Context()
+
+	_, err := r.Users.GetByID(ctx, "missing")
+	mustNotFound(t, "GetByID", err)
+
+	err = r.Users.SetIsActive(ctx, "missing", false)
+	mustNotFound(t, "SetIsActive", err)
+}
+
+func testUsersUpsertUpdatesFields(t *testing.T, r app.Repositories) {
+	ctx := t.Context()
+
+	seedTeam(t, r, "backend", user("u1", true))
+
+	mustNoErr(t, r.Users.UpsertUsersForTeam(ctx, "backend", []domain.User{
+		{ID: "u1", Username: "renamed", IsActive: false},
+	}))
+
+	u, err := r.Users.GetByID(ctx, "u1")
+	mustNoErr(t, err)
+	want := domain.User{ID: "u1", Username: "renamed", IsActive: false, TeamName: "backend"}
+	if *u != want {
+		t.Fatalf("GetByID after upsert = %+v, want %+v", *u, want)
+	}
+}
+
+func testUsersUpsertMovesBetweenTeams(t *testing.T, r app.Repositories) {
+	ctx := t.Context()
+
+	seedTeam(t, r, "backend", user("u1", true), user("u2", true))
+	seedTeam(t, r, "frontend", user("u1", true))
+
+	u, err := r.Users.GetByID(ctx, "u1")
+	mustNoErr(t, err)
+	if u.TeamName != "frontend" {
+		t.Fatalf("team after upsert into frontend = %q, want frontend", u.TeamName)
+	}
+
+	backend, err := r.Teams.ListMembers(ctx, "backend")
+	mustNoErr(t, err)
+	if got := userIDs(backend); !equalSlices(got, []domain.UserID{"u2"}) {
+		t.Fatalf("backend members = %v, want [u2]", got)
+	}
+
+	active, err := r.Users.ListActiveByTeam(ctx, "backend")
+	mustNoErr(t, err)
+	if got := userIDs(active); !sameSet(got, []domain.UserID{"u2"}) {
+		t.Fatalf("backend active members = %v, want [u2]", got)
+	}
+}
+
+func testUsersList(t *testing.T, r app.Repositories) {
+	ctx := t.Context()
+
+	seedTeam(t, r, "backend",
+		domain.User{ID: "u1", Username: "Alice", IsActive: true},
+		domain.User{ID: "u2", Username: "alex", IsActive: false},
+		domain.User{ID: "u3", Username: "bob", IsActive: true},
+	)
+	seedTeam(t, r, "frontend",
+		domain.User{ID: "f1", Username: "al_x", IsActive: true},
+		domain.User{ID: "f2", Username: "carol", IsActive: true},
+	)
+
+	active := true
+	tests := []struct {
+		name   string
+		filter domain.UserFilter
+		want   []domain.UserID
+	}{
+		{"all by username", domain.UserFilter{}, []domain.UserID{"u1", "f1", "u2", "u3", "f2"}},
+		{"prefix ignores case", domain.UserFilter{UsernamePrefix: "AL"}, []domain.UserID{"u1", "f1", "u2"}},
+		{"prefix wildcard is literal", domain.UserFilter{UsernamePrefix: "al_"}, []domain.UserID{"f1"}},
+		{"team", domain.UserFilter{TeamName: "frontend"}, []domain.UserID{"f1", "f2"}},
+		{"active", domain.UserFilter{UsernamePrefix: "al", IsActive: &active}, []domain.UserID{"u1", "f1"}},
+	}
+	for _, tt := range tests {
+		list, err := r.Users.List(ctx, tt.filter)
+		mustNoErr(t, err)
+		if got := userIDs(list); !equalSlices(got, tt.want) {
+			t.Fatalf("%s: List = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	var (
+		got   []domain.UserID
+		after *domain.UserCursor
+	)
+	for {
+		page, err := r.Users.List(ctx, domain.UserFilter{After: after, Limit: 2})
+		mustNoErr(t, err)
+		got = append(got, userIDs(page)...)
+		if len(page) < 2 {
+			break
+		}
+		cursor := page[len(page)-1].Cursor()
+		after = &cursor
+	}
+	if want := []domain.UserID{"u1", "f1", "u2", "u3", "f2"}; !equalSlices(got, want) {
+		t.Fatalf("pages = %v, want %v", got, want)
+	}
+}
+
+func testUsersCountOpenReviews(t *testing.T, r app.Repositories) {
+	ctx := t.Context()
+
+	seedTeam(t, r, "backend", user("u1", true), user("u2", true), user("u3", true))
+	seedPR(t, r, "pr-1", "u1", "u2")
+	seedPR(t, r, "pr-2", "u1", "u2", "u3")
+	seedPR(t, r, "pr-3", "u1", "u2")
+
+	merged, err := r.PullRequests.GetByID(ctx, "pr-3")
+	mustNoErr(t, err)
+	merged.Status = domain.PRStatusMerged
+	mustNoErr(t, r.PullRequests.Update(ctx, merged))
+
+	for id, want := range map[domain.UserID]int{"u1": 0, "u2": 2, "u3": 1} {
+		n, err := r.Users.CountOpenReviews(ctx, id)
+		mustNoErr(t, err)
+		if n != want {
+			t.Fatalf("CountOpenReviews(%s) = %d, want %d", id, n, want)
+		}
+	}
+}
@@ -0,0 +1,257 @@
+package repotest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/terps489/avito_tech_internship/internal/app"
+	"github.com/terps489/avito_tech_internship/internal/domain"
+	"github.com/terps489/avito_tech_internship/internal/webhook"
+)
+
+func webhookStore(t *testing.T, r app.Repositories) webhook.Store {
+	t.Helper()
+	store, ok := r.Webhooks.(webhook.Store)
+	if !ok {
+		t.Fatalf("%T does not implement webhook.Store", r.Webhooks)
+	}
+	return store
+}
+
+func seedWebhook(t *testing.T, r app.Repositories, active bool, types ...domain.EventType) *domain.Webhook {
+	t.Helper()
+	w := &domain.Webhook{
+		URL:        "https://example.com/hook",
+		Secret:     "s3cret",
+		EventTypes: types,
+		IsActive:   active,
+	}
+	mustNoErr(t, r.Webhooks.Create(t.Context(), w))
+	if w.ID == 0 || w.CreatedAt.IsZero() {
+		t.Fatalf("Create did not fill ID and CreatedAt: %+v", *w)
+	}
+	return w
+}
+
+func listDeliveries(t *testing.T, r app.Repositories, filter domain.WebhookDeliveryFilter) []domain.WebhookDelivery {
+	t.Helper()
+	deliveries, err := r.Webhooks.ListDeliveries(t.Context(), filter)
+	mustNoErr(t, err)
+	return deliveries
+}
+
+func testWebhooksCRUD(t *testing.T, r app.Repositories) {
+	ctx := t.Context()
+
+	created := seedWebhook(t, r, true, domain.EventPRMerged, domain.EventPRCreated)
+	other := seedWebhook(t, r, false, domain.EventUserDeactivated)
+
+	got, err := r.Webhooks.GetByID(ctx, created.ID)
+	mustNoErr(t, err)
+	if got.URL != created.URL || got.Secret != "s3cret" || !got.IsActive {
+		t.Fatalf("GetByID = %+v, want the created webhook", got)
+	}
+	// Event types come back sorted.
+	if want := []domain.EventType{domain.EventPRCreated, domain.EventPRMerged}; !equalSlices(got.EventTypes, want) {
+		t.Fatalf("EventTypes = %v, want %v", got.EventTypes, want)
+	}
+
+	got.URL = "https://example.com/other"
+	got.EventTypes = []domain.EventType{domain.EventPRReviewerReassigned}
+	got.IsActive = false
+	mustNoErr(t, r.Webhooks.Update(ctx, got))
+
+	updated, err := r.Webhooks.GetByID(ctx, created.ID)
+	mustNoErr(t, err)
+	if updated.URL != "https://example.com/other" || updated.IsActive ||
+		!equalSlices(updated.EventTypes, []domain.EventType{domain.EventPRReviewerReassigned}) {
+		t.Fatalf("after Update = %+v", updated)
+	}
+
+	all, err := r.Webhooks.List(ctx)
+	mustNoErr(t, err)
+	if len(all) != 2 || all[0].ID != created.ID || all[1].ID != other.ID {
+		t.Fatalf("List = %+v, want both webhooks ordered by id", all)
+	}
+	if !equalSlices(all[1].EventTypes, []domain.EventType{domain.EventUserDeactivated}) {
+		t.Fatalf("List event types = %v", all[1].EventTypes)
+	}
+
+	mustNoErr(t, r.Webhooks.Delete(ctx, created.ID))
+	_, err = r.Webhooks.GetByID(ctx, created.ID)
+	mustNotFound(t, "GetByID after Delete", err)
+	mustNotFound(t, "Delete twice", r.Webhooks.Delete(ctx, created.ID))
+	mustNotFound(t, "Update missing", r.Webhooks.Update(ctx, &domain.Webhook{ID: created.ID, URL: "https://x"}))
+}
+
+func testWebhooksEnqueue(t *testing.T, r app.Repositories) {
+	ctx := t.Context()
+	store := webhookStore(t, r)
+
+	merged := seedWebhook(t, r, true, domain.EventPRMerged)
+	seedWebhook(t, r, true, domain.EventPRCreated)
+	seedWebhook(t, r, false, domain.EventPRMerged)
+
+	e := appendOutbox(t, r, domain.EventPRMerged)
+	mustNoErr(t, store.EnqueueDeliveries(ctx, e))
+	mustNoErr(t, store.EnqueueDeliveries(ctx, e))
+
+	deliveries := listDeliveries(t, r, domain.WebhookDeliveryFilter{})
+	if len(deliveries) != 1 {
+		t.Fatalf("deliveries = %+v, want one for the active pr.merged subscriber", deliveries)
+	}
+	d := deliveries[0]
+	if d.WebhookID != merged.ID || d.EventID != e.ID || d.EventType != domain.EventPRMerged ||
+		d.Status != domain.WebhookDeliveryPending || d.Attempts != 0 || d.RedeliveryOf != nil {
+		t.Fatalf("delivery = %+v", d)
+	}
+
+	got, err := r.Webhooks.GetDelivery(ctx, d.ID)
+	mustNoErr(t, err)
+	if got.ID != d.ID || got.WebhookID != merged.ID {
+		t.Fatalf("GetDelivery = %+v, want %+v", got, d)
+	}
+	_, err = r.Webhooks.GetDelivery(ctx, d.ID+100)
+	mustNotFound(t, "GetDelivery", err)
+}
+
+func testWebhooksClaimAndMark(t *testing.T, r app.Repositories) {
+	ctx := t.Context()
+	store := webhookStore(t, r)
+
+	w := seedWebhook(t, r, true, domain.EventPRMerged)
+	e := appendOutbox(t, r, domain.EventPRMerged)
+	mustNoErr(t, store.EnqueueDeliveries(ctx, e))
+
+	jobs, err := store.ClaimDeliveries(ctx, 10, time.Minute)
+	mustNoErr(t, err)
+	if len(jobs) != 1 {
+		t.Fatalf("ClaimDeliveries = %+v, want 1 job", jobs)
+	}
+	job := jobs[0]
+	if job.URL != w.URL || job.Secret != "s3cret" || job.Delivery.Attempts != 1 {
+		t.Fatalf("job = %+v", job)
+	}
+	if job.Event.ID != e.ID || job.Event.Type != e.Type || job.Event.RequestID != "req-1" ||
+		!jsonEqual(t, job.Event.Payload, e.Payload) {
+		t.Fatalf("job event = %+v, want %+v", job.Event, e)
+	}
+
+	// Leased deliveries are hidden until the lease expires.
+	jobs, err = store.ClaimDeliveries(ctx, 10, time.Minute)
+	mustNoErr(t, err)
+	if len(jobs) != 0 {
+		t.Fatalf("second claim = %+v, want none", jobs)
+	}
+
+	id := job.Delivery.ID
+	mustNoErr(t, store.MarkDeliveryFailed(ctx, id, 503, "unavailable", time.Now().Add(-time.Second), false))
+
+	failed, err := r.Webhooks.GetDelivery(ctx, id)
+	mustNoErr(t, err)
+	if failed.Status != domain.WebhookDeliveryPending || failed.ResponseStatus != 503 || failed.LastError != "unavailable" {
+		t.Fatalf("after MarkDeliveryFailed = %+v", failed)
+	}
+
+	jobs, err = store.ClaimDeliveries(ctx, 10, time.Minute)
+	mustNoErr(t, err)
+	if len(jobs) != 1 || jobs[0].Delivery.Attempts != 2 {
+		t.Fatalf("retry claim = %+v, want attempt 2", jobs)
+	}
+
+	mustNoErr(t, store.MarkDeliverySucceeded(ctx, id, 204))
+	done, err := r.Webhooks.GetDelivery(ctx, id)
+	mustNoErr(t, err)
+	if done.Status != domain.WebhookDeliverySucceeded || done.ResponseStatus != 204 ||
+		done.LastError != "" || done.DeliveredAt == nil {
+		t.Fatalf("after MarkDeliverySucceeded = %+v", done)
+	}
+
+	succeeded := listDeliveries(t, r, domain.WebhookDeliveryFilter{Status: domain.WebhookDeliverySucceeded})
+	if len(succeeded) != 1 {
+		t.Fatalf("status filter = %+v, want the succeeded delivery", succeeded)
+	}
+}
+
+func testWebhooksGiveUpAndInactive(t *testing.T, r app.Repositories) {
+	ctx := t.Context()
+	store := webhookStore(t, r)
+
+	w := seedWebhook(t, r, true, domain.EventPRMerged, domain.EventPRCreated)
+	dead := appendOutbox(t, r, domain.EventPRMerged)
+	mustNoErr(t, store.EnqueueDeliveries(ctx, dead))
+
+	jobs, err := store.ClaimDeliveries(ctx, 10, 0)
+	mustNoErr(t, err)
+	if len(jobs) != 1 {
+		t.Fatalf("ClaimDeliveries = %+v, want 1 job", jobs)
+	}
+	mustNoErr(t, store.MarkDeliveryFailed(ctx, jobs[0].Delivery.ID, 0, "connection refused", time.Now().Add(-time.Second), true))
+
+	failed, err := r.Webhooks.GetDelivery(ctx, jobs[0].Delivery.ID)
+	mustNoErr(t, err)
+	if failed.Status != domain.WebhookDeliveryFailed || failed.ResponseStatus != 0 {
+		t.Fatalf("dead delivery = %+v", failed)
+	}
+
+	// Deliveries of a deactivated webhook wait until it is enabled again.
+	pending := appendOutbox(t, r, domain.EventPRCreated)
+	mustNoErr(t, store.EnqueueDeliveries(ctx, pending))
+	w.IsActive = false
+	mustNoErr(t, r.Webhooks.Update(ctx, w))
+
+	jobs, err = store.ClaimDeliveries(ctx, 10, 0)
+	mustNoErr(t, err)
+	if len(jobs) != 0 {
+		t.Fatalf("claim with inactive webhook = %+v, want none", jobs)
+	}
+
+	w.IsActive = true
+	mustNoErr(t, r.Webhooks.Update(ctx, w))
+	jobs, err = store.ClaimDeliveries(ctx, 10, 0)
+	mustNoErr(t, err)
+	if len(jobs) != 1 || jobs[0].Event.ID != pending.ID {
+		t.Fatalf("claim after reactivation = %+v, want event %d", jobs, pending.ID)
+	}
+}
+
+func testWebhooksRedelivery(t *testing.T, r app.Repositories) {
+	ctx := t.Context()
+	store := webhookStore(t, r)
+
+	w := seedWebhook(t, r, true, domain.EventPRMerged)
+	e := appendOutbox(t, r, domain.EventPRMerged)
+	mustNoErr(t, store.EnqueueDeliveries(ctx, e))
+	original := listDeliveries(t, r, domain.WebhookDeliveryFilter{WebhookID: w.ID})[0]
+
+	redelivery := &domain.WebhookDelivery{
+		WebhookID:    w.ID,
+		EventID:      e.ID,
+		EventType:    e.Type,
+		RedeliveryOf: &original.ID,
+	}
+	mustNoErr(t, r.Webhooks.CreateDelivery(ctx, redelivery))
+	if redelivery.ID == 0 || redelivery.Status != domain.WebhookDeliveryPending || redelivery.CreatedAt.IsZero() {
+		t.Fatalf("CreateDelivery did not fill generated fields: %+v", redelivery)
+	}
+
+	// Fan-out stays idempotent with a redelivery present.
+	mustNoErr(t, store.EnqueueDeliveries(ctx, e))
+
+	all := listDeliveries(t, r, domain.WebhookDeliveryFilter{WebhookID: w.ID})
+	if len(all) != 2 || all[0].ID != redelivery.ID || all[1].ID != original.ID {
+		t.Fatalf("deliveries = %+v, want redelivery then original", all)
+	}
+	if all[0].RedeliveryOf == nil || *all[0].RedeliveryOf != original.ID {
+		t.Fatalf("RedeliveryOf = %v, want %d", all[0].RedeliveryOf, original.ID)
+	}
+
+	page := listDeliveries(t, r, domain.WebhookDeliveryFilter{WebhookID: w.ID, BeforeID: redelivery.ID, Limit: 1})
+	if len(page) != 1 || page[0].ID != original.ID {
+		t.Fatalf("second page = %+v, want the original delivery", page)
+	}
+
+	mustNoErr(t, r.Webhooks.Delete(ctx, w.ID))
+	_, err := r.Webhooks.GetDelivery(ctx, original.ID)
+	mustNotFound(t, "GetDelivery after webhook Delete", err)
+}
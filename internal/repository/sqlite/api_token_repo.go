@@ -0,0 +1,119 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/terps489/avito_tech_internship/internal/domain"
+)
+
+type APITokenRepository struct {
+	db *sql.DB
+}
+
+func NewAPITokenRepository(db *sql.DB) *APITokenRepository {
+	return &APITokenRepository{db: db}
+}
+
+func (r *APITokenRepository) Create(ctx context.Context, t *domain.APIToken) error {
+	const query = `
+		INSERT INTO api_tokens (name, token_hash, role, team_name, user_id, created_at)
+		VALUES ($1, $2, $3, NULLIF($4, ''), NULLIF($5, ''), $6)
+		RETURNING id, created_at
+	`
+
+	return conn(ctx, r.db).QueryRowContext(ctx, query, t.Name, t.Hash, t.Role, t.TeamName, t.UserID, formatTime(time.Now())).
+		Scan(&t.ID, &t.CreatedAt)
+}
+
+func (r *APITokenRepository) GetByID(ctx context.Context, id int64) (*domain.APIToken, error) {
+	const query = `
+		SELECT id, name, token_hash, role, COALESCE(team_name, ''), COALESCE(user_id, ''), created_at, revoked_at
+		FROM api_tokens
+		WHERE id = $1
+	`
+
+	return scanAPIToken(conn(ctx, r.db).QueryRowContext(ctx, query, id))
+}
+
+func (r *APITokenRepository) GetByHash(ctx context.Context, hash string) (*domain.APIToken, error) {
+	const query = `
+		SELECT id, name, token_hash, role, COALESCE(team_name, ''), COALESCE(user_id, ''), created_at, revoked_at
+		FROM api_tokens
+		WHERE token_hash = $1
+	`
+
+	return scanAPIToken(conn(ctx, r.db).QueryRowContext(ctx, query, hash))
+}
+
+// List returns all tokens, revoked ones included, ordered by id.
+func (r *APITokenRepository) List(ctx context.Context) ([]domain.APIToken, error) {
+	const query = `
+		SELECT id, name, token_hash, role, COALESCE(team_name, ''), COALESCE(user_id, ''), created_at, revoked_at
+		FROM api_tokens
+		ORDER BY id
+	`
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var tokens []domain.APIToken
+	for rows.Next() {
+		t, err := scanAPIToken(rows)
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, *t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return tokens, nil
+}
+
+// Revoke marks the token revoked at at; revoking it again keeps the
+// original time.
+func (r *APITokenRepository) Revoke(ctx context.Context, id int64, at time.Time) error {
+	const query = `
+		UPDATE api_tokens
+		SET revoked_at = COALESCE(revoked_at, $2)
+		WHERE id = $1
+	`
+
+	res, err := conn(ctx, r.db).ExecContext(ctx, query, id, formatTime(at))
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+func scanAPIToken(row interface{ Scan(dest ...any) error }) (*domain.APIToken, error) {
+	var (
+		t         domain.APIToken
+		revokedAt sql.NullTime
+	)
+	if err := row.Scan(&t.ID, &t.Name, &t.Hash, &t.Role, &t.TeamName, &t.UserID, &t.CreatedAt, &revokedAt); err != nil {
+		return nil, err
+	}
+	if revokedAt.Valid {
+		at := revokedAt.Time
+		t.RevokedAt = &at
+	}
+	return &t, nil
+}
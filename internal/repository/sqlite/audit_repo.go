@@ -0,0 +1,102 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/terps489/avito_tech_internship/internal/domain"
+)
+
+type AuditRepository struct {
+	db *sql.DB
+}
+
+func NewAuditRepository(db *sql.DB) *AuditRepository {
+	return &AuditRepository{db: db}
+}
+
+func (r *AuditRepository) Append(ctx context.Context, e *domain.AuditEvent) error {
+	const query = `
+		INSERT INTO audit_events (actor, action, entity_type, entity_id, before, after, request_id, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id, created_at
+	`
+
+	return conn(ctx, r.db).QueryRowContext(ctx, query,
+		e.Actor, e.Action, e.EntityType, e.EntityID, nullableJSON(e.Before), nullableJSON(e.After), e.RequestID,
+		formatTime(time.Now()),
+	).Scan(&e.ID, &e.CreatedAt)
+}
+
+func (r *AuditRepository) List(ctx context.Context, filter domain.AuditFilter) ([]domain.AuditEvent, error) {
+	var (
+		conds []string
+		args  []any
+	)
+	addCond := func(cond string, arg any) {
+		args = append(args, arg)
+		conds = append(conds, fmt.Sprintf(cond, len(args)))
+	}
+
+	if filter.EntityType != "" {
+		addCond("entity_type = $%d", filter.EntityType)
+	}
+	if filter.EntityID != "" {
+		addCond("entity_id = $%d", filter.EntityID)
+	}
+	if filter.Actor != "" {
+		addCond("actor = $%d", filter.Actor)
+	}
+	if filter.BeforeID > 0 {
+		addCond("id < $%d", filter.BeforeID)
+	}
+
+	query := `
+		SELECT id, actor, action, entity_type, entity_id, before, after, request_id, created_at
+		FROM audit_events
+	`
+	if len(conds) > 0 {
+		query += " WHERE " + strings.Join(conds, " AND ")
+	}
+	query += " ORDER BY id DESC"
+	if filter.Limit > 0 {
+		args = append(args, filter.Limit)
+		query += fmt.Sprintf(" LIMIT $%d", len(args))
+	}
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var events []domain.AuditEvent
+	for rows.Next() {
+		var e domain.AuditEvent
+		if err := rows.Scan(
+			&e.ID, &e.Actor, &e.Action, &e.EntityType, &e.EntityID,
+			&e.Before, &e.After, &e.RequestID, &e.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		events = append(events, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
+
+// nullableJSON maps an empty document to SQL NULL.
+func nullableJSON(b []byte) any {
+	if len(b) == 0 {
+		return nil
+	}
+	return string(b)
+}
@@ -0,0 +1,101 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/terps489/avito_tech_internship/internal/domain"
+)
+
+type ExternalAccountRepository struct {
+	db *sql.DB
+}
+
+func NewExternalAccountRepository(db *sql.DB) *ExternalAccountRepository {
+	return &ExternalAccountRepository{db: db}
+}
+
+// Set creates the mapping or points an existing one to a.UserID.
+func (r *ExternalAccountRepository) Set(ctx context.Context, a *domain.ExternalAccount) error {
+	const query = `
+		INSERT INTO external_accounts (provider, login, user_id, created_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (provider, login) DO UPDATE
+		SET user_id = EXCLUDED.user_id
+		RETURNING created_at
+	`
+
+	return conn(ctx, r.db).QueryRowContext(ctx, query, a.Provider, a.Login, a.UserID, formatTime(time.Now())).Scan(&a.CreatedAt)
+}
+
+func (r *ExternalAccountRepository) Get(ctx context.Context, provider domain.ExternalProvider, login string) (*domain.ExternalAccount, error) {
+	const query = `
+		SELECT provider, login, user_id, created_at
+		FROM external_accounts
+		WHERE provider = $1 AND login = $2
+	`
+
+	var a domain.ExternalAccount
+	if err := conn(ctx, r.db).QueryRowContext(ctx, query, provider, login).
+		Scan(&a.Provider, &a.Login, &a.UserID, &a.CreatedAt); err != nil {
+		return nil, err
+	}
+
+	return &a, nil
+}
+
+// List returns mappings ordered by provider and login;
+// an empty provider matches all of them.
+func (r *ExternalAccountRepository) List(ctx context.Context, provider domain.ExternalProvider) ([]domain.ExternalAccount, error) {
+	const query = `
+		SELECT provider, login, user_id, created_at
+		FROM external_accounts
+		WHERE $1 = '' OR provider = $1
+		ORDER BY provider, login
+	`
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, provider)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var accounts []domain.ExternalAccount
+	for rows.Next() {
+		var a domain.ExternalAccount
+		if err := rows.Scan(&a.Provider, &a.Login, &a.UserID, &a.CreatedAt); err != nil {
+			return nil, err
+		}
+		accounts = append(accounts, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return accounts, nil
+}
+
+func (r *ExternalAccountRepository) Delete(ctx context.Context, provider domain.ExternalProvider, login string) error {
+	const query = `
+		DELETE FROM external_accounts
+		WHERE provider = $1 AND login = $2
+	`
+
+	res, err := conn(ctx, r.db).ExecContext(ctx, query, provider, login)
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
@@ -0,0 +1,21 @@
+CREATE TABLE pr_reviewer_events (
+    id           INTEGER PRIMARY KEY AUTOINCREMENT,
+    pr_id        TEXT NOT NULL REFERENCES pull_requests(pull_request_id) ON DELETE CASCADE,
+    event_type   TEXT NOT NULL CHECK (event_type IN ('assigned', 'reassigned', 'removed')),
+    from_user_id TEXT REFERENCES users(user_id),
+    to_user_id   TEXT REFERENCES users(user_id),
+    reason       TEXT NOT NULL,
+    actor        TEXT NOT NULL,
+    created_at   TIMESTAMP NOT NULL
+);
+
+CREATE INDEX pr_reviewer_events_pr_idx ON pr_reviewer_events (pr_id, id);
+CREATE INDEX pr_reviewer_events_reassigned_idx ON pr_reviewer_events (from_user_id)
+    WHERE event_type = 'reassigned';
+
+-- Reviewers assigned before the history existed.
+INSERT INTO pr_reviewer_events (pr_id, event_type, to_user_id, reason, actor, created_at)
+SELECT r.pr_id, 'assigned', r.reviewer_id, 'backfill', 'system', pr.created_at
+FROM pull_request_reviewers r
+JOIN pull_requests pr ON pr.pull_request_id = r.pr_id
+ORDER BY pr.created_at, r.pr_id, r.reviewer_id;
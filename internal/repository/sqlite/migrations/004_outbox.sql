@@ -0,0 +1,22 @@
+CREATE TABLE outbox_events (
+    id              INTEGER PRIMARY KEY AUTOINCREMENT,
+    event_type      TEXT NOT NULL,
+    payload         TEXT NOT NULL,
+    request_id      TEXT NOT NULL DEFAULT '',
+    created_at      TIMESTAMP NOT NULL,
+    status          TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'processed', 'dead')),
+    attempts        INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP NOT NULL,
+    last_error      TEXT,
+    processed_at    TIMESTAMP
+);
+
+CREATE INDEX outbox_events_pending_idx ON outbox_events (next_attempt_at, id)
+    WHERE status = 'pending';
+
+CREATE TABLE outbox_deliveries (
+    event_id     INTEGER NOT NULL REFERENCES outbox_events(id) ON DELETE CASCADE,
+    sink         TEXT NOT NULL,
+    delivered_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (event_id, sink)
+);
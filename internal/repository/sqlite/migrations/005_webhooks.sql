@@ -0,0 +1,41 @@
+CREATE TABLE webhooks (
+    id         INTEGER PRIMARY KEY AUTOINCREMENT,
+    url        TEXT NOT NULL,
+    secret     TEXT NOT NULL,
+    is_active  BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL
+);
+
+CREATE TABLE webhook_event_types (
+    webhook_id INTEGER NOT NULL REFERENCES webhooks(id) ON DELETE CASCADE,
+    event_type TEXT NOT NULL,
+    PRIMARY KEY (webhook_id, event_type)
+);
+
+CREATE INDEX webhook_event_types_event_idx ON webhook_event_types (event_type, webhook_id);
+
+CREATE TABLE webhook_deliveries (
+    id              INTEGER PRIMARY KEY AUTOINCREMENT,
+    webhook_id      INTEGER NOT NULL REFERENCES webhooks(id) ON DELETE CASCADE,
+    event_id        INTEGER NOT NULL REFERENCES outbox_events(id) ON DELETE CASCADE,
+    event_type      TEXT NOT NULL,
+    status          TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'succeeded', 'failed')),
+    attempts        INTEGER NOT NULL DEFAULT 0,
+    response_status INTEGER,
+    last_error      TEXT,
+    redelivery_of   INTEGER REFERENCES webhook_deliveries(id) ON DELETE SET NULL,
+    next_attempt_at TIMESTAMP NOT NULL,
+    created_at      TIMESTAMP NOT NULL,
+    delivered_at    TIMESTAMP
+);
+
+-- Fan-out creates at most one original delivery per webhook and event;
+-- redeliveries requested through the API are extra rows.
+CREATE UNIQUE INDEX webhook_deliveries_original_idx ON webhook_deliveries (webhook_id, event_id)
+    WHERE redelivery_of IS NULL;
+
+CREATE INDEX webhook_deliveries_pending_idx ON webhook_deliveries (next_attempt_at, id)
+    WHERE status = 'pending';
+
+CREATE INDEX webhook_deliveries_webhook_idx ON webhook_deliveries (webhook_id, id);
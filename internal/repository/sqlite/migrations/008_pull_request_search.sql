@@ -0,0 +1,3 @@
+CREATE INDEX pull_requests_author_created_idx ON pull_requests (author_id, created_at, pull_request_id);
+
+CREATE INDEX users_team_idx ON users (team_name);
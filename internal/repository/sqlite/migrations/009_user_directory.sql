@@ -0,0 +1 @@
+CREATE INDEX users_username_idx ON users (username, user_id);
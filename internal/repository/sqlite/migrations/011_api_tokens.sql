@@ -0,0 +1,10 @@
+CREATE TABLE api_tokens (
+    id         INTEGER PRIMARY KEY AUTOINCREMENT,
+    name       TEXT NOT NULL,
+    token_hash TEXT NOT NULL UNIQUE,
+    role       TEXT NOT NULL CHECK (role IN ('admin', 'team_lead', 'developer')),
+    team_name  TEXT REFERENCES teams(team_name),
+    user_id    TEXT REFERENCES users(user_id),
+    created_at TIMESTAMP NOT NULL,
+    revoked_at TIMESTAMP
+);
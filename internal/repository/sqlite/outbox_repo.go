@@ -0,0 +1,147 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"time"
+
+	"github.com/terps489/avito_tech_internship/internal/domain"
+)
+
+type OutboxRepository struct {
+	db *sql.DB
+}
+
+func NewOutboxRepository(db *sql.DB) *OutboxRepository {
+	return &OutboxRepository{db: db}
+}
+
+func (r *OutboxRepository) Append(ctx context.Context, e *domain.OutboxEvent) error {
+	const query = `
+		INSERT INTO outbox_events (event_type, payload, request_id, created_at, next_attempt_at)
+		VALUES ($1, $2, $3, $4, $4)
+		RETURNING id, created_at
+	`
+
+	return conn(ctx, r.db).QueryRowContext(ctx, query, e.Type, string(e.Payload), e.RequestID, formatTime(time.Now())).
+		Scan(&e.ID, &e.CreatedAt)
+}
+
+func (r *OutboxRepository) ClaimPending(ctx context.Context, limit int, lease time.Duration) ([]domain.OutboxEvent, error) {
+	// The single connection of the pool serializes claims,
+	// so no row locking is needed.
+	const query = `
+		UPDATE outbox_events
+		SET attempts = attempts + 1,
+		    next_attempt_at = $3
+		WHERE id IN (
+			SELECT id
+			FROM outbox_events
+			WHERE status = 'pending' AND next_attempt_at <= $2
+			ORDER BY id
+			LIMIT $1
+		)
+		RETURNING id, event_type, payload, request_id, created_at, attempts
+	`
+
+	now := time.Now()
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, limit, formatTime(now), formatTime(now.Add(lease)))
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var events []domain.OutboxEvent
+	for rows.Next() {
+		var e domain.OutboxEvent
+		if err := rows.Scan(&e.ID, &e.Type, &e.Payload, &e.RequestID, &e.CreatedAt, &e.Attempts); err != nil {
+			return nil, err
+		}
+		events = append(events, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.Slice(events, func(i, j int) bool {
+		return events[i].ID < events[j].ID
+	})
+
+	return events, nil
+}
+
+func (r *OutboxRepository) DeliveredSinks(ctx context.Context, eventID int64) ([]string, error) {
+	const query = `
+		SELECT sink
+		FROM outbox_deliveries
+		WHERE event_id = $1
+		ORDER BY sink
+	`
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, eventID)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var sinks []string
+	for rows.Next() {
+		var sink string
+		if err := rows.Scan(&sink); err != nil {
+			return nil, err
+		}
+		sinks = append(sinks, sink)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return sinks, nil
+}
+
+func (r *OutboxRepository) MarkDelivered(ctx context.Context, eventID int64, sink string) error {
+	const query = `
+		INSERT INTO outbox_deliveries (event_id, sink, delivered_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (event_id, sink) DO NOTHING
+	`
+
+	_, err := conn(ctx, r.db).ExecContext(ctx, query, eventID, sink, formatTime(time.Now()))
+	return err
+}
+
+func (r *OutboxRepository) MarkProcessed(ctx context.Context, eventID int64) error {
+	const query = `
+		UPDATE outbox_events
+		SET status = 'processed',
+		    processed_at = $2,
+		    last_error = NULL
+		WHERE id = $1
+	`
+
+	_, err := conn(ctx, r.db).ExecContext(ctx, query, eventID, formatTime(time.Now()))
+	return err
+}
+
+func (r *OutboxRepository) MarkFailed(ctx context.Context, eventID int64, retryAt time.Time, lastErr string, dead bool) error {
+	const query = `
+		UPDATE outbox_events
+		SET status = $2,
+		    next_attempt_at = $3,
+		    last_error = $4
+		WHERE id = $1
+	`
+
+	status := "pending"
+	if dead {
+		status = "dead"
+	}
+
+	_, err := conn(ctx, r.db).ExecContext(ctx, query, eventID, status, formatTime(retryAt), lastErr)
+	return err
+}
@@ -0,0 +1,428 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/terps489/avito_tech_internship/internal/domain"
+)
+
+type PullRequestRepository struct {
+	db *sql.DB
+}
+
+func NewPullRequestRepository(db *sql.DB) *PullRequestRepository {
+	return &PullRequestRepository{db: db}
+}
+
+func (r *PullRequestRepository) Create(ctx context.Context, pr *domain.PullRequest) error {
+	return inTx(ctx, r.db, func(tx querier) error {
+		const insertPR = `
+			INSERT INTO pull_requests (pull_request_id, pull_request_name, author_id, status, created_at)
+			VALUES ($1, $2, $3, $4, $5)
+		`
+
+		if _, err := tx.ExecContext(ctx, insertPR, pr.ID, pr.Title, pr.AuthorID, pr.Status, formatTime(time.Now())); err != nil {
+			return err
+		}
+
+		if len(pr.ReviewerIDs) > 0 {
+			const insertReviewer = `
+				INSERT INTO pull_request_reviewers (pr_id, reviewer_id)
+				VALUES ($1, $2)
+			`
+			for _, reviewerID := range pr.ReviewerIDs {
+				if _, err := tx.ExecContext(ctx, insertReviewer, pr.ID, reviewerID); err != nil {
+					return err
+				}
+			}
+		}
+
+		return nil
+	})
+}
+
+func (r *PullRequestRepository) GetByID(ctx context.Context, id domain.PullRequestID) (*domain.PullRequest, error) {
+	const queryPR = `
+		SELECT pull_request_id, pull_request_name, author_id, status, created_at, merged_at
+		FROM pull_requests
+		WHERE pull_request_id = $1
+	`
+
+	var pr domain.PullRequest
+	var mergedAt sql.NullTime
+
+	if err := conn(ctx, r.db).QueryRowContext(ctx, queryPR, id).
+		Scan(&pr.ID, &pr.Title, &pr.AuthorID, &pr.Status, &pr.CreatedAt, &mergedAt); err != nil {
+		return nil, err
+	}
+
+	if mergedAt.Valid {
+		t := mergedAt.Time
+		pr.MergedAt = &t
+	}
+
+	const queryReviewers = `
+		SELECT reviewer_id
+		FROM pull_request_reviewers
+		WHERE pr_id = $1
+	`
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, queryReviewers, id)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	for rows.Next() {
+		var rid domain.UserID
+		if err := rows.Scan(&rid); err != nil {
+			return nil, err
+		}
+		pr.ReviewerIDs = append(pr.ReviewerIDs, rid)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return &pr, nil
+}
+
+func (r *PullRequestRepository) Update(ctx context.Context, pr *domain.PullRequest) error {
+	return inTx(ctx, r.db, func(tx querier) error {
+		const updatePR = `
+			UPDATE pull_requests
+			SET pull_request_name = $1,
+			    author_id = $2,
+			    status = $3,
+			    merged_at = $4
+			WHERE pull_request_id = $5
+		`
+
+		var mergedAt interface{}
+		if pr.Status == domain.PRStatusMerged {
+			if pr.MergedAt == nil {
+				now := time.Now().UTC()
+				pr.MergedAt = &now
+			}
+			mergedAt = formatTime(*pr.MergedAt)
+		} else {
+			mergedAt = nil
+		}
+
+		if _, err := tx.ExecContext(ctx, updatePR, pr.Title, pr.AuthorID, pr.Status, mergedAt, pr.ID); err != nil {
+			return err
+		}
+
+		const deleteReviewers = `
+			DELETE FROM pull_request_reviewers
+			WHERE pr_id = $1
+		`
+		if _, err := tx.ExecContext(ctx, deleteReviewers, pr.ID); err != nil {
+			return err
+		}
+
+		const insertReviewer = `
+			INSERT INTO pull_request_reviewers (pr_id, reviewer_id)
+			VALUES ($1, $2)
+		`
+		for _, reviewerID := range pr.ReviewerIDs {
+			if _, err := tx.ExecContext(ctx, insertReviewer, pr.ID, reviewerID); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func (r *PullRequestRepository) Exists(ctx context.Context, id domain.PullRequestID) (bool, error) {
+	const query = `
+		SELECT 1
+		FROM pull_requests
+		WHERE pull_request_id = $1
+	`
+	var dummy int
+	err := conn(ctx, r.db).QueryRowContext(ctx, query, id).Scan(&dummy)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (r *PullRequestRepository) List(ctx context.Context, filter domain.PullRequestFilter) ([]domain.PullRequest, error) {
+	var (
+		conds []string
+		args  []any
+	)
+	addCond := func(cond string, arg any) {
+		args = append(args, arg)
+		conds = append(conds, fmt.Sprintf(cond, len(args)))
+	}
+
+	if filter.AuthorID != "" {
+		addCond("pr.author_id = $%d", filter.AuthorID)
+	}
+	if filter.ReviewerID != "" {
+		addCond(`EXISTS (
+			SELECT 1 FROM pull_request_reviewers r
+			WHERE r.pr_id = pr.pull_request_id AND r.reviewer_id = $%d
+		)`, filter.ReviewerID)
+	}
+	if filter.TeamName != "" {
+		addCond("pr.author_id IN (SELECT user_id FROM users WHERE team_name = $%d)", filter.TeamName)
+	}
+	if filter.Status != "" {
+		addCond("pr.status = $%d", filter.Status)
+	}
+	if filter.TitleContains != "" {
+		addCond(`pr.pull_request_name LIKE $%d ESCAPE '\'`, containsPattern(filter.TitleContains))
+	}
+	if !filter.CreatedFrom.IsZero() {
+		addCond("pr.created_at >= $%d", formatTime(filter.CreatedFrom))
+	}
+	if !filter.CreatedTo.IsZero() {
+		addCond("pr.created_at < $%d", formatTime(filter.CreatedTo))
+	}
+	if filter.After != nil {
+		op := ">"
+		if filter.Desc {
+			op = "<"
+		}
+		args = append(args, formatTime(filter.After.CreatedAt), filter.After.ID)
+		conds = append(conds, fmt.Sprintf("(pr.created_at, pr.pull_request_id) %s ($%d, $%d)", op, len(args)-1, len(args)))
+	}
+
+	query := `
+		SELECT pr.pull_request_id, pr.pull_request_name, pr.author_id, pr.status, pr.created_at, pr.merged_at
+		FROM pull_requests pr
+	`
+	if len(conds) > 0 {
+		query += " WHERE " + strings.Join(conds, " AND ")
+	}
+	if filter.Desc {
+		query += " ORDER BY pr.created_at DESC, pr.pull_request_id DESC"
+	} else {
+		query += " ORDER BY pr.created_at, pr.pull_request_id"
+	}
+	if filter.Limit > 0 {
+		args = append(args, filter.Limit)
+		query += fmt.Sprintf(" LIMIT $%d", len(args))
+	}
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var result []domain.PullRequest
+	for rows.Next() {
+		var (
+			pr       domain.PullRequest
+			mergedAt sql.NullTime
+		)
+		if err := rows.Scan(&pr.ID, &pr.Title, &pr.AuthorID, &pr.Status, &pr.CreatedAt, &mergedAt); err != nil {
+			return nil, err
+		}
+		if mergedAt.Valid {
+			t := mergedAt.Time
+			pr.MergedAt = &t
+		}
+		result = append(result, pr)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := r.loadReviewers(ctx, result); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// likeEscaper makes LIKE wildcards literal for patterns with ESCAPE '\'.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// containsPattern builds a LIKE pattern matching s anywhere.
+func containsPattern(s string) string {
+	return "%" + likeEscaper.Replace(s) + "%"
+}
+
+// loadReviewers fills ReviewerIDs of prs with a single query.
+func (r *PullRequestRepository) loadReviewers(ctx context.Context, prs []domain.PullRequest) error {
+	if len(prs) == 0 {
+		return nil
+	}
+
+	index := make(map[domain.PullRequestID]int, len(prs))
+	placeholders := make([]string, 0, len(prs))
+	args := make([]any, 0, len(prs))
+	for i, pr := range prs {
+		index[pr.ID] = i
+		args = append(args, pr.ID)
+		placeholders = append(placeholders, fmt.Sprintf("$%d", len(args)))
+	}
+
+	query := `
+		SELECT pr_id, reviewer_id
+		FROM pull_request_reviewers
+		WHERE pr_id IN (` + strings.Join(placeholders, ", ") + `)
+		ORDER BY pr_id, reviewer_id
+	`
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	for rows.Next() {
+		var (
+			prID       domain.PullRequestID
+			reviewerID domain.UserID
+		)
+		if err := rows.Scan(&prID, &reviewerID); err != nil {
+			return err
+		}
+		i := index[prID]
+		prs[i].ReviewerIDs = append(prs[i].ReviewerIDs, reviewerID)
+	}
+
+	return rows.Err()
+}
+
+func (r *PullRequestRepository) GetReviewerAssignmentStats(
+	ctx context.Context,
+	filter domain.AssignmentStatsFilter,
+) ([]domain.ReviewerAssignmentStat, error) {
+	var key string
+	switch filter.GroupBy {
+	case domain.GroupByTeam:
+		key = "u.team_name"
+	case domain.GroupByDay:
+		key = "substr(pr.created_at, 1, 10)"
+	case domain.GroupByWeek:
+		// Monday of the week: move to the next Sunday (or stay), then back six days.
+		key = "date(substr(pr.created_at, 1, 10), 'weekday 0', '-6 days')"
+	default:
+		key = "r.reviewer_id"
+	}
+
+	var (
+		conds []string
+		args  []any
+	)
+	addCond := func(cond string, arg any) {
+		args = append(args, arg)
+		conds = append(conds, fmt.Sprintf(cond, len(args)))
+	}
+
+	if !filter.From.IsZero() {
+		addCond("pr.created_at >= $%d", formatTime(filter.From))
+	}
+	if !filter.To.IsZero() {
+		addCond("pr.created_at < $%d", formatTime(filter.To))
+	}
+	if filter.TeamName != "" {
+		addCond("u.team_name = $%d", filter.TeamName)
+	}
+
+	query := `
+		SELECT ` + key + ` AS grp,
+		       COUNT(*),
+		       SUM(CASE WHEN pr.status = 'OPEN' THEN 1 ELSE 0 END),
+		       SUM(CASE WHEN pr.status = 'MERGED' THEN 1 ELSE 0 END)
+		FROM pull_request_reviewers r
+		JOIN pull_requests pr ON pr.pull_request_id = r.pr_id
+		JOIN users u ON u.user_id = r.reviewer_id
+	`
+	if len(conds) > 0 {
+		query += " WHERE " + strings.Join(conds, " AND ")
+	}
+	query += " GROUP BY grp ORDER BY grp"
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var stats []domain.ReviewerAssignmentStat
+	for rows.Next() {
+		var s domain.ReviewerAssignmentStat
+		if err := rows.Scan(&s.Key, &s.Count, &s.Open, &s.Merged); err != nil {
+			return nil, err
+		}
+		stats = append(stats, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return stats, nil
+}
+
+func (r *PullRequestRepository) ListMergeTimes(ctx context.Context, filter domain.MergeTimesFilter) ([]domain.MergeTime, error) {
+	conds := []string{"pr.merged_at IS NOT NULL"}
+	var args []any
+	addCond := func(cond string, arg any) {
+		args = append(args, arg)
+		conds = append(conds, fmt.Sprintf(cond, len(args)))
+	}
+
+	if !filter.From.IsZero() {
+		addCond("pr.merged_at >= $%d", formatTime(filter.From))
+	}
+	if !filter.To.IsZero() {
+		addCond("pr.merged_at < $%d", formatTime(filter.To))
+	}
+	if filter.TeamName != "" {
+		addCond("u.team_name = $%d", filter.TeamName)
+	}
+
+	query := `
+		SELECT pr.pull_request_id, pr.author_id, u.team_name, pr.created_at, pr.merged_at
+		FROM pull_requests pr
+		JOIN users u ON u.user_id = pr.author_id
+		WHERE ` + strings.Join(conds, " AND ") + `
+		ORDER BY pr.merged_at, pr.pull_request_id
+	`
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var result []domain.MergeTime
+	for rows.Next() {
+		var m domain.MergeTime
+		if err := rows.Scan(&m.PullRequestID, &m.AuthorID, &m.TeamName, &m.CreatedAt, &m.MergedAt); err != nil {
+			return nil, err
+		}
+		result = append(result, m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
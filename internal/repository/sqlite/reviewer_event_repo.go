@@ -0,0 +1,103 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/terps489/avito_tech_internship/internal/domain"
+)
+
+type ReviewerEventRepository struct {
+	db *sql.DB
+}
+
+func NewReviewerEventRepository(db *sql.DB) *ReviewerEventRepository {
+	return &ReviewerEventRepository{db: db}
+}
+
+func (r *ReviewerEventRepository) Append(ctx context.Context, e *domain.ReviewerEvent) error {
+	const query = `
+		INSERT INTO pr_reviewer_events (pr_id, event_type, from_user_id, to_user_id, reason, actor, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, created_at
+	`
+
+	return conn(ctx, r.db).QueryRowContext(ctx, query,
+		e.PullRequestID, e.Type, nullableString(string(e.FromUserID)), nullableString(string(e.ToUserID)),
+		e.Reason, e.Actor, formatTime(time.Now()),
+	).Scan(&e.ID, &e.CreatedAt)
+}
+
+func (r *ReviewerEventRepository) ListByPullRequest(ctx context.Context, prID domain.PullRequestID) ([]domain.ReviewerEvent, error) {
+	const query = `
+		SELECT id, pr_id, event_type, COALESCE(from_user_id, ''), COALESCE(to_user_id, ''), reason, actor, created_at
+		FROM pr_reviewer_events
+		WHERE pr_id = $1
+		ORDER BY id
+	`
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, prID)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var events []domain.ReviewerEvent
+	for rows.Next() {
+		var e domain.ReviewerEvent
+		if err := rows.Scan(
+			&e.ID, &e.PullRequestID, &e.Type, &e.FromUserID, &e.ToUserID, &e.Reason, &e.Actor, &e.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		events = append(events, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
+
+func (r *ReviewerEventRepository) GetReassignmentStats(ctx context.Context) ([]domain.ReassignmentStat, error) {
+	const query = `
+		SELECT from_user_id, COUNT(*) as cnt
+		FROM pr_reviewer_events
+		WHERE event_type = 'reassigned'
+		GROUP BY from_user_id
+		ORDER BY from_user_id
+	`
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var stats []domain.ReassignmentStat
+	for rows.Next() {
+		var s domain.ReassignmentStat
+		if err := rows.Scan(&s.UserID, &s.Count); err != nil {
+			return nil, err
+		}
+		stats = append(stats, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return stats, nil
+}
+
+// nullableString maps an empty string to SQL NULL.
+func nullableString(s string) any {
+	if s == "" {
+		return nil
+	}
+	return s
+}
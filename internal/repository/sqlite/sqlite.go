@@ -0,0 +1,148 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strings"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+//go:embed migrations/*.sql
+var migrationsFS embed.FS
+
+// timeLayout is fixed-width so that timestamps stored as TEXT compare
+// correctly in ORDER BY and range conditions.
+const timeLayout = "2006-01-02T15:04:05.000000000Z07:00"
+
+// Open opens the database file at path and applies pending migrations.
+func Open(path string) (*sql.DB, error) {
+	dsn := fmt.Sprintf("file:%s?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)", path)
+
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("sql open: %w", err)
+	}
+
+	// SQLite allows a single writer; one connection also keeps
+	// ":memory:" databases shared between queries.
+	db.SetMaxOpenConns(1)
+
+	if err := Migrate(db); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+
+	return db, nil
+}
+
+// Migrate applies embedded migrations that are not yet recorded
+// in schema_migrations.
+func Migrate(db *sql.DB) error {
+	const createTable = `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version    TEXT PRIMARY KEY,
+			applied_at TIMESTAMP NOT NULL
+		)
+	`
+	if _, err := db.Exec(createTable); err != nil {
+		return fmt.Errorf("create schema_migrations: %w", err)
+	}
+
+	names, err := fs.Glob(migrationsFS, "migrations/*.sql")
+	if err != nil {
+		return err
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		version := strings.TrimSuffix(strings.TrimPrefix(name, "migrations/"), ".sql")
+		if err := applyMigration(db, version, name); err != nil {
+			return fmt.Errorf("migration %s: %w", version, err)
+		}
+	}
+
+	return nil
+}
+
+// CheckSchema reports an error if an embedded migration is not recorded
+// in schema_migrations. Open applies them, so this only fails if the file
+// was changed behind the service's back.
+func CheckSchema(ctx context.Context, db *sql.DB) error {
+	rows, err := db.QueryContext(ctx, `SELECT version FROM schema_migrations`)
+	if err != nil {
+		return fmt.Errorf("read schema version: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	applied := make(map[string]bool)
+	for rows.Next() {
+		var version string
+		if err := rows.Scan(&version); err != nil {
+			return err
+		}
+		applied[version] = true
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	names, err := fs.Glob(migrationsFS, "migrations/*.sql")
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		version := strings.TrimSuffix(strings.TrimPrefix(name, "migrations/"), ".sql")
+		if !applied[version] {
+			return fmt.Errorf("migration %s is not applied", version)
+		}
+	}
+	return nil
+}
+
+func applyMigration(db *sql.DB, version, name string) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	var dummy int
+	err = tx.QueryRow(`SELECT 1 FROM schema_migrations WHERE version = $1`, version).Scan(&dummy)
+	if err == nil {
+		return nil
+	}
+	if err != sql.ErrNoRows {
+		return err
+	}
+
+	script, err := migrationsFS.ReadFile(name)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(string(script)); err != nil {
+		return err
+	}
+
+	const insert = `
+		INSERT INTO schema_migrations (version, applied_at)
+		VALUES ($1, $2)
+	`
+	if _, err := tx.Exec(insert, version, formatTime(time.Now())); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(timeLayout)
+}
@@ -0,0 +1,57 @@
+package sqlite_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/terps489/avito_tech_internship/internal/app"
+	"github.com/terps489/avito_tech_internship/internal/repository/repotest"
+	"github.com/terps489/avito_tech_internship/internal/repository/sqlite"
+)
+
+func TestConformance(t *testing.T) {
+	repotest.Run(t, func(t *testing.T) app.Repositories {
+		db, err := sqlite.Open(filepath.Join(t.TempDir(), "reviewer.db"))
+		if err != nil {
+			t.Fatalf("open sqlite: %v", err)
+		}
+		t.Cleanup(func() {
+			_ = db.Close()
+		})
+
+		return app.Repositories{
+			Users:            sqlite.NewUserRepository(db),
+			Teams:            sqlite.NewTeamRepository(db),
+			PullRequests:     sqlite.NewPullRequestRepository(db),
+			Audit:            sqlite.NewAuditRepository(db),
+			ReviewerEvents:   sqlite.NewReviewerEventRepository(db),
+			Outbox:           sqlite.NewOutboxRepository(db),
+			Webhooks:         sqlite.NewWebhookRepository(db),
+			ExternalAccounts: sqlite.NewExternalAccountRepository(db),
+			APITokens:        sqlite.NewAPITokenRepository(db),
+			IdempotencyKeys:  sqlite.NewIdempotencyRepository(db),
+			Tx:               sqlite.NewTxManager(db),
+		}
+	})
+}
+
+func TestCheckSchema(t *testing.T) {
+	db, err := sqlite.Open(filepath.Join(t.TempDir(), "reviewer.db"))
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+
+	if err := sqlite.CheckSchema(t.Context(), db); err != nil {
+		t.Fatalf("fresh database: %v", err)
+	}
+
+	if _, err := db.Exec(`DELETE FROM schema_migrations WHERE version = '009_user_directory'`); err != nil {
+		t.Fatal(err)
+	}
+	if err := sqlite.CheckSchema(t.Context(), db); err == nil {
+		t.Fatal("expected an error for a missing migration")
+	}
+}
@@ -0,0 +1,182 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/terps489/avito_tech_internship/internal/domain"
+)
+
+type UserRepository struct {
+	db *sql.DB
+}
+
+func NewUserRepository(db *sql.DB) *UserRepository {
+	return &UserRepository{db: db}
+}
+
+func (r *UserRepository) GetByID(ctx context.Context, id domain.UserID) (*domain.User, error) {
+	const query = `
+		SELECT user_id, username, is_active, team_name
+		FROM users
+		WHERE user_id = $1
+	`
+
+	var u domain.User
+	err := conn(ctx, r.db).QueryRowContext(ctx, query, id).Scan(&u.ID, &u.Username, &u.IsActive, &u.TeamName)
+	if err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
+func (r *UserRepository) ListActiveByTeam(ctx context.Context, teamName domain.TeamName) ([]domain.User, error) {
+	const query = `
+		SELECT user_id, username, is_active, team_name
+		FROM users
+		WHERE team_name = $1 AND is_active = TRUE
+	`
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, teamName)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var users []domain.User
+	for rows.Next() {
+		var u domain.User
+		if err := rows.Scan(&u.ID, &u.Username, &u.IsActive, &u.TeamName); err != nil {
+			return nil, err
+		}
+		users = append(users, u)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return users, nil
+}
+
+func (r *UserRepository) UpsertUsersForTeam(ctx context.Context, teamName domain.TeamName, users []domain.User) error {
+	return inTx(ctx, r.db, func(tx querier) error {
+		const query = `
+			INSERT INTO users (user_id, username, is_active, team_name)
+			VALUES ($1, $2, $3, $4)
+			ON CONFLICT (user_id) DO UPDATE
+			SET username = EXCLUDED.username,
+			    is_active = EXCLUDED.is_active,
+			    team_name = EXCLUDED.team_name
+		`
+
+		for _, u := range users {
+			if _, err := tx.ExecContext(ctx, query, u.ID, u.Username, u.IsActive, teamName); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func (r *UserRepository) SetIsActive(ctx context.Context, id domain.UserID, active bool) error {
+	const query = `
+		UPDATE users
+		SET is_active = $2
+		WHERE user_id = $1
+	`
+
+	res, err := conn(ctx, r.db).ExecContext(ctx, query, id, active)
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+func (r *UserRepository) List(ctx context.Context, filter domain.UserFilter) ([]domain.User, error) {
+	var (
+		conds []string
+		args  []any
+	)
+	addCond := func(cond string, arg any) {
+		args = append(args, arg)
+		conds = append(conds, fmt.Sprintf(cond, len(args)))
+	}
+
+	if filter.UsernamePrefix != "" {
+		addCond(`lower(username) LIKE $%d ESCAPE '\'`, likeEscaper.Replace(strings.ToLower(filter.UsernamePrefix))+"%")
+	}
+	if filter.TeamName != "" {
+		addCond("team_name = $%d", filter.TeamName)
+	}
+	if filter.IsActive != nil {
+		addCond("is_active = $%d", *filter.IsActive)
+	}
+	if filter.After != nil {
+		args = append(args, filter.After.Username, filter.After.ID)
+		conds = append(conds, fmt.Sprintf("(username, user_id) > ($%d, $%d)", len(args)-1, len(args)))
+	}
+
+	query := `
+		SELECT user_id, username, is_active, team_name
+		FROM users
+	`
+	if len(conds) > 0 {
+		query += " WHERE " + strings.Join(conds, " AND ")
+	}
+	query += " ORDER BY username, user_id"
+	if filter.Limit > 0 {
+		args = append(args, filter.Limit)
+		query += fmt.Sprintf(" LIMIT $%d", len(args))
+	}
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var users []domain.User
+	for rows.Next() {
+		var u domain.User
+		if err := rows.Scan(&u.ID, &u.Username, &u.IsActive, &u.TeamName); err != nil {
+			return nil, err
+		}
+		users = append(users, u)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return users, nil
+}
+
+func (r *UserRepository) CountOpenReviews(ctx context.Context, id domain.UserID) (int, error) {
+	const query = `
+		SELECT COUNT(*)
+		FROM pull_request_reviewers r
+		JOIN pull_requests pr ON pr.pull_request_id = r.pr_id
+		WHERE r.reviewer_id = $1 AND pr.status = 'OPEN'
+	`
+
+	var n int
+	if err := conn(ctx, r.db).QueryRowContext(ctx, query, id).Scan(&n); err != nil {
+		return 0, err
+	}
+	return n, nil
+}
@@ -0,0 +1,470 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/terps489/avito_tech_internship/internal/domain"
+)
+
+type WebhookRepository struct {
+	db *sql.DB
+}
+
+func NewWebhookRepository(db *sql.DB) *WebhookRepository {
+	return &WebhookRepository{db: db}
+}
+
+func (r *WebhookRepository) Create(ctx context.Context, w *domain.Webhook) error {
+	const query = `
+		INSERT INTO webhooks (url, secret, is_active, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $4)
+		RETURNING id, created_at, updated_at
+	`
+
+	return inTx(ctx, r.db, func(q querier) error {
+		if err := q.QueryRowContext(ctx, query, w.URL, w.Secret, w.IsActive, formatTime(time.Now())).
+			Scan(&w.ID, &w.CreatedAt, &w.UpdatedAt); err != nil {
+			return err
+		}
+		return insertWebhookEventTypes(ctx, q, w.ID, w.EventTypes)
+	})
+}
+
+func (r *WebhookRepository) GetByID(ctx context.Context, id int64) (*domain.Webhook, error) {
+	const query = `
+		SELECT id, url, secret, is_active, created_at, updated_at
+		FROM webhooks
+		WHERE id = $1
+	`
+
+	q := conn(ctx, r.db)
+
+	var w domain.Webhook
+	if err := q.QueryRowContext(ctx, query, id).
+		Scan(&w.ID, &w.URL, &w.Secret, &w.IsActive, &w.CreatedAt, &w.UpdatedAt); err != nil {
+		return nil, err
+	}
+
+	types, err := listWebhookEventTypes(ctx, q, w.ID)
+	if err != nil {
+		return nil, err
+	}
+	w.EventTypes = types[w.ID]
+
+	return &w, nil
+}
+
+func (r *WebhookRepository) List(ctx context.Context) ([]domain.Webhook, error) {
+	const query = `
+		SELECT id, url, secret, is_active, created_at, updated_at
+		FROM webhooks
+		ORDER BY id
+	`
+
+	q := conn(ctx, r.db)
+
+	rows, err := q.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var webhooks []domain.Webhook
+	for rows.Next() {
+		var w domain.Webhook
+		if err := rows.Scan(&w.ID, &w.URL, &w.Secret, &w.IsActive, &w.CreatedAt, &w.UpdatedAt); err != nil {
+			return nil, err
+		}
+		webhooks = append(webhooks, w)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	types, err := listWebhookEventTypes(ctx, q, 0)
+	if err != nil {
+		return nil, err
+	}
+	for i := range webhooks {
+		webhooks[i].EventTypes = types[webhooks[i].ID]
+	}
+
+	return webhooks, nil
+}
+
+func (r *WebhookRepository) Update(ctx context.Context, w *domain.Webhook) error {
+	const query = `
+		UPDATE webhooks
+		SET url = $2,
+		    secret = $3,
+		    is_active = $4,
+		    updated_at = $5
+		WHERE id = $1
+		RETURNING updated_at
+	`
+
+	return inTx(ctx, r.db, func(q querier) error {
+		if err := q.QueryRowContext(ctx, query, w.ID, w.URL, w.Secret, w.IsActive, formatTime(time.Now())).Scan(&w.UpdatedAt); err != nil {
+			return err
+		}
+
+		if _, err := q.ExecContext(ctx, `DELETE FROM webhook_event_types WHERE webhook_id = $1`, w.ID); err != nil {
+			return err
+		}
+		return insertWebhookEventTypes(ctx, q, w.ID, w.EventTypes)
+	})
+}
+
+func (r *WebhookRepository) Delete(ctx context.Context, id int64) error {
+	const query = `
+		DELETE FROM webhooks
+		WHERE id = $1
+	`
+
+	res, err := conn(ctx, r.db).ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+func (r *WebhookRepository) CreateDelivery(ctx context.Context, d *domain.WebhookDelivery) error {
+	const query = `
+		INSERT INTO webhook_deliveries (webhook_id, event_id, event_type, redelivery_of, next_attempt_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $5)
+		RETURNING id, status, attempts, next_attempt_at, created_at
+	`
+
+	var redeliveryOf any
+	if d.RedeliveryOf != nil {
+		redeliveryOf = *d.RedeliveryOf
+	}
+
+	d.ResponseStatus = 0
+	d.LastError = ""
+	d.DeliveredAt = nil
+
+	return conn(ctx, r.db).QueryRowContext(ctx, query, d.WebhookID, d.EventID, d.EventType, redeliveryOf, formatTime(time.Now())).
+		Scan(&d.ID, &d.Status, &d.Attempts, &d.NextAttemptAt, &d.CreatedAt)
+}
+
+const selectWebhookDelivery = `
+	SELECT id, webhook_id, event_id, event_type, status, attempts,
+	       COALESCE(response_status, 0), COALESCE(last_error, ''), redelivery_of,
+	       next_attempt_at, created_at, delivered_at
+	FROM webhook_deliveries
+`
+
+func (r *WebhookRepository) GetDelivery(ctx context.Context, id int64) (*domain.WebhookDelivery, error) {
+	row := conn(ctx, r.db).QueryRowContext(ctx, selectWebhookDelivery+" WHERE id = $1", id)
+
+	d, err := scanWebhookDelivery(row)
+	if err != nil {
+		return nil, err
+	}
+
+	return d, nil
+}
+
+func (r *WebhookRepository) ListDeliveries(ctx context.Context, filter domain.WebhookDeliveryFilter) ([]domain.WebhookDelivery, error) {
+	var (
+		conds []string
+		args  []any
+	)
+	addCond := func(cond string, arg any) {
+		args = append(args, arg)
+		conds = append(conds, fmt.Sprintf(cond, len(args)))
+	}
+
+	if filter.WebhookID > 0 {
+		addCond("webhook_id = $%d", filter.WebhookID)
+	}
+	if filter.Status != "" {
+		addCond("status = $%d", filter.Status)
+	}
+	if filter.BeforeID > 0 {
+		addCond("id < $%d", filter.BeforeID)
+	}
+
+	query := selectWebhookDelivery
+	if len(conds) > 0 {
+		query += " WHERE " + strings.Join(conds, " AND ")
+	}
+	query += " ORDER BY id DESC"
+	if filter.Limit > 0 {
+		args = append(args, filter.Limit)
+		query += fmt.Sprintf(" LIMIT $%d", len(args))
+	}
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var deliveries []domain.WebhookDelivery
+	for rows.Next() {
+		d, err := scanWebhookDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, *d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return deliveries, nil
+}
+
+// EnqueueDeliveries creates a pending delivery of e for every active
+// webhook subscribed to its type. Repeated calls for the same event
+// do not create duplicates.
+func (r *WebhookRepository) EnqueueDeliveries(ctx context.Context, e domain.OutboxEvent) error {
+	const query = `
+		INSERT INTO webhook_deliveries (webhook_id, event_id, event_type, next_attempt_at, created_at)
+		SELECT w.id, $1, $2, $3, $3
+		FROM webhooks w
+		JOIN webhook_event_types t ON t.webhook_id = w.id
+		WHERE w.is_active AND t.event_type = $2
+		ON CONFLICT DO NOTHING
+	`
+
+	_, err := conn(ctx, r.db).ExecContext(ctx, query, e.ID, e.Type, formatTime(time.Now()))
+	return err
+}
+
+func (r *WebhookRepository) ClaimDeliveries(ctx context.Context, limit int, lease time.Duration) ([]domain.WebhookJob, error) {
+	// The single connection of the pool serializes claims,
+	// so no row locking is needed.
+	const claim = `
+		UPDATE webhook_deliveries
+		SET attempts = attempts + 1,
+		    next_attempt_at = $3
+		WHERE id IN (
+			SELECT d.id
+			FROM webhook_deliveries d
+			JOIN webhooks w ON w.id = d.webhook_id
+			WHERE d.status = 'pending' AND d.next_attempt_at <= $2 AND w.is_active
+			ORDER BY d.id
+			LIMIT $1
+		)
+		RETURNING id
+	`
+
+	const query = `
+		SELECT d.id, d.webhook_id, d.event_id, d.event_type, d.status, d.attempts,
+		       COALESCE(d.response_status, 0), COALESCE(d.last_error, ''), d.redelivery_of,
+		       d.next_attempt_at, d.created_at, d.delivered_at,
+		       w.url, w.secret, e.payload, e.request_id, e.created_at
+		FROM webhook_deliveries d
+		JOIN webhooks w ON w.id = d.webhook_id
+		JOIN outbox_events e ON e.id = d.event_id
+		WHERE d.id = $1
+	`
+
+	var jobs []domain.WebhookJob
+	err := inTx(ctx, r.db, func(q querier) error {
+		now := time.Now()
+		rows, err := q.QueryContext(ctx, claim, limit, formatTime(now), formatTime(now.Add(lease)))
+		if err != nil {
+			return err
+		}
+
+		var ids []int64
+		for rows.Next() {
+			var id int64
+			if err := rows.Scan(&id); err != nil {
+				_ = rows.Close()
+				return err
+			}
+			ids = append(ids, id)
+		}
+		if err := rows.Close(); err != nil {
+			return err
+		}
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+		for _, id := range ids {
+			var (
+				job          domain.WebhookJob
+				d            = &job.Delivery
+				redeliveryOf sql.NullInt64
+				deliveredAt  sql.NullTime
+			)
+			if err := q.QueryRowContext(ctx, query, id).Scan(
+				&d.ID, &d.WebhookID, &d.EventID, &d.EventType, &d.Status, &d.Attempts,
+				&d.ResponseStatus, &d.LastError, &redeliveryOf,
+				&d.NextAttemptAt, &d.CreatedAt, &deliveredAt,
+				&job.URL, &job.Secret, &job.Event.Payload, &job.Event.RequestID, &job.Event.CreatedAt,
+			); err != nil {
+				return err
+			}
+			setDeliveryNullables(d, redeliveryOf, deliveredAt)
+			job.Event.ID = d.EventID
+			job.Event.Type = d.EventType
+			jobs = append(jobs, job)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return jobs, nil
+}
+
+func (r *WebhookRepository) MarkDeliverySucceeded(ctx context.Context, id int64, responseStatus int) error {
+	const query = `
+		UPDATE webhook_deliveries
+		SET status = 'succeeded',
+		    response_status = $2,
+		    last_error = NULL,
+		    delivered_at = $3
+		WHERE id = $1
+	`
+
+	_, err := conn(ctx, r.db).ExecContext(ctx, query, id, responseStatus, formatTime(time.Now()))
+	return err
+}
+
+func (r *WebhookRepository) MarkDeliveryFailed(
+	ctx context.Context,
+	id int64,
+	responseStatus int,
+	lastErr string,
+	retryAt time.Time,
+	dead bool,
+) error {
+	const query = `
+		UPDATE webhook_deliveries
+		SET status = $2,
+		    response_status = $3,
+		    last_error = $4,
+		    next_attempt_at = $5
+		WHERE id = $1
+	`
+
+	status := domain.WebhookDeliveryPending
+	if dead {
+		status = domain.WebhookDeliveryFailed
+	}
+
+	_, err := conn(ctx, r.db).ExecContext(ctx, query, id, status, nullableInt(responseStatus), lastErr, formatTime(retryAt))
+	return err
+}
+
+func insertWebhookEventTypes(ctx context.Context, q querier, webhookID int64, types []domain.EventType) error {
+	const query = `
+		INSERT INTO webhook_event_types (webhook_id, event_type)
+		VALUES ($1, $2)
+		ON CONFLICT DO NOTHING
+	`
+
+	for _, t := range types {
+		if _, err := q.ExecContext(ctx, query, webhookID, t); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// listWebhookEventTypes returns event types by webhook id,
+// for a single webhook or for all of them if webhookID is 0.
+func listWebhookEventTypes(ctx context.Context, q querier, webhookID int64) (map[int64][]domain.EventType, error) {
+	const query = `
+		SELECT webhook_id, event_type
+		FROM webhook_event_types
+		WHERE $1 = 0 OR webhook_id = $1
+		ORDER BY webhook_id, event_type
+	`
+
+	rows, err := q.QueryContext(ctx, query, webhookID)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	types := make(map[int64][]domain.EventType)
+	for rows.Next() {
+		var (
+			id int64
+			t  domain.EventType
+		)
+		if err := rows.Scan(&id, &t); err != nil {
+			return nil, err
+		}
+		types[id] = append(types[id], t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return types, nil
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanWebhookDelivery(row rowScanner) (*domain.WebhookDelivery, error) {
+	var (
+		d            domain.WebhookDelivery
+		redeliveryOf sql.NullInt64
+		deliveredAt  sql.NullTime
+	)
+	if err := row.Scan(
+		&d.ID, &d.WebhookID, &d.EventID, &d.EventType, &d.Status, &d.Attempts,
+		&d.ResponseStatus, &d.LastError, &redeliveryOf,
+		&d.NextAttemptAt, &d.CreatedAt, &deliveredAt,
+	); err != nil {
+		return nil, err
+	}
+	setDeliveryNullables(&d, redeliveryOf, deliveredAt)
+	return &d, nil
+}
+
+func setDeliveryNullables(d *domain.WebhookDelivery, redeliveryOf sql.NullInt64, deliveredAt sql.NullTime) {
+	if redeliveryOf.Valid {
+		id := redeliveryOf.Int64
+		d.RedeliveryOf = &id
+	}
+	if deliveredAt.Valid {
+		t := deliveredAt.Time
+		d.DeliveredAt = &t
+	}
+}
+
+// nullableInt maps zero to SQL NULL.
+func nullableInt(n int) any {
+	if n == 0 {
+		return nil
+	}
+	return n
+}
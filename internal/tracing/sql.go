@@ -0,0 +1,98 @@
+package tracing
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var sqlTracer = otel.Tracer("github.com/terps489/avito_tech_internship/internal/tracing")
+
+// Querier is implemented by both *sql.DB and *sql.Tx.
+type Querier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// SQL wraps q so that every query made within a span gets a child client
+// span. Queries outside of any span, such as the background pollers of
+// the outbox and webhooks, are not traced so that each poll does not start
+// a trace of its own. system is the db.system attribute, e.g.
+// semconv.DBSystemPostgreSQL. Spans of QueryContext end when the first
+// result is available, not when the rows are closed; argument values are
+// not recorded.
+func SQL(q Querier, system attribute.KeyValue) Querier {
+	return tracedQuerier{q: q, system: system}
+}
+
+type tracedQuerier struct {
+	q      Querier
+	system attribute.KeyValue
+}
+
+func (t tracedQuerier) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	ctx, span := t.start(ctx, query)
+	defer span.End()
+
+	res, err := t.q.ExecContext(ctx, query, args...)
+	recordError(span, err)
+	return res, err
+}
+
+func (t tracedQuerier) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	ctx, span := t.start(ctx, query)
+	defer span.End()
+
+	rows, err := t.q.QueryContext(ctx, query, args...)
+	recordError(span, err)
+	return rows, err
+}
+
+func (t tracedQuerier) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	ctx, span := t.start(ctx, query)
+	defer span.End()
+
+	row := t.q.QueryRowContext(ctx, query, args...)
+	recordError(span, row.Err())
+	return row
+}
+
+func (t tracedQuerier) start(ctx context.Context, query string) (context.Context, trace.Span) {
+	if !trace.SpanContextFromContext(ctx).IsValid() {
+		return ctx, trace.SpanFromContext(ctx)
+	}
+
+	op := operation(query)
+	return sqlTracer.Start(ctx, op,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			t.system,
+			semconv.DBOperationName(op),
+			semconv.DBQueryText(strings.TrimSpace(query)),
+		),
+	)
+}
+
+func recordError(span trace.Span, err error) {
+	if err == nil {
+		return
+	}
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
+
+// operation returns the leading keyword of query, e.g. SELECT.
+func operation(query string) string {
+	fields := strings.Fields(query)
+	if len(fields) == 0 {
+		return "SQL"
+	}
+	return strings.ToUpper(fields[0])
+}
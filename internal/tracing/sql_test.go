@@ -0,0 +1,68 @@
+package tracing_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+
+	"github.com/terps489/avito_tech_internship/internal/repository/sqlite"
+	"github.com/terps489/avito_tech_internship/internal/tracing"
+)
+
+func TestSQLSpans(t *testing.T) {
+	db, err := sqlite.Open(filepath.Join(t.TempDir(), "trace.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	ctx, parent := tp.Tracer("test").Start(context.Background(), "parent")
+
+	// The package tracer resolves through the global provider.
+	otel.SetTracerProvider(tp)
+
+	q := tracing.SQL(db, semconv.DBSystemSqlite)
+	if _, err := q.ExecContext(context.Background(), "SELECT 0"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := q.ExecContext(ctx, "SELECT 1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := q.ExecContext(ctx, "  select * from missing_table"); err == nil {
+		t.Fatal("expected an error for a missing table")
+	}
+	parent.End()
+
+	spans := recorder.Ended()
+	if len(spans) != 3 {
+		t.Fatalf("got %d spans, want 3", len(spans))
+	}
+
+	ok, failed := spans[0], spans[1]
+	if ok.Name() != "SELECT" || failed.Name() != "SELECT" {
+		t.Errorf("span names = %q, %q, want SELECT", ok.Name(), failed.Name())
+	}
+	if ok.Parent().SpanID() != parent.SpanContext().SpanID() {
+		t.Error("query span is not a child of the caller's span")
+	}
+	if ok.Status().Code.String() != "Unset" || failed.Status().Code.String() != "Error" {
+		t.Errorf("statuses = %v, %v, want Unset, Error", ok.Status().Code, failed.Status().Code)
+	}
+
+	var text string
+	for _, a := range failed.Attributes() {
+		if a.Key == semconv.DBQueryTextKey {
+			text = a.Value.AsString()
+		}
+	}
+	if text != "select * from missing_table" {
+		t.Errorf("db.query.text = %q", text)
+	}
+}
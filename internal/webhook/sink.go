@@ -0,0 +1,28 @@
+package webhook
+
+import (
+	"context"
+
+	"github.com/terps489/avito_tech_internship/internal/domain"
+)
+
+// FanoutSink is an outbox sink that enqueues webhook deliveries.
+// Events that cannot be subscribed to are skipped.
+type FanoutSink struct {
+	store Store
+}
+
+func NewFanoutSink(store Store) *FanoutSink {
+	return &FanoutSink{store: store}
+}
+
+func (s *FanoutSink) Name() string {
+	return "webhooks"
+}
+
+func (s *FanoutSink) Deliver(ctx context.Context, e domain.OutboxEvent) error {
+	if !domain.IsWebhookEventType(e.Type) {
+		return nil
+	}
+	return s.store.EnqueueDeliveries(ctx, e)
+}
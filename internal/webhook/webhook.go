@@ -0,0 +1,60 @@
+// Package webhook delivers domain events to the webhook subscriptions
+// managed through app.Service.
+//
+// Delivery has two steps. FanoutSink runs as an outbox sink and turns
+// every subscribed event into one pending delivery per matching webhook.
+// Worker then sends pending deliveries, signing each request with the
+// webhook secret, and retries failures with exponential backoff.
+package webhook
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"time"
+
+	"github.com/terps489/avito_tech_internship/internal/domain"
+)
+
+// Request headers set on every delivery.
+const (
+	HeaderWebhookID = "X-Webhook-ID"
+	HeaderDelivery  = "X-Webhook-Delivery"
+	HeaderEventID   = "X-Event-ID"
+	HeaderEventType = "X-Event-Type"
+	HeaderTimestamp = "X-Webhook-Timestamp"
+	HeaderSignature = "X-Webhook-Signature"
+)
+
+// Store is the delivery side of app.WebhookRepository.
+type Store interface {
+	// EnqueueDeliveries creates a pending delivery of e for every active
+	// webhook subscribed to its type. It is idempotent per event.
+	EnqueueDeliveries(ctx context.Context, e domain.OutboxEvent) error
+	// ClaimDeliveries leases up to limit due deliveries of active webhooks
+	// and increments their attempt counter.
+	ClaimDeliveries(ctx context.Context, limit int, lease time.Duration) ([]domain.WebhookJob, error)
+	MarkDeliverySucceeded(ctx context.Context, id int64, responseStatus int) error
+	// MarkDeliveryFailed schedules a retry at retryAt, or gives up if dead is set.
+	// responseStatus is 0 when no response was received.
+	MarkDeliveryFailed(ctx context.Context, id int64, responseStatus int, lastErr string, retryAt time.Time, dead bool) error
+}
+
+// Sign returns the X-Webhook-Signature value for a request body sent
+// at timestamp: "sha256=" followed by the hex HMAC-SHA256 of
+// "<timestamp>.<body>" keyed with the webhook secret.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify reports whether signature is valid for body and timestamp.
+// Receivers should also reject timestamps that are too old.
+func Verify(secret string, timestamp int64, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, timestamp, body)), []byte(signature))
+}
@@ -0,0 +1,195 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/terps489/avito_tech_internship/internal/domain"
+	"github.com/terps489/avito_tech_internship/internal/outbox"
+)
+
+// maxErrorLength bounds the error text kept in the delivery log.
+const maxErrorLength = 1024
+
+type Config struct {
+	PollInterval time.Duration
+	BatchSize    int
+	// Concurrency is the number of deliveries sent in parallel.
+	Concurrency int
+	// Lease is how long a claimed delivery stays hidden from other workers.
+	// It must exceed Timeout.
+	Lease       time.Duration
+	Timeout     time.Duration
+	MaxAttempts int
+	BaseBackoff time.Duration
+	MaxBackoff  time.Duration
+}
+
+func DefaultConfig() Config {
+	return Config{
+		PollInterval: time.Second,
+		BatchSize:    50,
+		Concurrency:  4,
+		Lease:        time.Minute,
+		Timeout:      10 * time.Second,
+		MaxAttempts:  8,
+		BaseBackoff:  10 * time.Second,
+		MaxBackoff:   time.Hour,
+	}
+}
+
+type Worker struct {
+	store  Store
+	client *http.Client
+	cfg    Config
+	now    func() time.Time
+}
+
+func NewWorker(store Store, cfg Config) *Worker {
+	return &Worker{
+		store:  store,
+		client: &http.Client{Timeout: cfg.Timeout},
+		cfg:    cfg,
+		now:    time.Now,
+	}
+}
+
+// Run sends deliveries until ctx is canceled.
+func (w *Worker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		n, err := w.DeliverOnce(ctx)
+		if err != nil && !errors.Is(err, context.Canceled) {
+			slog.ErrorContext(ctx, "webhook: delivery failed", slog.Any("error", err))
+		}
+
+		// A full batch means there is probably more work waiting.
+		if err == nil && n == w.cfg.BatchSize {
+			if ctx.Err() != nil {
+				return
+			}
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// DeliverOnce claims one batch of deliveries and sends it.
+// It returns the number of claimed deliveries.
+func (w *Worker) DeliverOnce(ctx context.Context) (int, error) {
+	jobs, err := w.store.ClaimDeliveries(ctx, w.cfg.BatchSize, w.cfg.Lease)
+	if err != nil {
+		return 0, err
+	}
+
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs []error
+		sem  = make(chan struct{}, max(w.cfg.Concurrency, 1))
+	)
+	for _, job := range jobs {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			if err := w.deliver(ctx, job); err != nil {
+				mu.Lock()
+				errs = append(errs, err)
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	return len(jobs), errors.Join(errs...)
+}
+
+// deliver sends a job and records the outcome. Only storage
+// errors are returned; a failed request is a failed attempt.
+func (w *Worker) deliver(ctx context.Context, job domain.WebhookJob) error {
+	d := job.Delivery
+
+	status, sendErr := w.send(ctx, job)
+	if sendErr == nil {
+		return w.store.MarkDeliverySucceeded(ctx, d.ID, status)
+	}
+
+	dead := d.Attempts >= w.cfg.MaxAttempts
+	if dead {
+		slog.ErrorContext(ctx, "webhook: delivery dropped",
+			slog.Int64("delivery_id", d.ID),
+			slog.Int64("webhook_id", d.WebhookID),
+			slog.Int64("event_id", d.EventID),
+			slog.Int("attempts", d.Attempts),
+			slog.Int("response_status", status),
+			slog.Any("error", sendErr),
+		)
+	}
+
+	msg := sendErr.Error()
+	if len(msg) > maxErrorLength {
+		msg = msg[:maxErrorLength]
+	}
+
+	retryAt := w.now().Add(outbox.Backoff(d.Attempts, w.cfg.BaseBackoff, w.cfg.MaxBackoff))
+	return w.store.MarkDeliveryFailed(ctx, d.ID, status, msg, retryAt, dead)
+}
+
+// send posts the event envelope and returns the response status,
+// or 0 if no response was received.
+func (w *Worker) send(ctx context.Context, job domain.WebhookJob) (int, error) {
+	body, err := json.Marshal(outbox.NewEnvelope(job.Event))
+	if err != nil {
+		return 0, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, job.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+
+	timestamp := w.now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "reviewer-service-webhooks")
+	req.Header.Set(HeaderWebhookID, strconv.FormatInt(job.Delivery.WebhookID, 10))
+	req.Header.Set(HeaderDelivery, strconv.FormatInt(job.Delivery.ID, 10))
+	req.Header.Set(HeaderEventID, strconv.FormatInt(job.Event.ID, 10))
+	req.Header.Set(HeaderEventType, string(job.Event.Type))
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderSignature, Sign(job.Secret, timestamp, body))
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("webhook responded with %s", resp.Status)
+	}
+
+	return resp.StatusCode, nil
+}
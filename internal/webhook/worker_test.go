@@ -0,0 +1,164 @@
+package webhook_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/terps489/avito_tech_internship/internal/domain"
+	"github.com/terps489/avito_tech_internship/internal/outbox"
+	"github.com/terps489/avito_tech_internship/internal/repository/memory"
+	"github.com/terps489/avito_tech_internship/internal/webhook"
+)
+
+type receiver struct {
+	mu       sync.Mutex
+	failures int
+	bodies   []outbox.Envelope
+	verified []bool
+}
+
+func (rc *receiver) handler(secret string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		rc.mu.Lock()
+		defer rc.mu.Unlock()
+
+		if rc.failures > 0 {
+			rc.failures--
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		body, _ := io.ReadAll(r.Body)
+		ts, _ := strconv.ParseInt(r.Header.Get(webhook.HeaderTimestamp), 10, 64)
+		rc.verified = append(rc.verified, webhook.Verify(secret, ts, body, r.Header.Get(webhook.HeaderSignature)))
+
+		var env outbox.Envelope
+		_ = json.Unmarshal(body, &env)
+		rc.bodies = append(rc.bodies, env)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func setup(t *testing.T, url string) (*memory.WebhookRepository, domain.OutboxEvent) {
+	t.Helper()
+	ctx := t.Context()
+	store := memory.NewStore()
+	webhooks := memory.NewWebhookRepository(store)
+	events := memory.NewOutboxRepository(store)
+
+	if err := webhooks.Create(ctx, &domain.Webhook{
+		URL:        url,
+		Secret:     "s3cret",
+		EventTypes: []domain.EventType{domain.EventPRMerged},
+		IsActive:   true,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	e := domain.OutboxEvent{Type: domain.EventPRMerged, Payload: []byte(`{"pull_request_id":"pr-1"}`)}
+	if err := events.Append(ctx, &e); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := webhook.NewFanoutSink(webhooks).Deliver(ctx, e); err != nil {
+		t.Fatal(err)
+	}
+	return webhooks, e
+}
+
+func testConfig() webhook.Config {
+	cfg := webhook.DefaultConfig()
+	cfg.MaxAttempts = 2
+	cfg.BaseBackoff = 0
+	cfg.MaxBackoff = 0
+	return cfg
+}
+
+func deliverOnce(t *testing.T, w *webhook.Worker) int {
+	t.Helper()
+	n, err := w.DeliverOnce(context.Background())
+	if err != nil {
+		t.Fatalf("DeliverOnce: %v", err)
+	}
+	return n
+}
+
+func TestWorkerSignsAndRetries(t *testing.T) {
+	rc := &receiver{failures: 1}
+	srv := httptest.NewServer(rc.handler("s3cret"))
+	defer srv.Close()
+
+	repo, e := setup(t, srv.URL)
+	w := webhook.NewWorker(repo, testConfig())
+
+	if n := deliverOnce(t, w); n != 1 {
+		t.Fatalf("first attempt claimed %d deliveries, want 1", n)
+	}
+	if n := deliverOnce(t, w); n != 1 {
+		t.Fatalf("retry claimed %d deliveries, want 1", n)
+	}
+	if n := deliverOnce(t, w); n != 0 {
+		t.Fatalf("claimed %d deliveries after success, want 0", n)
+	}
+
+	if len(rc.bodies) != 1 || rc.bodies[0].ID != e.ID || rc.bodies[0].Type != string(domain.EventPRMerged) {
+		t.Fatalf("received %+v, want event %d once", rc.bodies, e.ID)
+	}
+	if !rc.verified[0] {
+		t.Fatal("signature did not verify")
+	}
+
+	deliveries, err := repo.ListDeliveries(t.Context(), domain.WebhookDeliveryFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := deliveries[0]
+	if d.Status != domain.WebhookDeliverySucceeded || d.Attempts != 2 || d.ResponseStatus != http.StatusNoContent {
+		t.Fatalf("delivery = %+v, want succeeded on attempt 2", d)
+	}
+}
+
+func TestWorkerGivesUp(t *testing.T) {
+	rc := &receiver{failures: 10}
+	srv := httptest.NewServer(rc.handler("s3cret"))
+	defer srv.Close()
+
+	repo, _ := setup(t, srv.URL)
+	w := webhook.NewWorker(repo, testConfig())
+
+	deliverOnce(t, w)
+	deliverOnce(t, w)
+	if n := deliverOnce(t, w); n != 0 {
+		t.Fatalf("claimed %d deliveries after giving up, want 0", n)
+	}
+
+	deliveries, err := repo.ListDeliveries(t.Context(), domain.WebhookDeliveryFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := deliveries[0]
+	if d.Status != domain.WebhookDeliveryFailed || d.ResponseStatus != http.StatusServiceUnavailable || d.LastError == "" {
+		t.Fatalf("delivery = %+v, want failed with the last response", d)
+	}
+}
+
+func TestSignature(t *testing.T) {
+	body := []byte(`{"id":1}`)
+	sig := webhook.Sign("secret", 1700000000, body)
+
+	if !webhook.Verify("secret", 1700000000, body, sig) {
+		t.Fatal("Verify rejected a valid signature")
+	}
+	if webhook.Verify("other", 1700000000, body, sig) {
+		t.Fatal("Verify accepted a signature made with another secret")
+	}
+	if webhook.Verify("secret", 1700000001, body, sig) {
+		t.Fatal("Verify accepted a signature for another timestamp")
+	}
+}
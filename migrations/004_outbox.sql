@@ -0,0 +1,22 @@
+CREATE TABLE outbox_events (
+    id              BIGSERIAL PRIMARY KEY,
+    event_type      TEXT NOT NULL,
+    payload         JSONB NOT NULL,
+    request_id      TEXT NOT NULL DEFAULT '',
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    status          TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'processed', 'dead')),
+    attempts        INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_error      TEXT,
+    processed_at    TIMESTAMPTZ
+);
+
+CREATE INDEX outbox_events_pending_idx ON outbox_events (next_attempt_at, id)
+    WHERE status = 'pending';
+
+CREATE TABLE outbox_deliveries (
+    event_id     BIGINT NOT NULL REFERENCES outbox_events(id) ON DELETE CASCADE,
+    sink         TEXT NOT NULL,
+    delivered_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (event_id, sink)
+);
@@ -0,0 +1,9 @@
+CREATE TABLE external_accounts (
+    provider   TEXT NOT NULL CHECK (provider IN ('github', 'gitlab')),
+    login      TEXT NOT NULL,
+    user_id    TEXT NOT NULL REFERENCES users(user_id),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (provider, login)
+);
+
+CREATE INDEX external_accounts_user_idx ON external_accounts (user_id);
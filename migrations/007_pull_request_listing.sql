@@ -0,0 +1,5 @@
+CREATE INDEX pull_request_reviewers_reviewer_idx ON pull_request_reviewers (reviewer_id, pr_id);
+
+CREATE INDEX pull_requests_created_idx ON pull_requests (created_at, pull_request_id);
+
+CREATE INDEX pull_requests_status_created_idx ON pull_requests (status, created_at, pull_request_id);
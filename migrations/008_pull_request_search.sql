@@ -0,0 +1,7 @@
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE INDEX pull_requests_author_created_idx ON pull_requests (author_id, created_at, pull_request_id);
+
+CREATE INDEX pull_requests_name_trgm_idx ON pull_requests USING gin (pull_request_name gin_trgm_ops);
+
+CREATE INDEX users_team_idx ON users (team_name);
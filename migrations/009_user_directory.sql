@@ -0,0 +1,3 @@
+CREATE INDEX users_username_idx ON users (username COLLATE "C", user_id COLLATE "C");
+
+CREATE INDEX users_username_lower_idx ON users (lower(username) text_pattern_ops);
@@ -0,0 +1,18 @@
+-- Records applied migrations so that /readyz can tell whether the schema
+-- is current. Every later migration must insert its own version.
+CREATE TABLE schema_migrations (
+    version    TEXT PRIMARY KEY,
+    applied_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+INSERT INTO schema_migrations (version) VALUES
+    ('001_init'),
+    ('002_audit'),
+    ('003_reviewer_events'),
+    ('004_outbox'),
+    ('005_webhooks'),
+    ('006_external_accounts'),
+    ('007_pull_request_listing'),
+    ('008_pull_request_search'),
+    ('009_user_directory'),
+    ('010_schema_migrations');
@@ -0,0 +1,12 @@
+CREATE TABLE api_tokens (
+    id         BIGSERIAL PRIMARY KEY,
+    name       TEXT NOT NULL,
+    token_hash TEXT NOT NULL UNIQUE,
+    role       TEXT NOT NULL CHECK (role IN ('admin', 'team_lead', 'developer')),
+    team_name  TEXT REFERENCES teams(team_name),
+    user_id    TEXT REFERENCES users(user_id),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    revoked_at TIMESTAMPTZ
+);
+
+INSERT INTO schema_migrations (version) VALUES ('011_api_tokens');
@@ -0,0 +1,14 @@
+CREATE TABLE idempotency_keys (
+    scope           TEXT NOT NULL,
+    idempotency_key TEXT NOT NULL,
+    request_hash    TEXT NOT NULL,
+    status          INTEGER NOT NULL DEFAULT 0,
+    body            BYTEA,
+    created_at      TIMESTAMPTZ NOT NULL,
+    expires_at      TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (scope, idempotency_key)
+);
+
+CREATE INDEX idempotency_keys_expires_idx ON idempotency_keys (expires_at);
+
+INSERT INTO schema_migrations (version) VALUES ('012_idempotency_keys');
@@ -1,8 +1,12 @@
 $ErrorActionPreference = "Stop"
 $baseUrl = "http://localhost:8080"
+$headers = @{}
+if ($env:API_TOKEN) {
+    $headers["Authorization"] = "Bearer $env:API_TOKEN"
+}
 
 Write-Host "=== 1. Checking /health ==="
-$health = Invoke-WebRequest -Uri "$baseUrl/health" -Method GET
+$health = Invoke-WebRequest -Headers $headers -Uri "$baseUrl/health" -Method GET
 Write-Host "health status:" $health.StatusCode
 if ($health.StatusCode -ne 200) {
     Write-Error "ERROR: /health returned $($health.StatusCode)"
@@ -11,7 +15,7 @@ if ($health.StatusCode -ne 200) {
 
 Write-Host ""
 Write-Host "=== 2. Checking /stats/assignments ==="
-$stats = Invoke-WebRequest -Uri "$baseUrl/stats/assignments" -Method GET
+$stats = Invoke-WebRequest -Headers $headers -Uri "$baseUrl/stats/assignments" -Method GET
 $stats.Content | Write-Host
 
 Write-Host ""
@@ -34,7 +38,7 @@ for ($i = 1; $i -le 10; $i++) {
     } | ConvertTo-Json
 
     try {
-        $createResp = Invoke-WebRequest -Uri "$baseUrl/pullRequest/create" `
+        $createResp = Invoke-WebRequest -Headers $headers -Uri "$baseUrl/pullRequest/create" `
             -Method POST `
             -ContentType "application/json" `
             -Body $createBody `
@@ -49,7 +53,7 @@ for ($i = 1; $i -le 10; $i++) {
     if ($createCode -eq 201 -or $createCode -eq 409) {
         $mergeBody = @{ pull_request_id = $prId } | ConvertTo-Json
         try {
-            $mergeResp = Invoke-WebRequest -Uri "$baseUrl/pullRequest/merge" `
+            $mergeResp = Invoke-WebRequest -Headers $headers -Uri "$baseUrl/pullRequest/merge" `
                 -Method POST `
                 -ContentType "application/json" `
                 -Body $mergeBody `
@@ -86,7 +90,7 @@ Write-Host "=== 4. Checking /users/getReview for several users ==="
 foreach ($uid in @("u1", "u2", "u3", "u4", "u5")) {
     Write-Host "--- user_id=$uid ---"
     try {
-        $resp = Invoke-WebRequest -Uri "$baseUrl/users/getReview?user_id=$uid" `
+        $resp = Invoke-WebRequest -Headers $headers -Uri "$baseUrl/users/getReview?user_id=$uid" `
             -Method GET `
             -ErrorAction Stop
         $code = $resp.StatusCode
@@ -107,7 +111,7 @@ foreach ($uid in @("u1", "u2", "u3", "u4", "u5")) {
 
 Write-Host ""
 Write-Host "=== 5. Final /stats/assignments ==="
-$stats2 = Invoke-WebRequest -Uri "$baseUrl/stats/assignments" -Method GET
+$stats2 = Invoke-WebRequest -Headers $headers -Uri "$baseUrl/stats/assignments" -Method GET
 $stats2.Content | Write-Host
 
 Write-Host "=== POST-CHECK OK ==="